@@ -0,0 +1,148 @@
+// Package autoscale turns the prediction traffic observed by a client into
+// scaling signals for a J.A.M.S deployment. The signals are meant to be served
+// by a KEDA external scaler or read by a custom HPA controller.
+package autoscale
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+const defaultWindow = 10 * time.Second
+
+// Source provides the stats the signals are computed from. *jams.Client
+// implements it.
+type Source interface {
+	Stats() jams.Stats
+}
+
+// Config describes the capacity of a single server replica.
+type Config struct {
+	// TargetRate is the number of predictions per second a single replica
+	// should serve. Zero disables the rate signal.
+	TargetRate float64
+	// TargetInFlight is the number of concurrent predictions a single replica
+	// should serve. Zero disables the concurrency signal.
+	TargetInFlight float64
+	// MinReplicas and MaxReplicas bound DesiredReplicas.
+	MinReplicas int
+	MaxReplicas int
+	// Window is the minimum interval over which the prediction rate is
+	// measured. Defaults to 10 seconds.
+	Window time.Duration
+}
+
+// Provider computes scaling signals from the Predict calls of a Source. It is
+// safe for concurrent use.
+type Provider struct {
+	source Source
+	config Config
+
+	mu       sync.Mutex
+	sampled  time.Time
+	requests uint64
+	rate     float64
+}
+
+// New returns a Provider for the given source.
+func New(source Source, config Config) (*Provider, error) {
+	if config.TargetRate <= 0 && config.TargetInFlight <= 0 {
+		return nil, errors.New("at least one of TargetRate or TargetInFlight must be set")
+	}
+	if config.MinReplicas < 0 || config.MaxReplicas < config.MinReplicas {
+		return nil, errors.New("replica bounds must satisfy 0 <= MinReplicas <= MaxReplicas")
+	}
+	if config.MaxReplicas == 0 {
+		return nil, errors.New("MaxReplicas must be greater than zero")
+	}
+	if config.Window <= 0 {
+		config.Window = defaultWindow
+	}
+
+	p := &Provider{source: source, config: config}
+	p.sampled = time.Now()
+	p.requests, _ = predictCounters(source.Stats())
+	return p, nil
+}
+
+// Rate returns the prediction rate per second measured over the last window.
+func (p *Provider) Rate() float64 {
+	rate, _ := p.sample()
+	return rate
+}
+
+// InFlight returns the number of predictions currently in flight.
+func (p *Provider) InFlight() int64 {
+	_, inFlight := p.sample()
+	return inFlight
+}
+
+// DesiredReplicas returns the number of replicas needed to serve the current
+// load, bounded by MinReplicas and MaxReplicas.
+func (p *Provider) DesiredReplicas() int {
+	desired := int(math.Ceil(p.load()))
+	if desired < p.config.MinReplicas {
+		return p.config.MinReplicas
+	}
+	if desired > p.config.MaxReplicas {
+		return p.config.MaxReplicas
+	}
+	return desired
+}
+
+// Saturation returns the current load as a fraction of the capacity of
+// MaxReplicas replicas. Values above 1 mean the deployment cannot serve the
+// load even when fully scaled out.
+func (p *Provider) Saturation() float64 {
+	return p.load() / float64(p.config.MaxReplicas)
+}
+
+// load returns the current load expressed in replicas.
+func (p *Provider) load() float64 {
+	rate, inFlight := p.sample()
+
+	var load float64
+	if p.config.TargetRate > 0 {
+		load = rate / p.config.TargetRate
+	}
+	if p.config.TargetInFlight > 0 {
+		load = math.Max(load, float64(inFlight)/p.config.TargetInFlight)
+	}
+	return load
+}
+
+// sample reads the source and returns the prediction rate and in flight count.
+// The rate is only recomputed once per window.
+func (p *Provider) sample() (float64, int64) {
+	requests, inFlight := predictCounters(p.source.Stats())
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if elapsed := now.Sub(p.sampled); elapsed >= p.config.Window {
+		p.rate = float64(requests-p.requests) / elapsed.Seconds()
+		p.requests = requests
+		p.sampled = now
+	}
+	return p.rate, inFlight
+}
+
+// predictCounters sums the Predict request and in flight counters across
+// models.
+func predictCounters(stats jams.Stats) (uint64, int64) {
+	var requests uint64
+	var inFlight int64
+	for _, call := range stats.Calls {
+		if call.Method != jams.MethodPredict {
+			continue
+		}
+		requests += call.Requests
+		inFlight += call.InFlight
+	}
+	return requests, inFlight
+}
@@ -0,0 +1,121 @@
+package jams_client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff computes how long to wait before retrying. It is shared by request
+// retries, gRPC re-dials, watch polling after errors and health polling.
+//
+// attempt starts at 1 for the first retry and previous is the delay returned
+// for the previous attempt, zero for the first. Implementations must be safe
+// for concurrent use.
+type Backoff interface {
+	Delay(attempt int, previous time.Duration) time.Duration
+}
+
+// ConstantBackoff waits the same interval before every retry.
+type ConstantBackoff struct {
+	Interval time.Duration
+}
+
+// Delay implements Backoff.
+func (b ConstantBackoff) Delay(int, time.Duration) time.Duration {
+	return b.Interval
+}
+
+// ExponentialBackoff multiplies the delay by Multiplier on every attempt, up to
+// Max. Jitter randomises each delay by up to the given fraction, e.g. 0.2
+// spreads delays by ±20%.
+type ExponentialBackoff struct {
+	Base       time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// DefaultBackoff is used when retries are enabled without a Backoff.
+var DefaultBackoff = ExponentialBackoff{
+	Base:       100 * time.Millisecond,
+	Max:        5 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Delay implements Backoff.
+func (b ExponentialBackoff) Delay(attempt int, _ time.Duration) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(b.Base) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		delay *= 1 + b.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// DecorrelatedJitterBackoff picks each delay at random between Base and three
+// times the previous delay, capped at Max. It spreads out retries from many
+// clients better than exponential backoff. Base and Max are those of
+// DefaultBackoff when zero, as delays would otherwise stay zero or grow
+// without bound.
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay implements Backoff.
+func (b DecorrelatedJitterBackoff) Delay(_ int, previous time.Duration) time.Duration {
+	base, ceiling := b.Base, b.Max
+	if base <= 0 {
+		base = DefaultBackoff.Base
+	}
+	if ceiling <= 0 {
+		ceiling = DefaultBackoff.Max
+	}
+	// previous and upper are capped before multiplying, so that they cannot
+	// overflow
+	previous = min(max(previous, base), ceiling)
+	upper := min(3*previous, ceiling)
+	delay := base
+	if upper > base {
+		delay += time.Duration(rand.Int63n(int64(upper - base)))
+	}
+	return min(delay, ceiling)
+}
+
+// sleep waits for d or until ctx is done and reports whether the full duration
+// elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// WaitUntilHealthy polls HealthCheck, waiting between attempts according to
+// the client's Backoff, until the server is healthy or ctx is done.
+func (c *Client) WaitUntilHealthy(ctx context.Context) error {
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		err := c.HealthCheck(ctx)
+		if err == nil {
+			return nil
+		}
+		delay = c.opts.backoff.Delay(attempt, delay)
+		if !sleep(ctx, delay) {
+			return ctx.Err()
+		}
+	}
+}
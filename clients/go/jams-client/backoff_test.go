@@ -0,0 +1,36 @@
+package jams_client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff DecorrelatedJitterBackoff
+		base    time.Duration
+	}{
+		{name: "zero value", base: DefaultBackoff.Base},
+		{name: "negative base", backoff: DecorrelatedJitterBackoff{Base: -time.Second}, base: DefaultBackoff.Base},
+		{name: "base", backoff: DecorrelatedJitterBackoff{Base: 10 * time.Millisecond}, base: 10 * time.Millisecond},
+		{name: "max", backoff: DecorrelatedJitterBackoff{Base: 10 * time.Millisecond, Max: 50 * time.Millisecond}, base: 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var previous time.Duration
+			for attempt := 1; attempt <= 1000; attempt++ {
+				delay := tt.backoff.Delay(attempt, previous)
+				ceiling := tt.backoff.Max
+				if ceiling <= 0 {
+					ceiling = DefaultBackoff.Max
+				}
+				upper := min(3*max(previous, tt.base), ceiling)
+				if delay < min(tt.base, upper) || delay > upper {
+					t.Fatalf("attempt %d after %s: delay %s, want between %s and %s", attempt, previous, delay, tt.base, upper)
+				}
+				previous = delay
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package jams_client
+
+import "time"
+
+// LoadReports configures the balancing of WithLoadReportBalancing. Zero
+// fields take gRPC's defaults.
+type LoadReports struct {
+	// OutOfBand asks each server for load reports every Period on a separate
+	// stream, instead of reading the reports the servers attach to the
+	// trailers of calls.
+	OutOfBand bool
+	// Period is the interval of out-of-band reports. Defaults to 10s.
+	Period time.Duration
+	// Blackout is how long a server must report its load before its weight
+	// is used, to avoid churn when servers come and go. Defaults to 10s.
+	Blackout time.Duration
+	// ErrorPenalty weighs the error rate of a server into its load. Defaults
+	// to 1.
+	ErrorPenalty float64
+}
+
+// WithLoadReportBalancing makes the gRPC transport spread calls over the
+// servers its target resolves to, e.g. "dns:///jams.internal:4000", in
+// proportion to the load they report through ORCA (Open Request Cost
+// Aggregation): the requests per second a server reports divided by its
+// reported application utilization, such as a normalised queue depth, or
+// else its CPU utilization. Servers which report no load get the mean weight
+// of the others, so that without reports calls are spread round robin. It uses
+// gRPC's weighted_round_robin balancer and has no effect on the HTTP
+// transport or when the resolver provides a service config of its own.
+func WithLoadReportBalancing(r LoadReports) Option {
+	return func(o *options) {
+		o.loadReports = &r
+	}
+}
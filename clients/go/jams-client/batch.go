@@ -0,0 +1,186 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Batch defaults.
+const (
+	DefaultBatchChunkSize   = 1000
+	DefaultBatchConcurrency = 4
+)
+
+// BatchOptions configures PredictBatch.
+type BatchOptions struct {
+	// ChunkSize is the number of records sent per call, DefaultBatchChunkSize
+	// when zero.
+	ChunkSize int
+	// Concurrency is the number of chunks predicted at once,
+	// DefaultBatchConcurrency when zero.
+	Concurrency int
+	// FailFast stops at the first failed chunk, cancelling the chunks in
+	// flight, and returns its *ChunkError without any prediction.
+	FailFast bool
+	// DeadLetters, if not nil, receives the input of every failed chunk,
+	// except the chunks cancelled by ctx or FailFast.
+	DeadLetters DeadLetterQueue
+	// DeadLettersUnredacted puts the inputs into DeadLetters as given,
+	// without the redactions set with WithRedaction, e.g. to send them again
+	// from a queue as protected as the source of the inputs.
+	DeadLettersUnredacted bool
+}
+
+// BatchChunk is the prediction of a chunk of the input of PredictBatch.
+type BatchChunk struct {
+	// Index is the position of the chunk in the input.
+	Index int
+	// Start and End are the indices of the first record of the chunk and of
+	// the record following its last.
+	Start      int
+	End        int
+	Prediction *types.Prediction
+}
+
+// ChunkError is the failure of a chunk of the input of PredictBatch.
+type ChunkError struct {
+	Index int
+	Start int
+	End   int
+	Err   error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("chunk %d (records %d to %d): %v", e.Index, e.Start, e.End-1, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned by PredictBatch along with the predictions of the
+// successful chunks when some chunks failed.
+type BatchError struct {
+	// Chunks is the number of chunks of the input.
+	Chunks int
+	// Failures are the failed chunks, by index.
+	Failures []*ChunkError
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		msgs[i] = failure.Error()
+	}
+	return fmt.Sprintf("%d of %d chunks failed: %s", len(e.Failures), e.Chunks, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the failed chunks, so that errors.Is and
+// errors.As match them.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure
+	}
+	return errs
+}
+
+// PredictBatch makes predictions for a large input by splitting it into
+// chunks predicted concurrently with Predict. It returns the predictions of
+// the successful chunks in the order of the input, i.e. by index, and, when
+// some chunks failed, a *BatchError listing them, so that the records of the
+// failed chunks can be retried alone. See BatchOptions.FailFast to give up at the first failure instead.
+// Chunks have PriorityBatch unless ctx has a priority set with WithPriority.
+func (c *Client) PredictBatch(ctx context.Context, modelName string, input *types.Input, opts BatchOptions) ([]BatchChunk, error) {
+	if input == nil {
+		return nil, ErrNilInput
+	}
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = DefaultBatchChunkSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	records := input.Len()
+	chunks := (records + size - 1) / size
+	if chunks == 0 {
+		return nil, errors.New("input has no records")
+	}
+
+	if _, ok := ctx.Value(priorityKey{}).(Priority); !ok {
+		ctx = WithPriority(ctx, PriorityBatch)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		results   = make([]BatchChunk, chunks)
+		failures  = make([]*ChunkError, chunks)
+		firstFail *ChunkError
+		slots     = make(chan struct{}, concurrency)
+	)
+	for i := 0; i < chunks; i++ {
+		start, end := i*size, min((i+1)*size, records)
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			chunk := input.Slice(start, end)
+			prediction, err := c.Predict(ctx, modelName, chunk)
+			if err != nil && opts.DeadLetters != nil && ctx.Err() == nil {
+				putErr := opts.DeadLetters.Put(ctx, DeadLetter{
+					Source:  DeadLetterSourceBatch,
+					Model:   modelName,
+					Time:    time.Now(),
+					Records: &RecordRange{Start: start, End: end},
+					Input:   c.deadLetterInput(chunk, opts.DeadLettersUnredacted),
+					Err:     err,
+				})
+				if putErr != nil {
+					err = errors.Join(err, fmt.Errorf("failed to store dead letter: %w", putErr))
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[i] = &ChunkError{Index: i, Start: start, End: end, Err: err}
+				if opts.FailFast && firstFail == nil {
+					firstFail = failures[i]
+					cancel()
+				}
+				return
+			}
+			results[i] = BatchChunk{Index: i, Start: start, End: end, Prediction: prediction}
+		}(i, start, end)
+	}
+	wg.Wait()
+
+	if firstFail != nil {
+		return nil, firstFail
+	}
+	succeeded := make([]BatchChunk, 0, chunks)
+	var failed []*ChunkError
+	for i := range results {
+		if failures[i] != nil {
+			failed = append(failed, failures[i])
+		} else {
+			succeeded = append(succeeded, results[i])
+		}
+	}
+	if len(failed) > 0 {
+		return succeeded, &BatchError{Chunks: chunks, Failures: failed}
+	}
+	return succeeded, nil
+}
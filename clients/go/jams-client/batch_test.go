@@ -0,0 +1,139 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// reverseDelay predicts like echo, holding the chunks of the first records
+// the longest, so that chunks complete in the reverse order of the input.
+func reverseDelay(records int) func(context.Context, string, string) (string, error) {
+	return func(ctx context.Context, _, input string) (string, error) {
+		delay := time.Duration(records-firstIndex(input)) * time.Millisecond
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		return echo(input)
+	}
+}
+
+// indexInput returns an input of n records whose column "i" is their index.
+func indexInput(n int) *types.Input {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	return types.NewInput().AddFloats("i", values...)
+}
+
+// checkChunk checks that the predictions of chunk are those of its records.
+func checkChunk(t *testing.T, chunk BatchChunk) {
+	t.Helper()
+	rows := chunk.Prediction.Outputs["predictions"]
+	if len(rows) != chunk.End-chunk.Start {
+		t.Fatalf("chunk %d has %d predictions for records %d to %d", chunk.Index, len(rows), chunk.Start, chunk.End)
+	}
+	for k, row := range rows {
+		if int(row[0]) != chunk.Start+k {
+			t.Fatalf("chunk %d: record %d has the prediction of record %v", chunk.Index, chunk.Start+k, row[0])
+		}
+	}
+}
+
+func TestPredictBatchOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		records     int
+		chunkSize   int
+		concurrency int
+	}{
+		{name: "single chunk", records: 5, chunkSize: 10, concurrency: 4},
+		{name: "sequential", records: 40, chunkSize: 10, concurrency: 1},
+		{name: "concurrent", records: 95, chunkSize: 10, concurrency: 4},
+		{name: "all at once", records: 64, chunkSize: 4, concurrency: 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(&fakeTransport{predictFunc: reverseDelay(tt.records)})
+			chunks, err := client.PredictBatch(context.Background(), "m", indexInput(tt.records), BatchOptions{ChunkSize: tt.chunkSize, Concurrency: tt.concurrency})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := (tt.records + tt.chunkSize - 1) / tt.chunkSize
+			if len(chunks) != want {
+				t.Fatalf("got %d chunks, want %d", len(chunks), want)
+			}
+			for i, chunk := range chunks {
+				if chunk.Index != i || chunk.Start != i*tt.chunkSize || chunk.End != min((i+1)*tt.chunkSize, tt.records) {
+					t.Fatalf("chunk %d is %+v", i, chunk)
+				}
+				checkChunk(t, chunk)
+			}
+		})
+	}
+}
+
+// memoryQueue is a DeadLetterQueue keeping its dead letters in memory.
+type memoryQueue struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+func (q *memoryQueue) Put(_ context.Context, l DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.letters = append(q.letters, l)
+	return nil
+}
+
+func TestDeadLettersRedacted(t *testing.T) {
+	tests := []struct {
+		name       string
+		unredacted bool
+		want       any
+	}{
+		{name: "redacted by default", want: DefaultRedactionReplacement},
+		{name: "unredacted on request", unredacted: true, want: "a@example.com"},
+	}
+	failing := &fakeTransport{predictFunc: func(context.Context, string, string) (string, error) {
+		return "", errors.New("invalid input")
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(failing, WithRedactedColumns("email"))
+			input := func() *types.Input {
+				return types.NewInput().AddFloats("i", 0, 1).AddStrings("email", "a@example.com", "a@example.com")
+			}
+			letters := func(q *memoryQueue) []DeadLetter {
+				if len(q.letters) != 1 {
+					t.Fatalf("got %d dead letters, want 1", len(q.letters))
+				}
+				return q.letters
+			}
+
+			batch := &memoryQueue{}
+			client.PredictBatch(context.Background(), "m", input(), BatchOptions{DeadLetters: batch, DeadLettersUnredacted: tt.unredacted})
+			stream := &memoryQueue{}
+			records := &sliceReader{records: []map[string]any{{"i": 0.0, "email": "a@example.com"}}}
+			err := client.PredictStream(context.Background(), "m", records, StreamOptions{DeadLetters: stream, DeadLettersUnredacted: tt.unredacted}, func(BatchChunk, []map[string]any) error {
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for source, q := range map[string]*memoryQueue{"batch": batch, "stream": stream} {
+				emails, _ := letters(q)[0].Input.Column("email")
+				if emails[0] != tt.want {
+					t.Fatalf("%s dead letter has email %v, want %v", source, emails[0], tt.want)
+				}
+			}
+		})
+	}
+}
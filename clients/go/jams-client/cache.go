@@ -0,0 +1,240 @@
+package jams_client
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CachePolicy controls how long cached predictions are served.
+type CachePolicy struct {
+	// TTL is how long a cached prediction is fresh. Zero disables caching.
+	TTL time.Duration
+	// MaxStaleness enables stale-while-revalidate: for this long after the
+	// TTL has passed, the cached prediction is still returned immediately
+	// while a background request refreshes it. Zero disables it.
+	MaxStaleness time.Duration
+}
+
+// WithPredictionCache caches the outputs of up to maxEntries Predict calls,
+// keyed by model and input, according to policy. Use WithModelCachePolicy to
+// override the policy of single models.
+func WithPredictionCache(maxEntries int, policy CachePolicy) Option {
+	return func(o *options) {
+		o.cacheEntries = maxEntries
+		o.cachePolicy = policy
+	}
+}
+
+// WithModelCachePolicy sets the cache policy of the named model, e.g. to allow
+// stale predictions only on latency critical models. It has no effect without
+// WithPredictionCache.
+func WithModelCachePolicy(modelName string, policy CachePolicy) Option {
+	return func(o *options) {
+		if o.modelCachePolicies == nil {
+			o.modelCachePolicies = make(map[string]CachePolicy)
+		}
+		o.modelCachePolicies[modelName] = policy
+	}
+}
+
+// cacheRefreshTimeout bounds background refreshes of stale predictions.
+const cacheRefreshTimeout = 30 * time.Second
+
+type cacheKey struct {
+	model string
+	input string
+}
+
+type cacheEntry struct {
+	key        cacheKey
+	output     string
+	stored     time.Time
+	refreshing bool
+}
+
+// predictionCache is a least recently used cache of prediction outputs.
+type predictionCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	policy     CachePolicy
+	models     map[string]CachePolicy
+	entries    map[cacheKey]*list.Element
+	order      *list.List
+}
+
+func newPredictionCache(o *options) *predictionCache {
+	if o.cacheEntries <= 0 {
+		return nil
+	}
+	return &predictionCache{
+		maxEntries: o.cacheEntries,
+		policy:     o.cachePolicy,
+		models:     o.modelCachePolicies,
+		entries:    make(map[cacheKey]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *predictionCache) policyFor(model string) CachePolicy {
+	if policy, ok := c.models[model]; ok {
+		return policy
+	}
+	return c.policy
+}
+
+// get returns the cached output for key. refresh is set when the output is
+// stale and the caller must revalidate it; only one caller is asked to at a
+// time.
+func (c *predictionCache) get(key cacheKey) (output string, ok, refresh bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, found := c.entries[key]
+	if !found {
+		return "", false, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	policy := c.policyFor(key.model)
+	age := time.Since(entry.stored)
+	switch {
+	case age <= policy.TTL:
+		c.order.MoveToFront(elem)
+		return entry.output, true, false
+	case age <= policy.TTL+policy.MaxStaleness:
+		c.order.MoveToFront(elem)
+		refresh = !entry.refreshing
+		entry.refreshing = true
+		return entry.output, true, refresh
+	}
+	c.order.Remove(elem)
+	delete(c.entries, key)
+	return "", false, false
+}
+
+func (c *predictionCache) put(key cacheKey, output string) {
+	if c.policyFor(key.model).TTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		elem.Value = &cacheEntry{key: key, output: output, stored: time.Now()}
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, output: output, stored: time.Now()})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// refreshFailed allows another caller to revalidate key.
+func (c *predictionCache) refreshFailed(key cacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).refreshing = false
+	}
+}
+
+// predictOutput returns the raw prediction output for the encoded input,
+// serving and filling the prediction cache when one is configured. beforeSend,
+// if not nil, runs once the call is admitted by the quotas and the
+// concurrency limit, right before it is sent, and fails the call when it
+// fails. It does not run when the output is served from the cache.
+func (c *Client) predictOutput(ctx context.Context, modelName, payload string, beforeSend func() error) (string, error) {
+	fetch := func(ctx context.Context, beforeSend func() error) (string, error) {
+		start := time.Now()
+		if err := c.acquireQuota(ctx, modelName); err != nil {
+			c.cancelled(ctx, MethodPredict, modelName, start, true, err)
+			return "", err
+		}
+		if err := c.admission.acquire(ctx); err != nil {
+			c.cancelled(ctx, MethodPredict, modelName, start, true, err)
+			return "", err
+		}
+		defer c.admission.release()
+		stageTimerFrom(ctx).since(StageQueue, start)
+		if beforeSend != nil {
+			if err := beforeSend(); err != nil {
+				return "", err
+			}
+		}
+		var output string
+		err := c.invoke(ctx, MethodPredict, modelName, func(ctx context.Context) error {
+			var err error
+			output, err = c.transport.predict(ctx, modelName, payload)
+			return err
+		})
+		return output, err
+	}
+	if c.cache == nil {
+		return fetch(ctx, beforeSend)
+	}
+
+	key := cacheKey{model: modelName, input: payload}
+	if output, ok, refresh := c.cache.get(key); ok {
+		if refresh {
+			c.refresh(ctx, func(ctx context.Context) {
+				if output, err := fetch(ctx, nil); err != nil {
+					c.cache.refreshFailed(key)
+					c.opts.degraded.enter(DegradedStaleCache, modelName, err)
+				} else {
+					c.cache.put(key, output)
+					c.opts.degraded.leave(DegradedStaleCache, modelName)
+				}
+			})
+		}
+		return output, nil
+	}
+	output, err := fetch(ctx, beforeSend)
+	if err == nil {
+		c.cache.put(key, output)
+	}
+	return output, err
+}
+
+// refreshKeys are the context keys of the values kept by background
+// refreshes: those shaping the request, its quota and its accounting.
+var refreshKeys = []any{callMetadataKey{}, teamKey{}, rowsKey{}, priorityKey{}}
+
+// refresh runs a background refresh of the prediction cache, unless the
+// client is closed. The refresh outlives the caller, so it runs on a context
+// of the client which Close cancels and waits for, carrying the refreshKeys
+// values of the caller only: its stage timer, hooks and debug dumps belong to
+// a call which has returned.
+func (c *Client) refresh(caller context.Context, refresh func(ctx context.Context)) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if c.refreshCtx.Err() != nil {
+		return
+	}
+	ctx := c.refreshCtx
+	for _, key := range refreshKeys {
+		if v := caller.Value(key); v != nil {
+			ctx = context.WithValue(ctx, key, v)
+		}
+	}
+	c.refreshes.Add(1)
+	go func() {
+		defer c.refreshes.Done()
+		ctx, cancel := context.WithTimeout(ctx, cacheRefreshTimeout)
+		defer cancel()
+		refresh(ctx)
+	}()
+}
+
+// stopRefreshes cancels the background refreshes and waits for them to
+// return.
+func (c *Client) stopRefreshes() {
+	c.refreshMu.Lock()
+	c.cancelRefreshes()
+	c.refreshMu.Unlock()
+	c.refreshes.Wait()
+}
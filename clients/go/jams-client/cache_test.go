@@ -0,0 +1,109 @@
+package jams_client
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// staleClient returns a client caching predictions for a millisecond, then
+// serving them stale, whose first call predicts 1 and later ones refresh.
+func staleClient(refresh func(ctx context.Context) (string, error), opts ...Option) *Client {
+	var calls atomic.Int64
+	transport := &fakeTransport{predictFunc: func(ctx context.Context, _, _ string) (string, error) {
+		if calls.Add(1) == 1 {
+			return predictionsOutput([][]float64{{1}}), nil
+		}
+		return refresh(ctx)
+	}}
+	opts = append(opts, WithPredictionCache(10, CachePolicy{TTL: time.Millisecond, MaxStaleness: time.Hour}))
+	return newTestClient(transport, opts...)
+}
+
+// predictStale fills the cache then predicts once its prediction is stale,
+// starting a refresh.
+func predictStale(t *testing.T, ctx context.Context, c *Client) {
+	t.Helper()
+	for i := 0; i < 2; i++ {
+		prediction, err := c.Predict(ctx, "m", indexInput(1))
+		if err != nil {
+			t.Fatalf("Predict: %v", err)
+		}
+		if got := prediction.Outputs["predictions"][0][0]; got != 1 {
+			t.Fatalf("prediction = %v, want the cached 1", got)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestCacheRefreshContext(t *testing.T) {
+	type seen struct {
+		metadata map[string]string
+		team     string
+		timed    bool
+		err      error
+	}
+	release := make(chan struct{})
+	refreshed := make(chan seen, 1)
+	c := staleClient(func(ctx context.Context) (string, error) {
+		<-release
+		team, _ := ctx.Value(teamKey{}).(string)
+		refreshed <- seen{metadata: CallMetadata(ctx), team: team, timed: stageTimerFrom(ctx) != nil, err: ctx.Err()}
+		return predictionsOutput([][]float64{{2}}), nil
+	}, WithStageTimings())
+
+	ctx, cancel := context.WithCancel(WithTeam(WithCallMetadata(context.Background(), map[string]string{"experiment": "a"}), "ranking"))
+	predictStale(t, ctx, c)
+	// the refresh outlives the caller.
+	cancel()
+	close(release)
+
+	got := <-refreshed
+	if got.err != nil {
+		t.Fatalf("refresh context error = %v, want none", got.err)
+	}
+	if got.metadata["experiment"] != "a" || got.team != "ranking" {
+		t.Fatalf("refresh has metadata %v and team %q, want those of the caller", got.metadata, got.team)
+	}
+	if got.timed {
+		t.Fatalf("refresh has the stage timer of the caller")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if output := c.cache.order.Front().Value.(*cacheEntry).output; output != predictionsOutput([][]float64{{2}}) {
+		t.Fatalf("cached output = %q, want the refreshed one", output)
+	}
+}
+
+func TestCacheRefreshClose(t *testing.T) {
+	started := make(chan struct{})
+	var returned atomic.Bool
+	c := staleClient(func(ctx context.Context) (string, error) {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		returned.Store(true)
+		return "", ctx.Err()
+	})
+	predictStale(t, context.Background(), c)
+	<-started
+
+	closed := make(chan error)
+	go func() { closed <- c.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Close did not cancel the refresh")
+	}
+	if !returned.Load() {
+		t.Fatalf("Close returned before the refresh")
+	}
+
+	// stale predictions are still served, without refreshes, once closed.
+	predictStale(t, context.Background(), c)
+}
@@ -0,0 +1,79 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// CancelEvent describes a call whose context was cancelled, or whose deadline
+// passed, before it returned.
+type CancelEvent struct {
+	Method string
+	// Model is empty for calls which do not target a model.
+	Model string
+	// Cause is the cause of the cancellation, see context.Cause.
+	Cause error
+	// Elapsed is the time from the start of the call to its return.
+	Elapsed time.Duration
+	// Queued reports whether the call was cancelled while waiting for a quota
+	// or a slot of WithMaxConcurrency, before anything was sent.
+	Queued bool
+	// Answered reports whether the server answered the call, successfully or
+	// not, before the cancellation took effect, i.e. the request was
+	// processed. Otherwise the call was abandoned: the HTTP transport closes
+	// the connection and the gRPC transport resets the stream, which the
+	// server stops working on but does not acknowledge.
+	Answered bool
+	// Metadata is the metadata of the call context, see WithCallMetadata.
+	Metadata map[string]string
+}
+
+type onCancelKey struct{}
+
+// WithOnCancel returns a context whose calls run hook when they return after
+// the context was cancelled, e.g. to release resources tied to the call or to
+// log abandoned predictions. Hooks run synchronously, before the call
+// returns, and add up when WithOnCancel is applied several times.
+func WithOnCancel(ctx context.Context, hook func(CancelEvent)) context.Context {
+	if parent, ok := ctx.Value(onCancelKey{}).(func(CancelEvent)); ok {
+		child := hook
+		hook = func(e CancelEvent) {
+			parent(e)
+			child(e)
+		}
+	}
+	return context.WithValue(ctx, onCancelKey{}, hook)
+}
+
+// cancelled counts a call which returned err after ctx was cancelled and runs
+// the hooks of ctx. It does nothing when ctx is not done.
+func (c *Client) cancelled(ctx context.Context, method, model string, start time.Time, queued bool, err error) {
+	if ctx.Err() == nil {
+		return
+	}
+	c.stats.cancel(method, model)
+	if hook, ok := ctx.Value(onCancelKey{}).(func(CancelEvent)); ok {
+		hook(CancelEvent{
+			Method:   method,
+			Model:    model,
+			Cause:    context.Cause(ctx),
+			Elapsed:  time.Since(start),
+			Queued:   queued,
+			Answered: !queued && answered(err),
+			Metadata: CallMetadata(ctx),
+		})
+	}
+}
+
+// answered reports whether err, the outcome of a cancelled call, came from
+// the server rather than from the cancellation.
+func answered(err error) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return !grpcCanceled(err)
+}
@@ -0,0 +1,120 @@
+package jams_client
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ModelIterator iterates over the model catalog of the server, see Models.
+//
+//	it := client.Models(ctx)
+//	for it.Next() {
+//		fmt.Println(it.Model().Name)
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type ModelIterator struct {
+	ctx    context.Context
+	client *Client
+
+	fetched bool
+	models  []ModelMetadata
+	current ModelMetadata
+	err     error
+}
+
+// Models returns an iterator over the models loaded into the server. The
+// catalog is fetched on the first call to Next. The server has no paginated
+// listing and returns its whole catalog at once, so the iterator makes a
+// single GetModels call; it lets callers range over the catalog without
+// holding on to it.
+func (c *Client) Models(ctx context.Context) *ModelIterator {
+	return &ModelIterator{ctx: ctx, client: c}
+}
+
+// Next advances to the next model, reporting whether there is one. It returns
+// false at the end of the catalog or on error, see Err.
+func (it *ModelIterator) Next() bool {
+	if !it.fetched {
+		it.fetched = true
+		it.models, it.err = it.client.GetModels(it.ctx)
+	}
+	if it.err != nil || len(it.models) == 0 {
+		it.current = ModelMetadata{}
+		return false
+	}
+	it.current = it.models[0]
+	it.models[0] = ModelMetadata{}
+	it.models = it.models[1:]
+	return true
+}
+
+// Model returns the model Next advanced to.
+func (it *ModelIterator) Model() ModelMetadata {
+	return it.current
+}
+
+// Err returns the error which ended the iteration, if any.
+func (it *ModelIterator) Err() error {
+	return it.err
+}
+
+// ModelQuery selects models by name and metadata, see SearchModels. Zero
+// fields match every model.
+type ModelQuery struct {
+	// Name is a glob pattern of model names, in the syntax of path.Match,
+	// e.g. "catboost-*".
+	Name string
+	// NameRegexp matches model names, e.g. regexp.MustCompile("titanic").
+	NameRegexp *regexp.Regexp
+	// Framework is the framework of models, e.g. "lightgbm", compared
+	// case-insensitively.
+	Framework string
+	// UpdatedAfter matches models updated after it. Models whose update
+	// timestamp does not parse never match.
+	UpdatedAfter time.Time
+}
+
+// Match reports whether the query selects m. A malformed Name never matches.
+func (q ModelQuery) Match(m ModelMetadata) bool {
+	if q.Name != "" {
+		if ok, err := path.Match(q.Name, m.Name); err != nil || !ok {
+			return false
+		}
+	}
+	if q.NameRegexp != nil && !q.NameRegexp.MatchString(m.Name) {
+		return false
+	}
+	if q.Framework != "" && !strings.EqualFold(q.Framework, m.Framework) {
+		return false
+	}
+	if !q.UpdatedAfter.IsZero() {
+		updated, err := m.UpdatedAt()
+		if err != nil || !updated.After(q.UpdatedAfter) {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchModels returns the models loaded into the server which match query,
+// in the order the server lists them. The server cannot filter its catalog,
+// so the models are filtered by the client after a single GetModels call.
+func (c *Client) SearchModels(ctx context.Context, query ModelQuery) ([]ModelMetadata, error) {
+	if _, err := path.Match(query.Name, ""); err != nil {
+		return nil, fmt.Errorf("invalid name pattern %q: %w", query.Name, err)
+	}
+	var matched []ModelMetadata
+	it := c.Models(ctx)
+	for it.Next() {
+		if query.Match(it.Model()) {
+			matched = append(matched, it.Model())
+		}
+	}
+	return matched, it.Err()
+}
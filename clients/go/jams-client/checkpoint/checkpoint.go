@@ -0,0 +1,96 @@
+// Package checkpoint stores the progress of long running streams of
+// predictions in local files, so that an interrupted scoring job resumes
+// where it left off instead of scoring its records twice:
+//
+//	cp := checkpoint.File{Path: "scoring.ckpt"}
+//	err := client.PredictStream(ctx, "churn", records, jams.StreamOptions{Checkpoint: cp}, store)
+//
+// A checkpoint holds the offset of a single stream. Workers scoring shards of
+// the same input each need their own, e.g. with ShardPath.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// File is a jams.Checkpoint stored in the file at Path. The file is replaced
+// atomically on every Save, so that it holds either the previous or the new
+// offset after a crash.
+type File struct {
+	Path string
+}
+
+// state is the content of a checkpoint file.
+type state struct {
+	Offset  int       `json:"offset"`
+	Updated time.Time `json:"updated"`
+}
+
+// Load implements jams.Checkpoint. It returns zero when the file does not
+// exist.
+func (f File) Load() (int, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, fmt.Errorf("invalid checkpoint %s: %w", f.Path, err)
+	}
+	if s.Offset < 0 {
+		return 0, fmt.Errorf("invalid checkpoint %s: negative offset", f.Path)
+	}
+	return s.Offset, nil
+}
+
+// Save implements jams.Checkpoint.
+func (f File) Save(offset int) error {
+	data, err := json.Marshal(state{Offset: offset, Updated: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.Path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Reset removes the file, so that the stream starts over.
+func (f File) Reset() error {
+	err := os.Remove(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ShardPath returns the path of the checkpoint of a shard of the stream whose
+// checkpoint is at path, e.g. "scoring.shard-2-of-8.ckpt" for
+// "scoring.ckpt", so that each worker resumes its own shard.
+func ShardPath(path string, shard, shards int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.shard-%d-of-%d%s", strings.TrimSuffix(path, ext), shard, shards, ext)
+}
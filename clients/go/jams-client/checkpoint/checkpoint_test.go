@@ -0,0 +1,60 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	f := File{Path: filepath.Join(t.TempDir(), "stream.ckpt")}
+	if offset, err := f.Load(); err != nil || offset != 0 {
+		t.Fatalf("new checkpoint at %d (%v), want 0", offset, err)
+	}
+	for _, offset := range []int{10, 20, 1 << 40} {
+		if err := f.Save(offset); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := f.Load(); err != nil || got != offset {
+			t.Fatalf("checkpoint at %d (%v), want %d", got, err, offset)
+		}
+	}
+	if err := f.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if offset, err := f.Load(); err != nil || offset != 0 {
+		t.Fatalf("reset checkpoint at %d (%v), want 0", offset, err)
+	}
+	if err := f.Reset(); err != nil {
+		t.Fatalf("reset of a missing checkpoint: %v", err)
+	}
+	// no temporary file is left behind
+	entries, err := os.ReadDir(filepath.Dir(f.Path))
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("directory holds %v (%v)", entries, err)
+	}
+}
+
+func TestFileInvalid(t *testing.T) {
+	for _, content := range []string{"", "10", `{"offset": -1}`, `{"offset": "10"}`} {
+		f := File{Path: filepath.Join(t.TempDir(), "stream.ckpt")}
+		if err := os.WriteFile(f.Path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if offset, err := f.Load(); err == nil {
+			t.Fatalf("checkpoint %q loaded at %d", content, offset)
+		}
+	}
+}
+
+func TestShardPath(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"scoring.ckpt", "scoring.shard-2-of-8.ckpt"},
+		{"dir/scoring", "dir/scoring.shard-2-of-8"},
+	}
+	for _, tt := range tests {
+		if got := ShardPath(tt.path, 2, 8); got != tt.want {
+			t.Fatalf("ShardPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Package jams_client provides HTTP and gRPC clients for J.A.M.S - Just Another
+// Model Server.
+//
+// Both transports are exposed through the same Client type:
+//
+//	client, err := jams_client.NewHTTPClient("http://localhost:3000")
+//	client, err := jams_client.NewGRPCClient("localhost:4000")
+//
+// Building with the jams_nogrpc tag, or for js/wasm, leaves out the gRPC
+// transport and its dependencies; NewGRPCClient then returns ErrNoGRPC. On
+// js/wasm, the HTTP transport sends requests with the Fetch API.
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// transport is implemented by the HTTP and gRPC backends.
+type transport interface {
+	healthCheck(ctx context.Context) error
+	predict(ctx context.Context, modelName, input string) (string, error)
+	getModels(ctx context.Context) ([]ModelMetadata, error)
+	addModel(ctx context.Context, modelName string) error
+	updateModel(ctx context.Context, modelName string) error
+	deleteModel(ctx context.Context, modelName string) error
+	// retryable reports whether a failed call may be retried and the delay
+	// requested by the server, if any.
+	retryable(err error) (bool, time.Duration)
+	// name and features are the Transport and transport-specific Features
+	// of Capabilities.
+	name() string
+	features() []Feature
+	close() error
+}
+
+// Preprocessor transforms a model input before it is sent, e.g. scaling or
+// encoding features. Apply must not modify its argument.
+type Preprocessor interface {
+	Apply(in *types.Input) (*types.Input, error)
+}
+
+// Postprocessor transforms a prediction after it is parsed, e.g. mapping
+// regression outputs back to business units. Apply must not modify its
+// argument.
+type Postprocessor interface {
+	Apply(p *types.Prediction) (*types.Prediction, error)
+}
+
+// Client is a J.A.M.S client. It is safe for concurrent use.
+type Client struct {
+	transport transport
+	opts      *options
+	stats     *statsRecorder
+	cache     *predictionCache
+	admission *admission
+	dedup     *mutationDedup
+	// stopReports stops the model stats reports of WithModelStatsReporter.
+	stopReports func()
+	// loggedWarnings holds the warnings already logged.
+	loggedWarnings warningSet
+	// refreshCtx is the context of the background refreshes of the
+	// prediction cache, tracked by refreshes and cancelled by Close.
+	refreshMu       sync.Mutex
+	refreshCtx      context.Context
+	cancelRefreshes context.CancelFunc
+	refreshes       sync.WaitGroup
+}
+
+// NewClient returns a Client for endpoint, talking to the HTTP API when it
+// starts with "http://" or "https://", e.g. "http://localhost:3000", and to
+// the gRPC API otherwise, e.g. "localhost:4000" or
+// "dns:///jams.internal:4000".
+func NewClient(endpoint string, opts ...Option) (*Client, error) {
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return NewHTTPClient(endpoint, opts...)
+	}
+	return NewGRPCClient(endpoint, opts...)
+}
+
+func newClient(t transport, opts *options) *Client {
+	c := &Client{
+		transport: t,
+		opts:      opts,
+		stats:     newStatsRecorder(),
+		cache:     newPredictionCache(opts),
+		admission: newAdmission(opts.maxConcurrency),
+		dedup:     newMutationDedup(opts.dedupWindow),
+	}
+	c.refreshCtx, c.cancelRefreshes = context.WithCancel(context.Background())
+	c.stopReports = c.startModelStatsReports()
+	return c
+}
+
+// invoke runs a call against the transport, retrying it as configured with
+// WithRetry, and records its outcome, including its cancellation.
+func (c *Client) invoke(ctx context.Context, method, model string, call func(ctx context.Context) error) error {
+	var err error
+	c.profiled(ctx, method, model, func(ctx context.Context) {
+		err = c.doInvoke(ctx, method, model, call)
+	})
+	return err
+}
+
+// doInvoke is invoke without the profiling labels.
+func (c *Client) doInvoke(ctx context.Context, method, model string, call func(ctx context.Context) error) error {
+	c.stats.begin(method, model)
+	start := time.Now()
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	ctx, warnings := c.withWarnings(ctx)
+	defer c.reportWarnings(method, model, warnings)
+	ctx, usage := c.withUsage(ctx)
+	defer c.reportUsage(ctx, method, model, usage)
+	var (
+		err   error
+		delay time.Duration
+	)
+	for attempt := 1; ; attempt++ {
+		info := CallInfo{Method: method, Model: model, Attempt: attempt}
+		attemptCtx, cancelAttempt := c.attemptContext(ctx)
+		if len(c.opts.hooks) == 0 {
+			err = call(attemptCtx)
+		} else {
+			attemptStart := time.Now()
+			err = call(c.beforeAttempt(attemptCtx, info))
+			c.afterAttempt(ctx, info, time.Since(attemptStart), err)
+		}
+		cancelAttempt()
+		err = timeoutError(attemptCtx, err)
+		if err == nil || attempt >= c.opts.maxAttempts || ctx.Err() != nil {
+			break
+		}
+		ok, retryAfter := c.transport.retryable(err)
+		if !ok && !errors.Is(err, ErrAttemptTimeout) {
+			break
+		}
+		delay = c.opts.backoff.Delay(attempt, delay)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		if beyondDeadline(ctx, delay) {
+			break
+		}
+		c.stats.retry(method, model)
+		c.beforeRetry(ctx, info, delay, err)
+		if !sleep(ctx, delay) {
+			// report the cancellation rather than the failure it interrupted
+			err = fmt.Errorf("%w while retrying after: %w", ctx.Err(), err)
+			break
+		}
+	}
+	c.stats.record(method, model, time.Since(start), err)
+	c.cancelled(ctx, method, model, start, false, err)
+	return err
+}
+
+// HealthCheck checks whether the server is up.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return c.invoke(ctx, MethodHealthCheck, "", c.transport.healthCheck)
+}
+
+// Predict makes predictions for the given input using the named model. The
+// input is merged with the model's template first, if one was registered with
+// WithTemplate, passed through the model's Preprocessor, if one was
+// registered with WithPreprocessor, and checked against the columns declared
+// with WithColumns. The prediction is passed through the model's
+// Postprocessor, if one was registered with WithPostprocessor.
+func (c *Client) Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error) {
+	var (
+		prediction *types.Prediction
+		err        error
+	)
+	c.profiled(ctx, MethodPredict, modelName, func(ctx context.Context) {
+		prediction, err = c.predict(ctx, modelName, input)
+	})
+	return prediction, err
+}
+
+func (c *Client) predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error) {
+	if input == nil {
+		return nil, ErrNilInput
+	}
+	ctx, timer := c.withStageTimer(ctx)
+	start := time.Now()
+	if t, ok := c.opts.templates[modelName]; ok {
+		var err error
+		if input, err = t.Apply(input); err != nil {
+			return nil, err
+		}
+	}
+	if p, ok := c.opts.preprocessors[modelName]; ok {
+		var err error
+		if input, err = p.Apply(input); err != nil {
+			return nil, fmt.Errorf("failed to preprocess input: %w", err)
+		}
+	}
+	if columns, ok := c.opts.modelColumns[modelName]; ok {
+		if err := input.CheckColumns(columns...); err != nil {
+			return nil, err
+		}
+		input = input.Clone()
+		if err := input.SetOrder(columns...); err != nil {
+			return nil, err
+		}
+	}
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	timer.since(StagePrepare, start)
+	start = time.Now()
+	payload, err := input.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	timer.since(StageMarshal, start)
+	requestID := newRequestID()
+	// the call is journaled once admitted, so that calls rejected or
+	// cancelled before being sent do not fill the journal.
+	var journaled bool
+	journal := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.journalAppend(modelName, requestID, payload); err != nil {
+			return err
+		}
+		journaled = true
+		return nil
+	}
+	prediction, err := c.predictPayload(ctx, modelName, input, payload, requestID, journal)
+	if journaled {
+		c.journalSettle(requestID, err)
+	}
+	if err == nil {
+		c.reportStages(modelName, timer)
+	}
+	return prediction, err
+}
+
+// predictPayload sends the payload of input, the prepared input of a Predict
+// call, and completes the prediction. beforeSend is passed to predictOutput.
+func (c *Client) predictPayload(ctx context.Context, modelName string, input *types.Input, payload []byte, requestID string, beforeSend func() error) (*types.Prediction, error) {
+	start := time.Now()
+	dump := c.startDebugDump(ctx, modelName, requestID, start, input)
+	output, err := c.predictOutput(withRows(ctx, input.Len()), modelName, string(payload), beforeSend)
+	c.finishDebugDump(dump, output, err)
+	timer := stageTimerFrom(ctx)
+	var prediction *types.Prediction
+	if err == nil {
+		parseStart := time.Now()
+		prediction, err = types.ParsePredictionWith(output, c.opts.parseOptions)
+		timer.since(StageParse, parseStart)
+	}
+	postprocessStart := time.Now()
+	if p, ok := c.opts.postprocessors[modelName]; ok && err == nil {
+		if prediction, err = p.Apply(prediction); err != nil {
+			err = fmt.Errorf("failed to postprocess prediction: %w", err)
+		}
+	}
+	if u, ok := c.opts.uncertainties[modelName]; ok && err == nil {
+		prediction.Uncertainty = &u
+	}
+	timer.since(StagePostprocess, postprocessStart)
+	if len(c.opts.sinks) > 0 {
+		c.recordPrediction(PredictionRecord{
+			RequestID:  requestID,
+			Model:      modelName,
+			App:        c.opts.appIdentity,
+			Metadata:   CallMetadata(ctx),
+			Time:       start,
+			Latency:    time.Since(start),
+			Input:      c.RedactInput(input),
+			Prediction: prediction,
+			Err:        err,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return prediction, nil
+}
+
+// GetModels returns the models currently loaded into the server.
+func (c *Client) GetModels(ctx context.Context) ([]ModelMetadata, error) {
+	var models []ModelMetadata
+	err := c.invoke(ctx, MethodGetModels, "", func(ctx context.Context) error {
+		var err error
+		models, err = c.transport.getModels(ctx)
+		return err
+	})
+	return models, err
+}
+
+// AddModel loads a model from the model store into the server. The model name
+// is the artefact name without extension, e.g. "catboost-titanic_model".
+func (c *Client) AddModel(ctx context.Context, modelName string) error {
+	return c.mutate(ctx, MethodAddModel, modelName, c.transport.addModel)
+}
+
+// UpdateModel reloads an existing model from the model store.
+func (c *Client) UpdateModel(ctx context.Context, modelName string) error {
+	return c.mutate(ctx, MethodUpdateModel, modelName, c.transport.updateModel)
+}
+
+// DeleteModel unloads a model from the server.
+func (c *Client) DeleteModel(ctx context.Context, modelName string) error {
+	return c.mutate(ctx, MethodDeleteModel, modelName, c.transport.deleteModel)
+}
+
+// mutate invokes a call changing the models of the server, deduplicated
+// under WithMutationDedup.
+func (c *Client) mutate(ctx context.Context, method, modelName string, call func(ctx context.Context, modelName string) error) error {
+	return c.dedup.do(ctx, method, modelName, func() error {
+		return c.invoke(ctx, method, modelName, func(ctx context.Context) error {
+			return call(ctx, modelName)
+		})
+	})
+}
+
+// Close releases the resources held by the client. It cancels the background
+// refreshes of the prediction cache and waits for them to return.
+func (c *Client) Close() error {
+	c.stopReports()
+	c.stopRefreshes()
+	return c.transport.close()
+}
@@ -0,0 +1,332 @@
+// Package cluster treats several independent J.A.M.S servers as a single
+// catalog of models, for fleets whose models do not fit on one server.
+//
+// Calls of a model are routed to the servers hosting it, found from the
+// merged catalog of all servers, which is cached:
+//
+//	c, err := cluster.New(cluster.Config{
+//		Endpoints: []string{"http://jams-a:3000", "http://jams-b:3000"},
+//	}, jams.WithRetry(3))
+//	defer c.Close()
+//
+//	prediction, err := c.Predict(ctx, "titanic_model", input)
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+var (
+	// ErrUnknownModel is returned for calls of a model no server hosts.
+	ErrUnknownModel = errors.New("no server hosts the model")
+	// ErrConflict is returned for calls of a model whose servers disagree on
+	// what it is, see Model.Conflicting.
+	ErrConflict = errors.New("servers host conflicting models of the same name")
+)
+
+// defaultCatalogTTL is the default of Config.CatalogTTL.
+const defaultCatalogTTL = 30 * time.Second
+
+// missRefreshInterval limits the refreshes of the catalog triggered by calls
+// of unknown models.
+const missRefreshInterval = time.Second
+
+// Config configures a Cluster.
+type Config struct {
+	// Endpoints of the servers, see jams.NewClient.
+	Endpoints []string
+	// CatalogTTL is how long the merged catalog is cached. Calls of a model
+	// missing from the catalog refresh it early, at most once a second.
+	// Defaults to 30s.
+	CatalogTTL time.Duration
+}
+
+// Placement is a server hosting a model.
+type Placement struct {
+	Endpoint string
+	Model    jams.ModelMetadata
+}
+
+// Model is a model of the merged catalog.
+type Model struct {
+	Name string
+	// Placements are the servers hosting the model, in the order of
+	// Config.Endpoints.
+	Placements []Placement
+}
+
+// Endpoints returns the endpoints of the servers hosting the model.
+func (m Model) Endpoints() []string {
+	endpoints := make([]string, len(m.Placements))
+	for i, p := range m.Placements {
+		endpoints[i] = p.Endpoint
+	}
+	return endpoints
+}
+
+// Conflicting reports whether the servers hosting the model disagree on its
+// framework, i.e. different models are served under the same name. Placements
+// of the same framework are replicas, whose paths and update timestamps may
+// differ.
+func (m Model) Conflicting() bool {
+	for _, p := range m.Placements[1:] {
+		if p.Model.Framework != m.Placements[0].Model.Framework {
+			return true
+		}
+	}
+	return false
+}
+
+// EndpointError is the failure of a server to list its models. The models of
+// the server are left out of the catalog until it lists them again.
+type EndpointError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *EndpointError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Endpoint, e.Err)
+}
+
+func (e *EndpointError) Unwrap() error {
+	return e.Err
+}
+
+type member struct {
+	endpoint string
+	client   *jams.Client
+}
+
+// catalog is the merged catalog at a point in time.
+type catalog struct {
+	models  map[string]*Model
+	fetched time.Time
+	err     error
+}
+
+// Cluster holds a client per server and routes calls by the merged catalog.
+// It is safe for concurrent use.
+type Cluster struct {
+	members []member
+	ttl     time.Duration
+
+	// refreshMu serialises refreshes, so that concurrent misses share one.
+	refreshMu sync.Mutex
+	catalog   atomic.Pointer[catalog]
+	// next spreads calls over the replicas of models.
+	next atomic.Uint64
+}
+
+// New creates a client for every endpoint of cfg. opts apply to all of them.
+// The catalog is fetched on first use.
+func New(cfg Config, opts ...jams.Option) (*Cluster, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, errors.New("cluster has no endpoints")
+	}
+	c := &Cluster{ttl: cfg.CatalogTTL}
+	if c.ttl <= 0 {
+		c.ttl = defaultCatalogTTL
+	}
+	seen := make(map[string]bool, len(cfg.Endpoints))
+	for _, endpoint := range cfg.Endpoints {
+		if seen[endpoint] {
+			c.Close()
+			return nil, fmt.Errorf("duplicate endpoint %q", endpoint)
+		}
+		seen[endpoint] = true
+		client, err := jams.NewClient(endpoint, opts...)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("endpoint %q: %w", endpoint, err)
+		}
+		c.members = append(c.members, member{endpoint: endpoint, client: client})
+	}
+	return c, nil
+}
+
+// Endpoints returns the endpoints of the servers, in the order of
+// Config.Endpoints.
+func (c *Cluster) Endpoints() []string {
+	endpoints := make([]string, len(c.members))
+	for i, m := range c.members {
+		endpoints[i] = m.endpoint
+	}
+	return endpoints
+}
+
+// Member returns the client of the server at endpoint, e.g. to add a model to
+// a chosen server.
+func (c *Cluster) Member(endpoint string) (*jams.Client, bool) {
+	for _, m := range c.members {
+		if m.endpoint == endpoint {
+			return m.client, true
+		}
+	}
+	return nil, false
+}
+
+// Refresh fetches the catalogs of all servers concurrently and merges them.
+// Servers failing to list their models are left out and reported as
+// EndpointErrors joined in the returned error; the catalog of the others is
+// used regardless.
+func (c *Cluster) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	return c.refresh(ctx).err
+}
+
+func (c *Cluster) refresh(ctx context.Context) *catalog {
+	fetched := make([][]jams.ModelMetadata, len(c.members))
+	errs := make([]error, len(c.members))
+	var wg sync.WaitGroup
+	for i, m := range c.members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			models, err := m.client.GetModels(ctx)
+			if err != nil {
+				errs[i] = &EndpointError{Endpoint: m.endpoint, Err: err}
+				return
+			}
+			fetched[i] = models
+		}()
+	}
+	wg.Wait()
+
+	cat := &catalog{models: make(map[string]*Model), fetched: time.Now(), err: errors.Join(errs...)}
+	for i, models := range fetched {
+		for _, metadata := range models {
+			model, ok := cat.models[metadata.Name]
+			if !ok {
+				model = &Model{Name: metadata.Name}
+				cat.models[metadata.Name] = model
+			}
+			model.Placements = append(model.Placements, Placement{Endpoint: c.members[i].endpoint, Model: metadata})
+		}
+	}
+	// a catalog fetched with a cancelled context lacks the servers it did
+	// not wait for, so it only serves the call which fetched it.
+	if ctx.Err() == nil {
+		c.catalog.Store(cat)
+	}
+	return cat
+}
+
+// current returns the catalog, refreshing it when older than maxAge.
+func (c *Cluster) current(ctx context.Context, maxAge time.Duration) *catalog {
+	if cat := c.catalog.Load(); cat != nil && time.Since(cat.fetched) < maxAge {
+		return cat
+	}
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	// another call may have refreshed the catalog while this one waited.
+	if cat := c.catalog.Load(); cat != nil && time.Since(cat.fetched) < maxAge {
+		return cat
+	}
+	return c.refresh(ctx)
+}
+
+// Catalog returns the merged catalog sorted by model name, refreshing it if
+// it is older than Config.CatalogTTL. The error reports the servers which
+// failed to list their models at the last refresh, whose models are missing.
+func (c *Cluster) Catalog(ctx context.Context) ([]Model, error) {
+	cat := c.current(ctx, c.ttl)
+	models := make([]Model, 0, len(cat.models))
+	for _, m := range cat.models {
+		models = append(models, Model{Name: m.Name, Placements: append([]Placement(nil), m.Placements...)})
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Name < models[j].Name
+	})
+	return models, cat.err
+}
+
+// Conflicts returns the models of the merged catalog which are Conflicting.
+func (c *Cluster) Conflicts(ctx context.Context) ([]Model, error) {
+	models, err := c.Catalog(ctx)
+	var conflicts []Model
+	for _, m := range models {
+		if m.Conflicting() {
+			conflicts = append(conflicts, m)
+		}
+	}
+	return conflicts, err
+}
+
+// Route returns the client of a server hosting the named model. Calls are
+// spread round robin over the servers hosting it. A model missing from the
+// catalog refreshes it before failing with ErrUnknownModel.
+func (c *Cluster) Route(ctx context.Context, modelName string) (*jams.Client, error) {
+	cat := c.current(ctx, c.ttl)
+	model, ok := cat.models[modelName]
+	if !ok {
+		cat = c.current(ctx, missRefreshInterval)
+		if model, ok = cat.models[modelName]; !ok {
+			if cat.err != nil {
+				return nil, fmt.Errorf("%w %q: %w", ErrUnknownModel, modelName, cat.err)
+			}
+			return nil, fmt.Errorf("%w %q", ErrUnknownModel, modelName)
+		}
+	}
+	if model.Conflicting() {
+		return nil, fmt.Errorf("%w: %q on %v", ErrConflict, modelName, model.Endpoints())
+	}
+	placement := model.Placements[(c.next.Add(1)-1)%uint64(len(model.Placements))]
+	client, _ := c.Member(placement.Endpoint)
+	return client, nil
+}
+
+// Predict makes predictions with the named model on a server hosting it.
+func (c *Cluster) Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error) {
+	client, err := c.Route(ctx, modelName)
+	if err != nil {
+		return nil, err
+	}
+	return client.Predict(ctx, modelName, input)
+}
+
+// GetModels returns the models of the merged catalog, once per model, with
+// the metadata of the first server hosting each.
+func (c *Cluster) GetModels(ctx context.Context) ([]jams.ModelMetadata, error) {
+	models, err := c.Catalog(ctx)
+	metadata := make([]jams.ModelMetadata, len(models))
+	for i, m := range models {
+		metadata[i] = m.Placements[0].Model
+	}
+	return metadata, err
+}
+
+// HealthCheck checks whether all servers are up.
+func (c *Cluster) HealthCheck(ctx context.Context) error {
+	errs := make([]error, len(c.members))
+	var wg sync.WaitGroup
+	for i, m := range c.members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.client.HealthCheck(ctx); err != nil {
+				errs[i] = &EndpointError{Endpoint: m.endpoint, Err: err}
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Close closes the clients of all servers.
+func (c *Cluster) Close() error {
+	var errs []error
+	for _, m := range c.members {
+		errs = append(errs, m.client.Close())
+	}
+	return errors.Join(errs...)
+}
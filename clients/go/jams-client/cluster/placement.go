@@ -0,0 +1,303 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultTolerance is the default of PlacementPolicy.Tolerance.
+const defaultTolerance = 0.2
+
+// PlacementPolicy guides PlanPlacement. The servers do not report the memory
+// of their models, so it is measured and provided by operators.
+type PlacementPolicy struct {
+	// Capacity is the memory available to models on each server, by
+	// endpoint, in bytes. Servers without a capacity are unbounded.
+	Capacity map[string]int64 `json:"capacity,omitempty" yaml:"capacity,omitempty"`
+	// Memory is the memory used by each model, by name, in bytes. Models
+	// without a size use none.
+	Memory map[string]int64 `json:"memory,omitempty" yaml:"memory,omitempty"`
+	// Traffic is the load of each model, by name, e.g. in requests per
+	// second. Defaults to the Predict requests made through the cluster,
+	// see Cluster.Traffic. The traffic of a replicated model is split evenly
+	// between its servers.
+	Traffic map[string]float64 `json:"traffic,omitempty" yaml:"traffic,omitempty"`
+	// Tolerance is how far above the mean traffic of the servers, as a
+	// fraction of it, the traffic of a server may be before models are moved
+	// away from it. Defaults to 0.2.
+	Tolerance float64 `json:"tolerance,omitempty" yaml:"tolerance,omitempty"`
+}
+
+// Move moves a model from one server to another.
+type Move struct {
+	Model string `json:"model"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	// Reason explains why the model is moved.
+	Reason string `json:"reason"`
+}
+
+// PlacementPlan is the ordered list of moves suggested by PlanPlacement.
+type PlacementPlan struct {
+	Moves []Move `json:"moves"`
+}
+
+// Empty reports whether the placement needs no change.
+func (p PlacementPlan) Empty() bool {
+	return len(p.Moves) == 0
+}
+
+// serverLoad is the planned load of a server.
+type serverLoad struct {
+	endpoint string
+	// capacity is 0 for unbounded servers.
+	capacity int64
+	memory   int64
+	traffic  float64
+	models   map[string]bool
+}
+
+func (s *serverLoad) fits(model string, memory int64) bool {
+	return !s.models[model] && (s.capacity == 0 || s.memory+memory <= s.capacity)
+}
+
+// free is the memory left on the server, the most for unbounded servers.
+func (s *serverLoad) free() int64 {
+	if s.capacity == 0 {
+		return 1<<63 - 1
+	}
+	return s.capacity - s.memory
+}
+
+// planner moves models between the servers of a placement in memory.
+type planner struct {
+	servers []*serverLoad
+	memory  map[string]int64
+	// share is the traffic of a model on each of its servers.
+	share map[string]float64
+	// movable holds the models which may move, each at most once.
+	movable map[string]bool
+	plan    PlacementPlan
+}
+
+func (p *planner) move(model string, from, to *serverLoad, reason string) {
+	from.memory -= p.memory[model]
+	from.traffic -= p.share[model]
+	delete(from.models, model)
+	to.memory += p.memory[model]
+	to.traffic += p.share[model]
+	to.models[model] = true
+	delete(p.movable, model)
+	p.plan.Moves = append(p.plan.Moves, Move{Model: model, From: from.endpoint, To: to.endpoint, Reason: reason})
+}
+
+// candidates returns the movable models of s, largest first by key.
+func (p *planner) candidates(s *serverLoad, key func(model string) float64) []string {
+	var models []string
+	for model := range s.models {
+		if p.movable[model] {
+			models = append(models, model)
+		}
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if ki, kj := key(models[i]), key(models[j]); ki != kj {
+			return ki > kj
+		}
+		return models[i] < models[j]
+	})
+	return models
+}
+
+// relieveMemory moves models away from the servers over their capacity, the
+// largest first, to the servers with the most free memory.
+func (p *planner) relieveMemory() {
+	for _, s := range p.servers {
+		for s.capacity > 0 && s.memory > s.capacity {
+			moved := false
+			for _, model := range p.candidates(s, func(m string) float64 { return float64(p.memory[m]) }) {
+				var target *serverLoad
+				for _, t := range p.servers {
+					if t.fits(model, p.memory[model]) && (target == nil || t.free() > target.free()) {
+						target = t
+					}
+				}
+				if target != nil {
+					reason := fmt.Sprintf("%s uses %s of its %s memory", s.endpoint, formatBytes(s.memory), formatBytes(s.capacity))
+					p.move(model, s, target, reason)
+					moved = true
+					break
+				}
+			}
+			if !moved {
+				break
+			}
+		}
+	}
+}
+
+// balanceTraffic moves models away from the busiest server while it serves
+// more than limit, choosing the move which best evens out the busiest server
+// and the target.
+func (p *planner) balanceTraffic(tolerance float64) {
+	var total float64
+	for _, s := range p.servers {
+		total += s.traffic
+	}
+	if total == 0 {
+		return
+	}
+	limit := total / float64(len(p.servers)) * (1 + tolerance)
+	for {
+		busiest := p.servers[0]
+		for _, s := range p.servers[1:] {
+			if s.traffic > busiest.traffic {
+				busiest = s
+			}
+		}
+		if busiest.traffic <= limit {
+			return
+		}
+		var (
+			bestModel  string
+			bestTarget *serverLoad
+			bestPeak   = busiest.traffic
+		)
+		for _, model := range p.candidates(busiest, func(m string) float64 { return p.share[m] }) {
+			share := p.share[model]
+			if share == 0 {
+				continue
+			}
+			for _, t := range p.servers {
+				if t == busiest || !t.fits(model, p.memory[model]) {
+					continue
+				}
+				if peak := max(busiest.traffic-share, t.traffic+share); peak < bestPeak {
+					bestModel, bestTarget, bestPeak = model, t, peak
+				}
+			}
+		}
+		if bestTarget == nil {
+			return
+		}
+		reason := fmt.Sprintf("%s serves %.0f%% of the traffic, above %.0f%%", busiest.endpoint, 100*busiest.traffic/total, 100*limit/total)
+		p.move(bestModel, busiest, bestTarget, reason)
+	}
+}
+
+// PlanPlacement suggests moves of models between the servers which bring each
+// server within its memory capacity, then even out the traffic of the servers
+// to within the policy's tolerance of their mean. It changes nothing; print
+// the plan for a dry run, or execute it with ApplyPlacement.
+//
+// Each model moves at most once. Models loaded by a server at startup are
+// served under their name without the framework prefix, e.g. "titanic_model",
+// and would be served under their artefact name once added to another server,
+// so only models added through the API, e.g. "catboost-titanic_model", move.
+// Conflicting models never move. Planning fails when a server cannot list its
+// models, since its load would be unknown.
+func (c *Cluster) PlanPlacement(ctx context.Context, policy PlacementPolicy) (PlacementPlan, error) {
+	if err := c.Refresh(ctx); err != nil {
+		return PlacementPlan{}, fmt.Errorf("incomplete catalog: %w", err)
+	}
+	models, _ := c.Catalog(ctx)
+	traffic := policy.Traffic
+	if traffic == nil {
+		traffic = c.Traffic()
+	}
+	tolerance := policy.Tolerance
+	if tolerance <= 0 {
+		tolerance = defaultTolerance
+	}
+
+	p := &planner{
+		memory:  make(map[string]int64, len(models)),
+		share:   make(map[string]float64, len(models)),
+		movable: make(map[string]bool, len(models)),
+	}
+	servers := make(map[string]*serverLoad, len(c.members))
+	for _, m := range c.members {
+		s := &serverLoad{endpoint: m.endpoint, capacity: policy.Capacity[m.endpoint], models: make(map[string]bool)}
+		servers[m.endpoint] = s
+		p.servers = append(p.servers, s)
+	}
+	for _, m := range models {
+		p.memory[m.Name] = policy.Memory[m.Name]
+		p.share[m.Name] = traffic[m.Name] / float64(len(m.Placements))
+		p.movable[m.Name] = !m.Conflicting() && strings.HasPrefix(m.Name, m.Placements[0].Model.Framework+"-")
+		for _, placement := range m.Placements {
+			s := servers[placement.Endpoint]
+			s.memory += p.memory[m.Name]
+			s.traffic += p.share[m.Name]
+			s.models[m.Name] = true
+		}
+	}
+
+	p.relieveMemory()
+	p.balanceTraffic(tolerance)
+	return p.plan, nil
+}
+
+// ApplyPlacement executes the moves of a plan in order: it adds the model to
+// the target server, waits for it to be ready, then deletes it from the
+// source server, so that the model stays served throughout. A model which
+// fails to load on the target stays on the source. It keeps going after a
+// failed move, returns the errors joined, and refreshes the catalog.
+func (c *Cluster) ApplyPlacement(ctx context.Context, plan PlacementPlan) error {
+	var errs []error
+	for _, move := range plan.Moves {
+		if err := c.applyMove(ctx, move); err != nil {
+			errs = append(errs, fmt.Errorf("failed to move model %s from %s to %s: %w", move.Model, move.From, move.To, err))
+		}
+	}
+	if err := c.Refresh(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Cluster) applyMove(ctx context.Context, move Move) error {
+	from, ok := c.Member(move.From)
+	if !ok {
+		return fmt.Errorf("unknown endpoint %q", move.From)
+	}
+	to, ok := c.Member(move.To)
+	if !ok {
+		return fmt.Errorf("unknown endpoint %q", move.To)
+	}
+	if err := to.AddModel(ctx, move.Model); err != nil {
+		return err
+	}
+	if err := to.WaitForModel(ctx, move.Model); err != nil {
+		return err
+	}
+	return from.DeleteModel(ctx, move.Model)
+}
+
+// Traffic returns the Predict requests made through the cluster per model,
+// summed over the servers.
+func (c *Cluster) Traffic() map[string]float64 {
+	traffic := make(map[string]float64)
+	for _, m := range c.members {
+		for _, stats := range m.client.ModelStats() {
+			traffic[stats.Model] += float64(stats.Requests)
+		}
+	}
+	return traffic
+}
+
+// formatBytes formats n in binary units, e.g. "1.5GiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, prefix := float64(n)/unit, 0
+	for value >= unit && prefix < 4 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f%ciB", value, "KMGTP"[prefix])
+}
@@ -0,0 +1,246 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// server is a J.A.M.S HTTP API serving the models of its map, by name to
+// framework. Models are added under their artefact name.
+type server struct {
+	mu     sync.Mutex
+	models map[string]string
+	// fail answers the additions of a model with a 500.
+	fail map[string]bool
+	// broken accepts the additions of a model which then never loads.
+	broken map[string]bool
+	calls  []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodGet {
+		var resp struct {
+			Total  int                  `json:"total"`
+			Models []jams.ModelMetadata `json:"models"`
+		}
+		for name, framework := range s.models {
+			resp.Models = append(resp.Models, jams.ModelMetadata{Name: name, Framework: framework})
+		}
+		sort.Slice(resp.Models, func(i, j int) bool { return resp.Models[i].Name < resp.Models[j].Name })
+		resp.Total = len(resp.Models)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	name := r.URL.Query().Get("model_name")
+	if r.Method != http.MethodDelete {
+		var req struct {
+			ModelName string `json:"model_name"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		name = req.ModelName
+	}
+	s.calls = append(s.calls, r.Method+" "+name)
+	switch {
+	case s.fail[name]:
+		http.Error(w, "failed to load the model", http.StatusInternalServerError)
+	case r.Method == http.MethodPost && !s.broken[name]:
+		framework, _, _ := strings.Cut(name, "-")
+		s.models[name] = framework
+	case r.Method == http.MethodDelete:
+		delete(s.models, name)
+	}
+}
+
+// newFleet returns a cluster of a server per models map, the servers, and a
+// function formatting moves with the index of their servers, e.g.
+// "catboost-a 0->1".
+func newFleet(t *testing.T, models ...map[string]string) (*Cluster, []*server, func(Move) string) {
+	t.Helper()
+	servers := make([]*server, len(models))
+	endpoints := make([]string, len(models))
+	index := make(map[string]int, len(models))
+	for i, m := range models {
+		servers[i] = &server{models: m, fail: make(map[string]bool), broken: make(map[string]bool)}
+		srv := httptest.NewServer(servers[i])
+		t.Cleanup(srv.Close)
+		endpoints[i] = srv.URL
+		index[srv.URL] = i
+	}
+	c, err := New(Config{Endpoints: endpoints},
+		jams.WithRetry(1), jams.WithBackoff(jams.ConstantBackoff{Interval: 5 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	name := func(m Move) string {
+		return fmt.Sprintf("%s %d->%d", m.Model, index[m.From], index[m.To])
+	}
+	return c, servers, name
+}
+
+func moves(plan PlacementPlan, name func(Move) string) []string {
+	var moves []string
+	for _, m := range plan.Moves {
+		moves = append(moves, name(m))
+	}
+	return moves
+}
+
+func TestPlanPlacement(t *testing.T) {
+	const gib = 1 << 30
+	tests := []struct {
+		name    string
+		servers []map[string]string
+		policy  PlacementPolicy
+		want    []string
+	}{
+		{
+			name: "memory",
+			servers: []map[string]string{
+				{"catboost-a": "catboost", "catboost-b": "catboost", "titanic_model": "catboost"},
+				{"catboost-c": "catboost"},
+			},
+			policy: PlacementPolicy{
+				Capacity: map[string]int64{"0": 10 * gib, "1": 10 * gib},
+				Memory:   map[string]int64{"catboost-a": 6 * gib, "catboost-b": 5 * gib, "titanic_model": 3 * gib, "catboost-c": gib},
+				Traffic:  map[string]float64{},
+			},
+			want: []string{"catboost-a 0->1"},
+		},
+		{
+			name: "traffic",
+			servers: []map[string]string{
+				{"lightgbm-x": "lightgbm", "lightgbm-y": "lightgbm"},
+				{"lightgbm-z": "lightgbm"},
+				{},
+			},
+			policy: PlacementPolicy{
+				Traffic:   map[string]float64{"lightgbm-x": 40, "lightgbm-y": 30, "lightgbm-z": 20},
+				Tolerance: 0.5,
+			},
+			want: []string{"lightgbm-x 0->2"},
+		},
+		{
+			name: "unmovable models",
+			servers: []map[string]string{
+				{"titanic_model": "catboost", "torch-iris": "torch"},
+				{"torch-iris": "tensorflow"},
+				{},
+			},
+			policy: PlacementPolicy{
+				Traffic: map[string]float64{"titanic_model": 100, "torch-iris": 100},
+			},
+		},
+		{
+			name: "balanced",
+			servers: []map[string]string{
+				{"lightgbm-x": "lightgbm"},
+				{"lightgbm-y": "lightgbm"},
+			},
+			policy: PlacementPolicy{
+				Traffic: map[string]float64{"lightgbm-x": 11, "lightgbm-y": 9},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, name := newFleet(t, tt.servers...)
+			// capacities are keyed by server index in the table.
+			capacity := make(map[string]int64)
+			for i, endpoint := range c.Endpoints() {
+				if v, ok := tt.policy.Capacity[fmt.Sprint(i)]; ok {
+					capacity[endpoint] = v
+				}
+			}
+			tt.policy.Capacity = capacity
+
+			plan, err := c.PlanPlacement(context.Background(), tt.policy)
+			if err != nil {
+				t.Fatalf("PlanPlacement: %v", err)
+			}
+			if got := moves(plan, name); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("moves = %q, want %q", got, tt.want)
+			}
+			if plan.Empty() != (len(tt.want) == 0) {
+				t.Errorf("Empty() = %v", plan.Empty())
+			}
+
+			// once applied, the placement needs no further change.
+			if err := c.ApplyPlacement(context.Background(), plan); err != nil {
+				t.Fatalf("ApplyPlacement: %v", err)
+			}
+			plan, err = c.PlanPlacement(context.Background(), tt.policy)
+			if err != nil {
+				t.Fatalf("PlanPlacement: %v", err)
+			}
+			if !plan.Empty() {
+				t.Fatalf("moves after applying = %q, want none", moves(plan, name))
+			}
+		})
+	}
+}
+
+func TestApplyPlacement(t *testing.T) {
+	c, servers, _ := newFleet(t,
+		map[string]string{"lightgbm-x": "lightgbm", "lightgbm-y": "lightgbm", "lightgbm-z": "lightgbm"},
+		map[string]string{},
+		map[string]string{},
+	)
+	endpoints := c.Endpoints()
+	servers[1].fail["lightgbm-x"] = true
+	servers[2].broken["lightgbm-y"] = true
+	plan := PlacementPlan{Moves: []Move{
+		{Model: "lightgbm-x", From: endpoints[0], To: endpoints[1]},
+		{Model: "lightgbm-z", From: endpoints[0], To: endpoints[2]},
+		{Model: "lightgbm-y", From: endpoints[0], To: endpoints[2]},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := c.ApplyPlacement(ctx, plan)
+	var httpErr *jams.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("ApplyPlacement error = %v, want the failure to add lightgbm-x", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "failed to move model lightgbm-y") {
+		t.Errorf("ApplyPlacement error = %v, want the timeout waiting for lightgbm-y", err)
+	}
+
+	// models failing to load on their target stay on the source.
+	want := [][]string{
+		{"DELETE lightgbm-z"},
+		{"POST lightgbm-x"},
+		{"POST lightgbm-z", "POST lightgbm-y"},
+	}
+	for i, s := range servers {
+		if !reflect.DeepEqual(s.calls, want[i]) {
+			t.Errorf("calls of server %d = %q, want %q", i, s.calls, want[i])
+		}
+	}
+	wantModels := []map[string]string{
+		{"lightgbm-x": "lightgbm", "lightgbm-y": "lightgbm"},
+		{},
+		{"lightgbm-z": "lightgbm"},
+	}
+	for i, s := range servers {
+		if !reflect.DeepEqual(s.models, wantModels[i]) {
+			t.Errorf("models of server %d = %v, want %v", i, s.models, wantModels[i])
+		}
+	}
+}
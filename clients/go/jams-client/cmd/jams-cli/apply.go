@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/reconcile"
+)
+
+// runApply reconciles the server against a models manifest:
+//
+//	prune: true
+//	models:
+//	  - name: titanic_model
+//	    framework: catboost
+//	    generation: 2
+//	    inputs:
+//	      age: numeric
+//	      sex: categorical
+//	    labels:
+//	      team: risk
+//
+// With --deployed, the manifest of the deployed release, models whose
+// generation was bumped are reloaded once their inputs pass the breaking
+// change check.
+func runApply(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	var server serverFlags
+	server.register(fs)
+	file := fs.String("f", "", "path to the models manifest, - for stdin")
+	dryRun := fs.Bool("dry-run", false, "print the changes without applying them")
+	deployed := fs.String("deployed", "", "path to the manifest of the deployed release, to reload bumped generations")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("a manifest is required, use -f")
+	}
+
+	desired, err := readManifest(*file)
+	if err != nil {
+		return err
+	}
+
+	client, err := server.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	reconciler := reconcile.New(client)
+	if *deployed != "" {
+		observed, err := readManifest(*deployed)
+		if err != nil {
+			return fmt.Errorf("deployed manifest: %w", err)
+		}
+		reconciler.Observe(observed)
+	}
+	plan, err := reconciler.Plan(ctx, desired)
+	if err != nil {
+		return err
+	}
+	printPlan(os.Stdout, plan)
+	if *dryRun || plan.Empty() {
+		return nil
+	}
+
+	err = reconciler.Apply(ctx, plan)
+	fmt.Println()
+	for _, s := range reconciler.Statuses() {
+		if s.LastAction == "" {
+			continue
+		}
+		line := fmt.Sprintf("%s %s: %s", s.Model, s.LastAction, s.Phase)
+		if s.Error != "" {
+			line += " (" + s.Error + ")"
+		}
+		fmt.Println(line)
+	}
+	return err
+}
+
+func readManifest(path string) (reconcile.ModelSet, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return reconcile.ModelSet{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var set reconcile.ModelSet
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&set); err != nil {
+		return reconcile.ModelSet{}, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return set, set.Validate()
+}
+
+func printPlan(w io.Writer, plan reconcile.Plan) {
+	if plan.Empty() {
+		fmt.Fprintln(w, "no changes, server matches the manifest")
+		return
+	}
+	for _, a := range plan.Actions {
+		symbol := "~"
+		switch a.Type {
+		case reconcile.ActionAdd:
+			symbol = "+"
+		case reconcile.ActionDelete:
+			symbol = "-"
+		}
+		fmt.Fprintf(w, "%s %-6s %s (%s): %s\n", symbol, a.Type, a.Model, a.Target, a.Reason)
+		for _, c := range a.SchemaChanges {
+			fmt.Fprintf(w, "           %s\n", c)
+		}
+	}
+	for _, warning := range plan.Warnings {
+		fmt.Fprintln(w, "warning:", warning)
+	}
+}
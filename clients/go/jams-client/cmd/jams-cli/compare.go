@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/diffpred"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// runCompare scores the same records with two models and reports how much
+// their predictions agree, e.g. to decide whether a challenger replaces the
+// champion.
+func runCompare(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	var server serverFlags
+	server.register(fs)
+	output := registerOutput(fs)
+	modelA := fs.String("model-a", "", "name of the first model, e.g. the champion")
+	modelB := fs.String("model-b", "", "name of the second model, e.g. the challenger")
+	input := fs.String("input", "", "file of JSON records, one object per line or arrays of objects, - for stdin")
+	batchSize := fs.Int("batch-size", 100, "number of records sent per request")
+	var tolerance types.Tolerance
+	fs.Float64Var(&tolerance.Abs, "tolerance", 1e-6, "absolute difference under which two values agree")
+	fs.Float64Var(&tolerance.Rel, "relative-tolerance", 0, "difference relative to the value of model a under which two values agree")
+	fs.Uint64Var(&tolerance.ULPs, "ulps", 0, "units in the last place within which two values agree, e.g. across architectures")
+	fs.BoolVar(&tolerance.Float32, "float32", false, "count --ulps in single precision, for models computing in float32")
+	var threshold *float64
+	fs.Func("threshold", "decision threshold, reports how often both models take the same side of it", func(s string) error {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		threshold = &v
+		return nil
+	})
+	reportPath := fs.String("report", "", "file the detailed report is written to, with the most differing records and the differences by input feature")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(*output); err != nil {
+		return err
+	}
+	if *modelA == "" || *modelB == "" {
+		return errors.New("two models are required, use --model-a and --model-b")
+	}
+	if *input == "" {
+		return errors.New("an input is required, use --input")
+	}
+	if *batchSize <= 0 {
+		return errors.New("--batch-size must be greater than zero")
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	client, err := server.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	diff := diffpred.New(diffpred.Options{Tolerance: tolerance, Threshold: threshold})
+	records := newJSONRecordReader(bufio.NewReader(r))
+	for start := 0; ; {
+		batch, err := readRecords(records, *batchSize)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read record %d: %w", start+len(batch), err)
+		}
+		if len(batch) > 0 {
+			in, a, b, perr := predictBoth(ctx, client, *modelA, *modelB, batch)
+			if perr != nil {
+				return fmt.Errorf("records %d to %d: %w", start, start+len(batch)-1, perr)
+			}
+			diff.Add(in, a, b)
+			start += len(batch)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	report := diff.Report()
+	for _, name := range report.OnlyA {
+		fmt.Fprintf(os.Stderr, "warning: output %s is only returned by model a\n", name)
+	}
+	for _, name := range report.OnlyB {
+		fmt.Fprintf(os.Stderr, "warning: output %s is only returned by model b\n", name)
+	}
+	if *reportPath != "" {
+		if err := writeReport(*reportPath, report); err != nil {
+			return err
+		}
+	}
+	return render(os.Stdout, *output, comparisonTable(report))
+}
+
+// writeReport writes the text form of report to path.
+func writeReport(path string, report diffpred.Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteText(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readRecords reads up to size records from r. It returns io.EOF along with
+// the last records.
+func readRecords(r jams.RecordReader, size int) ([]map[string]any, error) {
+	records := make([]map[string]any, 0, size)
+	for len(records) < size {
+		record, err := r.Read()
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// predictBoth predicts batch with both models at once.
+func predictBoth(ctx context.Context, client *jams.Client, modelA, modelB string, batch []map[string]any) (*types.Input, *types.Prediction, *types.Prediction, error) {
+	in, err := types.NewInputFromRecords(batch)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var (
+		b    *types.Prediction
+		errB error
+		done = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		b, errB = client.Predict(ctx, modelB, in)
+	}()
+	a, errA := client.Predict(ctx, modelA, in)
+	<-done
+	if errA != nil {
+		return nil, nil, nil, fmt.Errorf("model %s: %w", modelA, errA)
+	}
+	if errB != nil {
+		return nil, nil, nil, fmt.Errorf("model %s: %w", modelB, errB)
+	}
+	return in, a, b, nil
+}
+
+// comparisonTable renders the columns and argmax agreements of report.
+func comparisonTable(report diffpred.Report) table {
+	t := table{
+		columns: []column{
+			{name: "OUTPUT"}, {name: "COLUMN"}, {name: "RECORDS"},
+			{name: "MEAN A"}, {name: "MEAN B"}, {name: "STD A", wide: true}, {name: "STD B", wide: true},
+			{name: "CORRELATION"}, {name: "MEAN ABS DIFF"}, {name: "MAX ABS DIFF", wide: true},
+			{name: "AGREEMENT"}, {name: "DECISION AGREEMENT", wide: report.Threshold == nil},
+		},
+		raw: report,
+	}
+	for _, c := range report.Columns {
+		t.rows = append(t.rows, []string{
+			c.Output, strconv.Itoa(c.Column), strconv.Itoa(c.Records),
+			formatStat(c.MeanA), formatStat(c.MeanB), formatStat(c.StdA), formatStat(c.StdB),
+			formatOptional(c.Correlation), formatStat(c.MeanAbsDiff), formatStat(c.MaxAbsDiff),
+			formatPercent(c.Agreement), formatOptionalPercent(c.DecisionAgreement),
+		})
+	}
+	for _, a := range report.Argmax {
+		t.rows = append(t.rows, []string{
+			a.Output, "argmax", strconv.Itoa(a.Records), "", "", "", "", "", "", "", formatPercent(a.Agreement), "",
+		})
+	}
+	return t
+}
+
+func formatStat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+func formatOptional(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return formatStat(*v)
+}
+
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', 2, 64) + "%"
+}
+
+func formatOptionalPercent(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return formatPercent(*v)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// errDiffer is returned by diff when the catalogs differ so the command exits
+// with status 1 like diff(1).
+var errDiffer = errors.New("model catalogs differ")
+
+// runDiff compares the model catalogs of two servers.
+func runDiff(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	protocol := fs.String("protocol", envOr("JAMS_PROTOCOL", "http"), "protocol of both servers, http or grpc (env JAMS_PROTOCOL)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jams-cli diff [flags] <server-a> <server-b>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	a, err := fetchModels(ctx, *protocol, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := fetchModels(ctx, *protocol, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	if !printDiff(os.Stdout, fs.Arg(0), fs.Arg(1), a, b) {
+		return errDiffer
+	}
+	return nil
+}
+
+func fetchModels(ctx context.Context, protocol, addr string) (map[string]jams.ModelMetadata, error) {
+	client, err := connect(protocol, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	models, err := client.GetModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get models from %s: %w", addr, err)
+	}
+	byName := make(map[string]jams.ModelMetadata, len(models))
+	for _, m := range models {
+		byName[m.Name] = m
+	}
+	return byName, nil
+}
+
+// printDiff writes the differences between two catalogs and reports whether
+// they are identical. Models are compared by framework and path; update
+// timestamps are expected to differ between servers and are ignored.
+func printDiff(w io.Writer, nameA, nameB string, a, b map[string]jams.ModelMetadata) bool {
+	names := make(map[string]bool, len(a)+len(b))
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", nameA, nameB)
+	same := true
+	for _, name := range sorted {
+		ma, inA := a[name]
+		mb, inB := b[name]
+		switch {
+		case !inB:
+			same = false
+			fmt.Fprintf(w, "- %s (%s)\n", name, ma.Framework)
+		case !inA:
+			same = false
+			fmt.Fprintf(w, "+ %s (%s)\n", name, mb.Framework)
+		case ma.Framework != mb.Framework || ma.Path != mb.Path:
+			same = false
+			fmt.Fprintf(w, "~ %s\n", name)
+			if ma.Framework != mb.Framework {
+				fmt.Fprintf(w, "    framework: %s -> %s\n", ma.Framework, mb.Framework)
+			}
+			if ma.Path != mb.Path {
+				fmt.Fprintf(w, "    path: %s -> %s\n", ma.Path, mb.Path)
+			}
+		}
+	}
+	if same {
+		fmt.Fprintf(w, "%d models, catalogs match\n", len(sorted))
+	}
+	return same
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/reconcile"
+)
+
+// Files written to the export tarball.
+const (
+	catalogFile  = "catalog.json"
+	manifestFile = "models.yaml"
+)
+
+// catalog is the metadata captured by export.
+type catalog struct {
+	Source     string               `json:"source"`
+	ExportedAt time.Time            `json:"exported_at"`
+	Models     []jams.ModelMetadata `json:"models"`
+}
+
+// runExport writes the model catalog of a server to a tarball holding the raw
+// metadata and a manifest which can be passed to apply or import.
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var server serverFlags
+	server.register(fs)
+	out := fs.String("o", "jams-catalog.tar.gz", "path of the tarball to write, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := server.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	models, err := client.GetModels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get models: %w", err)
+	}
+
+	catalogJSON, err := json.MarshalIndent(catalog{
+		Source:     server.addr,
+		ExportedAt: time.Now().UTC(),
+		Models:     models,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestYAML, err := yaml.Marshal(reconcile.FromMetadata(models))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeTarball(w, map[string][]byte{
+		catalogFile:  catalogJSON,
+		manifestFile: manifestYAML,
+	}); err != nil {
+		return err
+	}
+	if *out != "-" {
+		fmt.Printf("exported %d models to %s\n", len(models), *out)
+	}
+	return nil
+}
+
+// runImport restores a catalog written by export onto a server. The model
+// artefacts must already be present in the target server's model store.
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	var server serverFlags
+	server.register(fs)
+	in := fs.String("f", "", "path of the tarball to read, - for stdin")
+	prune := fs.Bool("prune", false, "delete models which are not in the catalog")
+	dryRun := fs.Bool("dry-run", false, "print the changes without applying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.New("a tarball is required, use -f")
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	files, err := readTarball(r)
+	if err != nil {
+		return err
+	}
+	manifest, ok := files[manifestFile]
+	if !ok {
+		return fmt.Errorf("tarball has no %s", manifestFile)
+	}
+	var desired reconcile.ModelSet
+	if err := yaml.Unmarshal(manifest, &desired); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", manifestFile, err)
+	}
+	desired.Prune = *prune
+
+	client, err := server.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	reconciler := reconcile.New(client)
+	plan, err := reconciler.Plan(ctx, desired)
+	if err != nil {
+		return err
+	}
+	printPlan(os.Stdout, plan)
+	if *dryRun || plan.Empty() {
+		return nil
+	}
+	return reconciler.Apply(ctx, plan)
+}
+
+func writeTarball(w io.Writer, files map[string][]byte) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, name := range []string{catalogFile, manifestFile} {
+		data := files[name]
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func readTarball(r io.Reader) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tarball: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tarball: %w", err)
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, io.LimitReader(tr, 64<<20)); err != nil {
+			return nil, err
+		}
+		files[header.Name] = buf.Bytes()
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// errUnhealthy is returned by health when a server is unhealthy so the
+// command exits with status 1.
+var errUnhealthy = errors.New("servers are unhealthy")
+
+// endpointStatus is the json rendering of jams.EndpointHealth.
+type endpointStatus struct {
+	Endpoint  string  `json:"endpoint"`
+	Healthy   bool    `json:"healthy"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// runHealth probes the health of several servers.
+func runHealth(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("health", flag.ContinueOnError)
+	output := registerOutput(fs)
+	timeout := fs.Duration("timeout", 5*time.Second, "timeout of each health check")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jams-cli health [flags] <endpoint>...")
+		fmt.Fprintln(fs.Output(), "Endpoints starting with http:// or https:// are probed over HTTP, others over gRPC.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(*output); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	identity := jams.WithAppIdentity(jams.AppIdentity{Name: "jams-cli", Version: jams.Version})
+	report := jams.HealthCheckAll(ctx, fs.Args(), identity, jams.WithTimeouts(jams.Timeouts{Overall: *timeout}))
+
+	t := table{columns: []column{{name: "ENDPOINT"}, {name: "HEALTHY"}, {name: "LATENCY"}, {name: "ERROR"}}}
+	statuses := make([]endpointStatus, 0, len(report))
+	healthy := true
+	for _, h := range report {
+		status := endpointStatus{Endpoint: h.Endpoint, Healthy: h.Healthy, LatencyMS: float64(h.Latency.Microseconds()) / 1000}
+		if h.Err != nil {
+			status.Error = h.Err.Error()
+		}
+		statuses = append(statuses, status)
+		t.rows = append(t.rows, []string{h.Endpoint, fmt.Sprint(h.Healthy), h.Latency.Round(time.Millisecond).String(), status.Error})
+		healthy = healthy && h.Healthy
+	}
+	t.raw = statuses
+	if err := render(os.Stdout, *output, t); err != nil {
+		return err
+	}
+	if !healthy {
+		return errUnhealthy
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Command jams-cli manages and queries J.A.M.S servers.
+//
+//	jams-cli apply -f models.yaml --dry-run
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+const usage = `Usage: jams-cli [--output format] <command> [flags]
+
+Commands:
+  apply      reconcile the server against a models manifest
+  compare    report how much the predictions of two models agree
+  diff       compare the model catalogs of two servers
+  export     write the model catalog of a server to a tarball
+  health     probe the health of several servers
+  import     restore a catalog written by export onto a server
+  models     list or watch the models loaded into the server
+  placement  move models between servers by memory and traffic
+  predict    make predictions with a model
+  replay     re-send the calls of an audit log and compare the predictions
+  traffic    send synthetic predictions, e.g. to soak test the server
+  verify     run model unit tests against the server
+
+Global flags:
+  --output  output format: table, wide, json or csv (env JAMS_OUTPUT)
+
+Run 'jams-cli <command> -h' for command flags.
+`
+
+type command func(ctx context.Context, args []string) error
+
+var commands = map[string]command{
+	"apply":     runApply,
+	"compare":   runCompare,
+	"diff":      runDiff,
+	"export":    runExport,
+	"health":    runHealth,
+	"import":    runImport,
+	"models":    runModels,
+	"placement": runPlacement,
+	"predict":   runPredict,
+	"replay":    runReplay,
+	"traffic":   runTraffic,
+	"verify":    runVerify,
+}
+
+func main() {
+	global := flag.NewFlagSet("jams-cli", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	global.StringVar(&globalOutput, "output", globalOutput, "")
+	if err := global.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if err := validateFormat(globalOutput); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+
+	args := global.Args()
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd(ctx, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		if errors.Is(err, errDiffer) || errors.Is(err, errTestsFailed) || errors.Is(err, errUnhealthy) || errors.Is(err, errReplayDiffers) {
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// serverFlags holds the flags used to connect to a server.
+type serverFlags struct {
+	addr     string
+	protocol string
+}
+
+func (f *serverFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.addr, "addr", envOr("JAMS_ADDR", ""), "server address, defaults to http://localhost:3000 or localhost:4000 for grpc (env JAMS_ADDR)")
+	fs.StringVar(&f.protocol, "protocol", envOr("JAMS_PROTOCOL", "http"), "server protocol, http or grpc (env JAMS_PROTOCOL)")
+}
+
+func (f *serverFlags) client() (*jams.Client, error) {
+	return connect(f.protocol, f.addr)
+}
+
+// connect returns a client for the given protocol and address, identifying
+// itself as jams-cli to the server.
+func connect(protocol, addr string) (*jams.Client, error) {
+	identity := jams.WithAppIdentity(jams.AppIdentity{Name: "jams-cli", Version: jams.Version})
+	switch strings.ToLower(protocol) {
+	case "http":
+		if addr == "" {
+			addr = "http://localhost:3000"
+		}
+		return jams.NewHTTPClient(addr, identity)
+	case "grpc":
+		if addr == "" {
+			addr = "localhost:4000"
+		}
+		return jams.NewGRPCClient(addr, identity)
+	default:
+		return nil, fmt.Errorf("unknown protocol %q", protocol)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+var modelsCommands = map[string]command{
+	"list":  runModelsList,
+	"watch": runModelsWatch,
+}
+
+// runModels dispatches the models subcommands.
+func runModels(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: jams-cli models <list|watch> [flags]")
+		return flag.ErrHelp
+	}
+	cmd, ok := modelsCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown models command %q", args[0])
+	}
+	return cmd(ctx, args[1:])
+}
+
+// runModelsList prints the models loaded into the server, optionally only
+// those matching the filter flags.
+func runModelsList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("models list", flag.ContinueOnError)
+	var server serverFlags
+	server.register(fs)
+	output := registerOutput(fs)
+	var query jams.ModelQuery
+	fs.StringVar(&query.Name, "model", "", "only list models matching this glob pattern")
+	pattern := fs.String("regexp", "", "only list models whose name matches this regular expression")
+	fs.StringVar(&query.Framework, "framework", "", "only list models of this framework")
+	since := fs.Duration("since", 0, "only list models loaded or updated within this duration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(*output); err != nil {
+		return err
+	}
+	if _, err := path.Match(query.Name, ""); err != nil {
+		return fmt.Errorf("invalid model pattern: %w", err)
+	}
+	if *pattern != "" {
+		re, err := regexp.Compile(*pattern)
+		if err != nil {
+			return fmt.Errorf("invalid name regexp: %w", err)
+		}
+		query.NameRegexp = re
+	}
+	if *since > 0 {
+		query.UpdatedAfter = time.Now().Add(-*since)
+	}
+
+	client, err := server.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	models, err := client.SearchModels(ctx, query)
+	if err != nil {
+		return err
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Name < models[j].Name
+	})
+	return render(os.Stdout, *output, modelsTable(models))
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Output formats accepted by --output.
+const (
+	formatTable = "table"
+	formatWide  = "wide"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// globalOutput is the format set with the global --output flag. Commands
+// default their own --output flag to it.
+var globalOutput = envOr("JAMS_OUTPUT", formatTable)
+
+func registerOutput(fs *flag.FlagSet) *string {
+	return fs.String("output", globalOutput, "output format: table, wide, json or csv (env JAMS_OUTPUT)")
+}
+
+func validateFormat(format string) error {
+	switch format {
+	case formatTable, formatWide, formatJSON, formatCSV:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, use table, wide, json or csv", format)
+	}
+}
+
+// column is a table column. Wide columns are only shown by the wide and csv
+// formats while narrow columns are only shown by the table format.
+type column struct {
+	name   string
+	wide   bool
+	narrow bool
+}
+
+func (c column) visible(wide bool) bool {
+	if wide {
+		return !c.narrow
+	}
+	return !c.wide
+}
+
+// table is tabular data rendered by the table, wide and csv formats. The json
+// format renders raw instead.
+type table struct {
+	columns []column
+	rows    [][]string
+	raw     any
+}
+
+func render(w io.Writer, format string, t table) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t.raw)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(t.headers(true)); err != nil {
+			return err
+		}
+		for _, row := range t.rows {
+			if err := cw.Write(t.project(row, true)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case formatTable, formatWide:
+		wide := format == formatWide
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.headers(wide), "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(t.project(row, wide), "\t"))
+		}
+		return tw.Flush()
+	default:
+		return validateFormat(format)
+	}
+}
+
+func (t table) headers(wide bool) []string {
+	headers := make([]string, 0, len(t.columns))
+	for _, c := range t.columns {
+		if c.visible(wide) {
+			headers = append(headers, c.name)
+		}
+	}
+	return headers
+}
+
+// project drops the cells of a row which are not visible in the format.
+func (t table) project(row []string, wide bool) []string {
+	cells := make([]string, 0, len(row))
+	for i, cell := range row {
+		if t.columns[i].visible(wide) {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+func modelsTable(models []jams.ModelMetadata) table {
+	t := table{
+		columns: []column{{name: "NAME"}, {name: "FRAMEWORK"}, {name: "LAST UPDATED"}, {name: "PATH", wide: true}},
+		raw:     models,
+	}
+	for _, m := range models {
+		t.rows = append(t.rows, []string{m.Name, m.Framework, m.LastUpdated, m.Path})
+	}
+	return t
+}
+
+// predictionTable renders one row per record. The table format joins the
+// values of each output in a single cell while the wide and csv formats use
+// one column per value.
+func predictionTable(p *types.Prediction) table {
+	t := table{raw: p.Outputs}
+	t.columns = append(t.columns, column{name: "RECORD"})
+
+	names := p.Names()
+	widths := make([]int, len(names))
+	records := 0
+	for i, name := range names {
+		rows, _ := p.Output(name)
+		records = max(records, len(rows))
+		for _, row := range rows {
+			widths[i] = max(widths[i], len(row))
+		}
+		t.columns = append(t.columns, column{name: strings.ToUpper(name), narrow: true})
+		for j := 0; j < widths[i]; j++ {
+			t.columns = append(t.columns, column{name: fmt.Sprintf("%s[%d]", name, j), wide: true})
+		}
+	}
+
+	for r := 0; r < records; r++ {
+		row := []string{strconv.Itoa(r)}
+		for i, name := range names {
+			rows, _ := p.Output(name)
+			var values []float64
+			if r < len(rows) {
+				values = rows[r]
+			}
+			joined := make([]string, len(values))
+			for j, v := range values {
+				joined[j] = strconv.FormatFloat(v, 'g', 6, 64)
+			}
+			row = append(row, strings.Join(joined, ", "))
+			for j := 0; j < widths[i]; j++ {
+				cell := ""
+				if j < len(values) {
+					cell = strconv.FormatFloat(values[j], 'g', -1, 64)
+				}
+				row = append(row, cell)
+			}
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/cluster"
+)
+
+// placementFile is the policy read by placement:
+//
+//	endpoints: [http://jams-a:3000, http://jams-b:3000]
+//	capacity:
+//	  http://jams-a:3000: 8589934592
+//	memory:
+//	  catboost-titanic_model: 1073741824
+//	traffic:
+//	  catboost-titanic_model: 120
+type placementFile struct {
+	Endpoints               []string `yaml:"endpoints"`
+	cluster.PlacementPolicy `yaml:",inline"`
+}
+
+// runPlacement plans and applies moves of models between the servers of a
+// cluster.
+func runPlacement(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("placement", flag.ContinueOnError)
+	file := fs.String("f", "", "path to the placement policy, - for stdin")
+	dryRun := fs.Bool("dry-run", false, "print the moves without applying them")
+	output := registerOutput(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(*output); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("a placement policy is required, use -f")
+	}
+
+	policy, err := readPlacement(*file)
+	if err != nil {
+		return err
+	}
+	c, err := cluster.New(cluster.Config{Endpoints: policy.Endpoints})
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	plan, err := c.PlanPlacement(ctx, policy.PlacementPolicy)
+	if err != nil {
+		return err
+	}
+	if plan.Empty() {
+		fmt.Println("no moves, placement matches the policy")
+		return nil
+	}
+	t := table{columns: []column{{name: "MODEL"}, {name: "FROM"}, {name: "TO"}, {name: "REASON"}}, raw: plan}
+	for _, m := range plan.Moves {
+		t.rows = append(t.rows, []string{m.Model, m.From, m.To, m.Reason})
+	}
+	if err := render(os.Stdout, *output, t); err != nil {
+		return err
+	}
+	if *dryRun {
+		return nil
+	}
+	return c.ApplyPlacement(ctx, plan)
+}
+
+func readPlacement(path string) (placementFile, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return placementFile{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var policy placementFile
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil {
+		return placementFile{}, fmt.Errorf("failed to parse placement policy: %w", err)
+	}
+	return policy, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/checkpoint"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/dlq"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/shard"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// runPredict makes predictions for a JSON input given on the command line or,
+// without --input, for records read from stdin.
+func runPredict(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("predict", flag.ContinueOnError)
+	var server serverFlags
+	server.register(fs)
+	output := registerOutput(fs)
+	model := fs.String("model", "", "name of the model to use")
+	input := fs.String("input", "", `model input as JSON, e.g. '{"age": [22.0], "sex": ["male"]}'; reads records from stdin when empty`)
+	batchSize := fs.Int("batch-size", 100, "number of stdin records sent per request")
+	concurrency := fs.Int("concurrency", 1, "number of stdin batches predicted at once")
+	includeInput := fs.Bool("include-input", false, "include the input record in each stdin output line")
+	checkpointPath := fs.String("checkpoint", "", "record the stdin records scored in this file and skip them when run again, to resume an interrupted run")
+	shardFlag := fs.String("shard", "", `score only the stdin records of shard "index/count", e.g. "0/4", to split the input across workers`)
+	shardKey := fs.String("shard-key", "", "comma separated fields assigning records to shards, defaults to the whole record")
+	deadLetters := fs.String("dead-letters", "", "append the stdin batches that fail to this JSONL file and carry on, instead of stopping")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(*output); err != nil {
+		return err
+	}
+	if *model == "" {
+		return errors.New("a model is required, use --model")
+	}
+	if *batchSize <= 0 {
+		return errors.New("--batch-size must be greater than zero")
+	}
+	if *concurrency <= 0 {
+		return errors.New("--concurrency must be greater than zero")
+	}
+
+	client, err := server.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if *input == "" {
+		var queue jams.DeadLetterQueue
+		if *deadLetters != "" {
+			q, err := dlq.OpenFile(*deadLetters)
+			if err != nil {
+				return err
+			}
+			defer q.Close()
+			queue = cliDeadLetters{q}
+		}
+		opts := jams.StreamOptions{ChunkSize: *batchSize, Concurrency: *concurrency, DeadLetters: queue}
+		var spec *shard.Spec
+		if *shardFlag != "" {
+			s, err := shard.Parse(*shardFlag)
+			if err != nil {
+				return err
+			}
+			spec = &s
+		}
+		if *checkpointPath != "" {
+			path := *checkpointPath
+			if spec != nil {
+				path = checkpoint.ShardPath(path, spec.Index, spec.Count)
+			}
+			opts.Checkpoint = checkpoint.File{Path: path}
+		}
+		var records jams.RecordReader = newJSONRecordReader(bufio.NewReader(os.Stdin))
+		if spec != nil {
+			var fields []string
+			if *shardKey != "" {
+				fields = strings.Split(*shardKey, ",")
+			}
+			records = shard.ByHash(records, *spec, fields...)
+		}
+		return predictStream(ctx, client, *model, records, os.Stdout, opts, *includeInput)
+	}
+
+	in, err := types.ParseInput([]byte(*input))
+	if err != nil {
+		return err
+	}
+	prediction, err := client.Predict(ctx, *model, in)
+	if err != nil {
+		return err
+	}
+	return render(os.Stdout, *output, predictionTable(prediction))
+}
+
+// predictStream makes predictions for records and writes one JSON line per
+// record to w as soon as its batch has been scored. Records are read as
+// batches are scored, so memory stays bounded however many there are.
+func predictStream(ctx context.Context, client *jams.Client, model string, records jams.RecordReader, w io.Writer, opts jams.StreamOptions, includeInput bool) error {
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+	return client.PredictStream(ctx, model, records, opts, func(chunk jams.BatchChunk, batch []map[string]any) error {
+		for i, record := range batch {
+			line := make(map[string]any, len(chunk.Prediction.Outputs)+1)
+			for name, values := range chunk.Prediction.Record(i) {
+				line[name] = values
+			}
+			if includeInput {
+				line["input"] = record
+			}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		}
+		return out.Flush()
+	})
+}
+
+// jsonRecordReader reads JSON records, either one object per line (JSONL) or
+// JSON arrays of objects, one at a time without reading whole arrays into
+// memory.
+type jsonRecordReader struct {
+	decoder *json.Decoder
+	inArray bool
+}
+
+func newJSONRecordReader(r io.Reader) *jsonRecordReader {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return &jsonRecordReader{decoder: decoder}
+}
+
+// Read implements jams.RecordReader.
+func (r *jsonRecordReader) Read() (map[string]any, error) {
+	for {
+		if r.inArray {
+			if r.decoder.More() {
+				var record any
+				if err := r.decoder.Decode(&record); err != nil {
+					return nil, err
+				}
+				object, ok := record.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("expected a JSON object per record, got %T", record)
+				}
+				return object, nil
+			}
+			// the closing bracket
+			if _, err := r.decoder.Token(); err != nil {
+				return nil, err
+			}
+			r.inArray = false
+		}
+
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch token {
+		case json.Delim('['):
+			r.inArray = true
+		case json.Delim('{'):
+			return r.readObject()
+		default:
+			return nil, fmt.Errorf("expected a JSON object per record, got %v", token)
+		}
+	}
+}
+
+// readObject reads the members of an object whose opening brace was read.
+func (r *jsonRecordReader) readObject() (map[string]any, error) {
+	object := make(map[string]any)
+	for r.decoder.More() {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %v in object", token)
+		}
+		var value any
+		if err := r.decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		object[key] = value
+	}
+	// the closing brace
+	if _, err := r.decoder.Token(); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// cliDeadLetters marks the dead letters of the command and reports them on
+// stderr.
+type cliDeadLetters struct {
+	jams.DeadLetterQueue
+}
+
+func (q cliDeadLetters) Put(ctx context.Context, l jams.DeadLetter) error {
+	l.Source = jams.DeadLetterSourceCLI
+	if err := q.DeadLetterQueue.Put(ctx, l); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "records %d to %d failed: %v\n", l.Records.Start, l.Records.End-1, l.Err)
+	return nil
+}
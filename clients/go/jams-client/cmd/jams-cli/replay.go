@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/diffpred"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/sink"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// errReplayDiffers is returned by replay when replayed calls fail or differ
+// from the recorded ones so the command exits with status 1.
+var errReplayDiffers = errors.New("replayed predictions differ from the recorded ones")
+
+// runReplay sends the calls of an audit log, written by sink.AuditLog, to a
+// server and compares the predictions with the recorded ones, e.g. to
+// validate a new server or model version with production traffic before
+// releasing it.
+func runReplay(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	output := registerOutput(fs)
+	protocol := fs.String("protocol", envOr("JAMS_PROTOCOL", "http"), "protocol of the target server, http or grpc (env JAMS_PROTOCOL)")
+	target := fs.String("target", "", "address of the server the calls are replayed against")
+	from := fs.String("from", "", "audit log of the calls, one JSON object per line, - for stdin")
+	model := fs.String("model", "", "model every call is replayed with, e.g. a new version, instead of the recorded one")
+	speed := fs.Float64("speed", 0, "replay at the recorded pace sped up by this factor, e.g. 2 for twice as fast, 0 for as fast as possible")
+	concurrency := fs.Int("concurrency", 8, "calls in flight at most")
+	var tolerance types.Tolerance
+	fs.Float64Var(&tolerance.Abs, "tolerance", 1e-6, "absolute difference under which two values agree")
+	fs.Float64Var(&tolerance.Rel, "relative-tolerance", 0, "difference relative to the recorded value under which two values agree")
+	fs.Uint64Var(&tolerance.ULPs, "ulps", 0, "units in the last place within which two values agree, e.g. across architectures")
+	fs.BoolVar(&tolerance.Float32, "float32", false, "count --ulps in single precision, for models computing in float32")
+	reportPath := fs.String("report", "", "file the detailed report of every model is written to, with the most differing records and the differences by input feature")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(*output); err != nil {
+		return err
+	}
+	if *target == "" {
+		return errors.New("a target server is required, use --target")
+	}
+	if *from == "" {
+		return errors.New("an audit log is required, use --from")
+	}
+	if *speed < 0 {
+		return errors.New("--speed must not be negative")
+	}
+	if *concurrency <= 0 {
+		return errors.New("--concurrency must be greater than zero")
+	}
+
+	var r io.Reader = os.Stdin
+	if *from != "-" {
+		f, err := os.Open(*from)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	client, err := connect(*protocol, *target)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	report := newReplayReport(tolerance)
+	records := sink.NewAuditReader(bufio.NewReader(r))
+	var (
+		wg       sync.WaitGroup
+		inFlight = make(chan struct{}, *concurrency)
+		start    time.Time
+		first    time.Time
+	)
+	for n := 0; ; n++ {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			wg.Wait()
+			return fmt.Errorf("failed to read the audit log: %w", err)
+		}
+		if *model != "" {
+			record.Model = *model
+		}
+		if n == 0 {
+			start, first = time.Now(), record.Time
+		}
+		if *speed > 0 {
+			offset := time.Duration(float64(record.Time.Sub(first)) / *speed)
+			if err := sleepUntil(ctx, start.Add(offset)); err != nil {
+				break
+			}
+		}
+		select {
+		case inFlight <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+			report.add(record, replayCall(ctx, client, record))
+		}()
+	}
+	wg.Wait()
+
+	if report.lastError != "" {
+		fmt.Fprintf(os.Stderr, "warning: last error: %s\n", report.lastError)
+	}
+	if *reportPath != "" {
+		if err := report.write(*reportPath); err != nil {
+			return err
+		}
+	}
+	if err := render(os.Stdout, *output, report.table(client.Stats())); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !report.passed() {
+		return errReplayDiffers
+	}
+	return nil
+}
+
+// sleepUntil waits until t or until ctx is done.
+func sleepUntil(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// replayed is the outcome of a replayed call.
+type replayed struct {
+	input      *types.Input
+	prediction *types.Prediction
+	err        error
+}
+
+func replayCall(ctx context.Context, client *jams.Client, record sink.AuditRecord) replayed {
+	input, err := types.ParseInput(record.Input)
+	if err != nil {
+		return replayed{err: fmt.Errorf("request %s: %w", record.RequestID, err)}
+	}
+	prediction, err := client.Predict(ctx, record.Model, input)
+	if err != nil {
+		return replayed{err: fmt.Errorf("request %s: %w", record.RequestID, err)}
+	}
+	return replayed{input: input, prediction: prediction}
+}
+
+// replayReport accumulates the outcomes of the replayed calls by model.
+type replayReport struct {
+	tolerance types.Tolerance
+
+	mu        sync.Mutex
+	models    map[string]*modelReplay
+	lastError string
+}
+
+// modelReplay is the outcome of the replayed calls of a model, as rendered by
+// the json format.
+type modelReplay struct {
+	Model string `json:"model"`
+	Calls int    `json:"calls"`
+	// Failed counts the calls which failed when replayed.
+	Failed int `json:"failed"`
+	// Compared counts the calls which succeeded both when recorded and
+	// replayed, of which Matched agree on every value within the tolerance.
+	Compared   int     `json:"compared"`
+	Matched    int     `json:"matched"`
+	MaxAbsDiff float64 `json:"max_abs_diff"`
+	// RecordedP50 and ReplayedP50 are the median latencies of the calls.
+	RecordedP50 time.Duration `json:"recorded_p50"`
+	ReplayedP50 time.Duration `json:"replayed_p50"`
+
+	// newFailures counts the calls which only failed when replayed.
+	newFailures int
+	recorded    []float64
+	diff        *diffpred.Diff
+}
+
+func newReplayReport(tolerance types.Tolerance) *replayReport {
+	return &replayReport{tolerance: tolerance, models: make(map[string]*modelReplay)}
+}
+
+func (r *replayReport) add(record sink.AuditRecord, result replayed) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.models[record.Model]
+	if !ok {
+		m = &modelReplay{Model: record.Model, diff: diffpred.New(diffpred.Options{Tolerance: r.tolerance})}
+		r.models[record.Model] = m
+	}
+	m.Calls++
+	m.recorded = append(m.recorded, record.LatencyMS)
+	if result.err != nil {
+		m.Failed++
+		if record.Error == "" {
+			m.newFailures++
+		}
+		r.lastError = result.err.Error()
+		return
+	}
+	if record.Output == nil {
+		return
+	}
+	m.Compared++
+	diff := m.diff.Add(result.input, &types.Prediction{Outputs: record.Output}, result.prediction)
+	if diff.Matched() {
+		m.Matched++
+	}
+	m.MaxAbsDiff = max(m.MaxAbsDiff, diff.MaxAbsDiff)
+}
+
+// passed reports whether no call failed which had succeeded when recorded and
+// every compared call matched.
+func (r *replayReport) passed() bool {
+	for _, m := range r.models {
+		if m.newFailures > 0 || m.Matched < m.Compared {
+			return false
+		}
+	}
+	return true
+}
+
+// write writes the text form of the report of every model to path.
+func (r *replayReport) write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	models := make([]string, 0, len(r.models))
+	for model := range r.models {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for i, model := range models {
+		if i > 0 {
+			fmt.Fprintln(f)
+		}
+		fmt.Fprintf(f, "== %s ==\n", model)
+		if err := r.models[model].diff.Report().WriteText(f); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+func (r *replayReport) table(stats jams.Stats) table {
+	replayedP50 := make(map[string]time.Duration)
+	for _, call := range stats.Calls {
+		if call.Method == jams.MethodPredict {
+			replayedP50[call.Model] = call.Latency.P50
+		}
+	}
+	models := make([]*modelReplay, 0, len(r.models))
+	for _, m := range r.models {
+		m.RecordedP50 = medianMillis(m.recorded)
+		m.ReplayedP50 = replayedP50[m.Model].Round(time.Microsecond)
+		models = append(models, m)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Model < models[j].Model
+	})
+
+	t := table{
+		columns: []column{
+			{name: "MODEL"}, {name: "CALLS"}, {name: "FAILED"}, {name: "COMPARED"}, {name: "MATCHED"},
+			{name: "MAX ABS DIFF"}, {name: "RECORDED P50", wide: true}, {name: "REPLAYED P50", wide: true},
+		},
+		raw: models,
+	}
+	for _, m := range models {
+		t.rows = append(t.rows, []string{
+			m.Model, strconv.Itoa(m.Calls), strconv.Itoa(m.Failed), strconv.Itoa(m.Compared), strconv.Itoa(m.Matched),
+			formatStat(m.MaxAbsDiff), m.RecordedP50.String(), m.ReplayedP50.String(),
+		})
+	}
+	return t
+}
+
+// medianMillis returns the median of latencies in milliseconds.
+func medianMillis(latencies []float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), latencies...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+	return time.Duration(median * float64(time.Millisecond)).Round(time.Microsecond)
+}
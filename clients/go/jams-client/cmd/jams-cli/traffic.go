@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/traffic"
+)
+
+// trafficResult is the report of a run as rendered by the json format.
+type trafficResult struct {
+	traffic.Report
+	Latency jams.LatencyStats `json:"latency"`
+}
+
+// runTraffic sends synthetic predictions drawn from the distributions of a
+// sample of records, e.g. to soak test a server.
+func runTraffic(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("traffic", flag.ContinueOnError)
+	var server serverFlags
+	server.register(fs)
+	output := registerOutput(fs)
+	model := fs.String("model", "", "name of the model")
+	sample := fs.String("sample", "", "file of JSON records the distributions of the features are learnt from")
+	profilePath := fs.String("profile", "", "file of a profile saved with --save-profile, instead of --sample")
+	saveProfile := fs.String("save-profile", "", "file the profile learnt from --sample is saved to")
+	qps := fs.Float64("qps", 10, "requests sent per second")
+	duration := fs.Duration("duration", 0, "how long requests are sent for, until interrupted when zero")
+	rows := fs.Int("rows", 1, "records per request")
+	maxInFlight := fs.Int("max-in-flight", 64, "requests in flight above which requests are dropped")
+	seed := fs.Int64("seed", 0, "seed of the records drawn, 0 for a random seed")
+	interval := fs.Duration("interval", 10*time.Second, "period of the progress lines written to stderr, 0 to disable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(*output); err != nil {
+		return err
+	}
+	if *model == "" {
+		return errors.New("a model is required, use --model")
+	}
+	if (*sample == "") == (*profilePath == "") {
+		return errors.New("either --sample or --profile is required")
+	}
+
+	profile, err := loadProfile(*sample, *profilePath)
+	if err != nil {
+		return err
+	}
+	if *saveProfile != "" {
+		data, err := json.MarshalIndent(profile, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*saveProfile, append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	client, err := server.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	generator, err := traffic.New(client, traffic.Config{
+		Model:       *model,
+		Profile:     profile,
+		QPS:         *qps,
+		Rows:        *rows,
+		Duration:    *duration,
+		MaxInFlight: *maxInFlight,
+		Seed:        *seed,
+		Interval:    *interval,
+		OnReport: func(r traffic.Report) {
+			fmt.Fprintf(os.Stderr, "%s: sent %d, failed %d, dropped %d, %.1f qps\n",
+				r.Elapsed.Round(time.Second), r.Sent, r.Failed, r.Dropped, r.QPS)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	report, err := generator.Run(ctx)
+	if err != nil {
+		return err
+	}
+	if report.LastError != "" {
+		fmt.Fprintf(os.Stderr, "warning: last error: %s\n", report.LastError)
+	}
+
+	result := trafficResult{Report: report}
+	for _, call := range client.Stats().Calls {
+		if call.Method == jams.MethodPredict && call.Model == *model {
+			result.Latency = call.Latency
+		}
+	}
+	t := table{
+		columns: []column{
+			{name: "SENT"}, {name: "FAILED"}, {name: "DROPPED"}, {name: "QPS"}, {name: "ELAPSED", wide: true},
+			{name: "P50"}, {name: "P90"}, {name: "P99"}, {name: "MAX", wide: true},
+		},
+		rows: [][]string{{
+			strconv.Itoa(report.Sent), strconv.Itoa(report.Failed), strconv.Itoa(report.Dropped),
+			strconv.FormatFloat(report.QPS, 'f', 1, 64), report.Elapsed.Round(time.Millisecond).String(),
+			result.Latency.P50.String(), result.Latency.P90.String(), result.Latency.P99.String(), result.Latency.Max.String(),
+		}},
+		raw: result,
+	}
+	return render(os.Stdout, *output, t)
+}
+
+// loadProfile learns a profile from the records of sample or reads the
+// profile saved at path.
+func loadProfile(sample, path string) (*traffic.Profile, error) {
+	if sample != "" {
+		f, err := os.Open(sample)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		profile, err := traffic.Learn(newJSONRecordReader(bufio.NewReader(f)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to learn from %s: %w", sample, err)
+		}
+		return profile, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var profile traffic.Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+	return &profile, nil
+}
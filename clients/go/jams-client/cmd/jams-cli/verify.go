@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/contract"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/testvectors"
+)
+
+// errTestsFailed is returned by verify when a test fails so the command exits
+// with status 1.
+var errTestsFailed = errors.New("model tests failed")
+
+// verifyResult is a test result as rendered by the json format.
+type verifyResult struct {
+	contract.Result
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runVerify runs the model unit tests of a suite against the server, see the
+// contract package for the format of the suite, or the canonical vectors of
+// the sample models with --vectors.
+func runVerify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	var server serverFlags
+	server.register(fs)
+	output := registerOutput(fs)
+	file := fs.String("f", "", "path to the test suite")
+	models := fs.String("model", "", "comma separated models whose tests are run, defaults to all")
+	vectors := fs.String("vectors", "", "run the canonical vectors of a framework, or all, instead of a suite")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(*output); err != nil {
+		return err
+	}
+	if (*file == "") == (*vectors == "") {
+		return errors.New("a test suite is required, use either -f or --vectors")
+	}
+
+	suite, err := loadSuite(*file, *vectors)
+	if err != nil {
+		return err
+	}
+	if *models != "" {
+		suite = suite.Filter(strings.Split(*models, ",")...)
+	}
+	if len(suite.Tests) == 0 {
+		return errors.New("no test to run")
+	}
+
+	client, err := server.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	results := suite.Run(ctx, client)
+	t := table{columns: []column{{name: "TEST"}, {name: "MODEL"}, {name: "RESULT"}, {name: "LATENCY", wide: true}, {name: "DETAILS"}}}
+	raw := make([]verifyResult, len(results))
+	failed := 0
+	for i, r := range results {
+		raw[i] = verifyResult{Result: r, Passed: r.Passed()}
+		status, details := "PASS", ""
+		switch {
+		case r.Err != nil:
+			status, details = "ERROR", r.Err.Error()
+			raw[i].Error = details
+		case len(r.Mismatches) > 0:
+			status = "FAIL"
+			msgs := make([]string, len(r.Mismatches))
+			for j, m := range r.Mismatches {
+				msgs[j] = m.String()
+			}
+			details = strings.Join(msgs, "; ")
+		}
+		if !r.Passed() {
+			failed++
+		}
+		t.rows = append(t.rows, []string{r.Test, r.Model, status, r.Latency.String(), details})
+	}
+	t.raw = raw
+	if err := render(os.Stdout, *output, t); err != nil {
+		return err
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d tests failed\n", failed, len(results))
+		return errTestsFailed
+	}
+	return nil
+}
+
+// loadSuite reads the suite at file, or the canonical vectors of the given
+// framework.
+func loadSuite(file, vectors string) (*contract.Suite, error) {
+	switch {
+	case file != "":
+		return contract.LoadFile(file)
+	case vectors == "all":
+		return testvectors.All()
+	default:
+		return testvectors.Suite(vectors)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// runModelsWatch prints model lifecycle events as they happen.
+func runModelsWatch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("models watch", flag.ContinueOnError)
+	var server serverFlags
+	server.register(fs)
+	output := registerOutput(fs)
+	interval := fs.Duration("interval", 5*time.Second, "polling interval")
+	since := fs.Duration("since", 0, "also print models loaded or updated within this duration before the watch started")
+	model := fs.String("model", "", "only print events for models matching this glob pattern")
+	framework := fs.String("framework", "", "only print events for models of this framework")
+	eventTypes := fs.String("type", "", "comma separated event types to print: added, updated, removed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(*output); err != nil {
+		return err
+	}
+	if _, err := path.Match(*model, ""); err != nil {
+		return fmt.Errorf("invalid model pattern: %w", err)
+	}
+	wanted := make(map[jams.ModelEventType]bool)
+	for _, t := range strings.Split(*eventTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			wanted[jams.ModelEventType(t)] = true
+		}
+	}
+
+	client, err := server.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	started := time.Now()
+	watcher := client.WatchModels(ctx, *interval)
+	errs := watcher.Errors
+	w := csv.NewWriter(os.Stdout)
+	for {
+		select {
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			fmt.Fprintln(os.Stderr, "error:", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// models loaded before the watch are only shown with --since.
+			if event.Initial {
+				updated, err := event.Model.UpdatedAt()
+				if err != nil || started.Sub(updated) > *since {
+					continue
+				}
+			}
+			if *model != "" {
+				if ok, _ := path.Match(*model, event.Model.Name); !ok {
+					continue
+				}
+			}
+			if *framework != "" && event.Model.Framework != *framework {
+				continue
+			}
+			if len(wanted) > 0 && !wanted[event.Type] {
+				continue
+			}
+			if err := printEvent(w, *output, event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func printEvent(w *csv.Writer, format string, event jams.ModelEvent) error {
+	timestamp := event.Time.Format(time.RFC3339)
+	switch format {
+	case formatJSON:
+		return json.NewEncoder(os.Stdout).Encode(event)
+	case formatCSV:
+		if err := w.Write([]string{timestamp, string(event.Type), event.Model.Name, event.Model.Framework, event.Model.Path}); err != nil {
+			return err
+		}
+		w.Flush()
+		return w.Error()
+	case formatWide:
+		_, err := fmt.Printf("%s  %-8s %-40s %-12s %s\n", timestamp, event.Type, event.Model.Name, event.Model.Framework, event.Model.Path)
+		return err
+	default:
+		_, err := fmt.Printf("%s  %-8s %-40s %s\n", timestamp, event.Type, event.Model.Name, event.Model.Framework)
+		return err
+	}
+}
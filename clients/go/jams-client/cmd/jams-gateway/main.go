@@ -0,0 +1,81 @@
+// Command jams-gateway serves the J.A.M.S REST API and forwards it to a gRPC
+// server, as a sidecar for HTTP-only consumers of gRPC-only deployments.
+//
+//	jams-gateway --listen :3000 --target jams:4000
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/gateway"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	fs := flag.NewFlagSet("jams-gateway", flag.ExitOnError)
+	listen := fs.String("listen", envOr("JAMS_GATEWAY_LISTEN", ":3000"), "address to serve the REST API on (env JAMS_GATEWAY_LISTEN)")
+	target := fs.String("target", envOr("JAMS_ADDR", "localhost:4000"), "gRPC server address (env JAMS_ADDR)")
+	channels := fs.Int("channels", 1, "number of gRPC connections to the server")
+	retries := fs.Int("retries", 1, "attempts per call on unavailable servers")
+	_ = fs.Parse(os.Args[1:])
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	opts := []jams.Option{
+		jams.WithGRPCChannels(*channels),
+		jams.WithRetry(*retries),
+		jams.WithLogger(logger),
+	}
+	if token := os.Getenv("JAMS_TOKEN"); token != "" {
+		opts = append(opts, jams.WithBearerToken(token))
+	}
+	client, err := jams.NewGRPCClient(*target, opts...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           gateway.New(client, gateway.WithLogger(logger)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	logger.Info("jams gateway listening", "listen", *listen, "target", *target)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,205 @@
+package jams_client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec is a content coding of the bodies of the HTTP transport, see
+// WithCompression and WithCodec.
+type Codec interface {
+	// Name is the token of the coding in the Content-Encoding and
+	// Accept-Encoding headers, e.g. "zstd".
+	Name() string
+	// Compress returns data compressed.
+	Compress(data []byte) ([]byte, error)
+	// NewReader returns a reader decompressing r.
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+// GzipCodec compresses with gzip at Level, gzip.DefaultCompression if zero.
+type GzipCodec struct {
+	Level int
+}
+
+// Name implements Codec.
+func (GzipCodec) Name() string { return "gzip" }
+
+// Compress implements Codec.
+func (c GzipCodec) Compress(data []byte) ([]byte, error) {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// NewReader implements Codec.
+func (GzipCodec) NewReader(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// ZstdCodec compresses with zstd at Level, on zstd's scale of 1 to 22, 3 if
+// zero. It is usually much faster than gzip at a similar ratio.
+type ZstdCodec struct {
+	Level int
+}
+
+// Name implements Codec.
+func (ZstdCodec) Name() string { return "zstd" }
+
+var (
+	zstdMu       sync.Mutex
+	zstdEncoders = make(map[zstd.EncoderLevel]*zstd.Encoder)
+	zstdDecoder  *zstd.Decoder
+)
+
+// Compress implements Codec.
+func (c ZstdCodec) Compress(data []byte) ([]byte, error) {
+	level := c.Level
+	if level == 0 {
+		level = 3
+	}
+	encoderLevel := zstd.EncoderLevelFromZstd(level)
+	// encoders are safe for concurrent EncodeAll calls, and costly to make.
+	zstdMu.Lock()
+	encoder, ok := zstdEncoders[encoderLevel]
+	if !ok {
+		var err error
+		if encoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(encoderLevel)); err != nil {
+			zstdMu.Unlock()
+			return nil, err
+		}
+		zstdEncoders[encoderLevel] = encoder
+	}
+	zstdMu.Unlock()
+	return encoder.EncodeAll(data, nil), nil
+}
+
+// NewReader implements Codec.
+func (ZstdCodec) NewReader(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	zstdMu.Lock()
+	if zstdDecoder == nil {
+		if zstdDecoder, err = zstd.NewReader(nil); err != nil {
+			zstdMu.Unlock()
+			return nil, err
+		}
+	}
+	decoder := zstdDecoder
+	zstdMu.Unlock()
+	data, err = decoder.DecodeAll(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// BrotliCodec compresses with brotli at Quality, from 0 to 11, 6 if zero.
+type BrotliCodec struct {
+	Quality int
+}
+
+// Name implements Codec.
+func (BrotliCodec) Name() string { return "br" }
+
+// Compress implements Codec.
+func (c BrotliCodec) Compress(data []byte) ([]byte, error) {
+	quality := c.Quality
+	if quality == 0 {
+		quality = brotli.DefaultCompression
+	}
+	var buf bytes.Buffer
+	w := brotli.NewWriterLevel(&buf, quality)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// NewReader implements Codec.
+func (BrotliCodec) NewReader(r io.Reader) (io.Reader, error) {
+	return brotli.NewReader(r), nil
+}
+
+// compressionRule is the compression of the requests of a model.
+type compressionRule struct {
+	// codec is nil when requests are not compressed.
+	codec   Codec
+	minSize int
+}
+
+// WithCompression compresses the request bodies of Predict calls of at least
+// minSize bytes with codec over HTTP, and accepts responses compressed with
+// it. Use WithModelCompression to override the compression of single
+// models.
+//
+// The J.A.M.S server does not decompress requests itself, so compression is
+// meant for servers behind a proxy which does. If the server answers a
+// compressed request with 415 Unsupported Media Type, the request is sent
+// again uncompressed and the client stops compressing. It has no effect on
+// the gRPC transport, whose compressors are set with grpc.UseCompressor.
+func WithCompression(codec Codec, minSize int) Option {
+	return func(o *options) {
+		o.compression = compressionRule{codec: codec, minSize: minSize}
+		o.codecs = appendCodec(o.codecs, codec)
+	}
+}
+
+// WithModelCompression sets the compression of the requests of the named
+// model, see WithCompression. A nil codec turns compression off for the
+// model.
+func WithModelCompression(modelName string, codec Codec, minSize int) Option {
+	return func(o *options) {
+		if o.modelCompression == nil {
+			o.modelCompression = make(map[string]compressionRule)
+		}
+		o.modelCompression[modelName] = compressionRule{codec: codec, minSize: minSize}
+		o.codecs = appendCodec(o.codecs, codec)
+	}
+}
+
+// WithCodec accepts responses compressed with codec over HTTP, in addition
+// to gzip, e.g. from a proxy compressing with zstd, and lets a custom coding
+// replace a built-in one of the same name.
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codecs = appendCodec(o.codecs, codec)
+	}
+}
+
+// appendCodec adds codec to codecs, replacing the codec of the same name.
+func appendCodec(codecs []Codec, codec Codec) []Codec {
+	if codec == nil {
+		return codecs
+	}
+	for i, c := range codecs {
+		if c.Name() == codec.Name() {
+			codecs[i] = codec
+			return codecs
+		}
+	}
+	return append(codecs, codec)
+}
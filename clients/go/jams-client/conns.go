@@ -0,0 +1,224 @@
+package jams_client
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// WithDNSRefresh makes the HTTP transport re-resolve the host of its base URL
+// every interval, for servers behind DNS based failover or whose addresses
+// change in rolling deployments. Connections to addresses the host no longer
+// resolves to are recycled: closed once their requests are done, so that the
+// next requests dial the new addresses. Failed lookups leave the connections
+// in place and are logged to the logger set with WithLogger.
+//
+// It has no effect on base URLs with an IP address, on the gRPC transport,
+// which re-resolves targets itself, or with an *http.Client whose Transport
+// is not an *http.Transport.
+func WithDNSRefresh(interval time.Duration) Option {
+	return func(o *options) {
+		o.dnsRefresh = interval
+	}
+}
+
+// WithMaxConnectionAge recycles connections once they are older than age,
+// give or take 10% so that they are not all re-dialled at once: HTTP
+// connections are closed once their request in progress is done, and gRPC
+// channels are replaced by new ones and closed once their calls in flight
+// are done. New connections spread over the servers behind a load balancer,
+// evening out the traffic after servers are added.
+//
+// Over HTTP it has no effect with an *http.Client whose Transport is not an
+// *http.Transport.
+func WithMaxConnectionAge(age time.Duration) Option {
+	return func(o *options) {
+		o.maxConnAge = age
+	}
+}
+
+// WithMaxConnectionIdle closes connections left idle for longer than idle:
+// it sets the IdleConnTimeout of the HTTP transport and the idle timeout of
+// gRPC channels, which reconnect on the next call.
+//
+// Over HTTP it has no effect with an *http.Client whose Transport is not an
+// *http.Transport.
+func WithMaxConnectionIdle(idle time.Duration) Option {
+	return func(o *options) {
+		o.maxConnIdle = idle
+	}
+}
+
+// jitterAge returns age give or take 10%.
+func jitterAge(age time.Duration) time.Duration {
+	return age - age/10 + time.Duration(rand.Int63n(int64(age/5)+1))
+}
+
+// connTracker tracks the connections dialled by an HTTP transport, so that
+// they can be recycled gracefully.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[*trackedConn]struct{}
+}
+
+// trackedConn is a connection dialled through a connTracker.
+type trackedConn struct {
+	net.Conn
+	tracker *connTracker
+	// addr is the address dialled, host and port.
+	addr string
+	// expires is when the connection reaches its maximum age, if any.
+	expires time.Time
+
+	mu sync.Mutex
+	// inFlight counts the requests using the connection.
+	inFlight int
+	// retired connections are closed once their requests are done.
+	retired bool
+}
+
+// withConnTracking makes transport dial through a connTracker, returned,
+// whose connections live up to maxAge if set.
+func withConnTracking(transport *http.Transport, maxAge time.Duration) *connTracker {
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	tracker := &connTracker{conns: make(map[*trackedConn]struct{})}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tc := &trackedConn{Conn: conn, tracker: tracker, addr: addr}
+		if maxAge > 0 {
+			tc.expires = time.Now().Add(jitterAge(maxAge))
+		}
+		tracker.mu.Lock()
+		tracker.conns[tc] = struct{}{}
+		tracker.mu.Unlock()
+		return tc, nil
+	}
+	return tracker
+}
+
+// trace returns a context whose request reports the tracked connection it
+// gets through acquired.
+func (t *connTracker) trace(ctx context.Context, acquired func(*trackedConn)) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			conn := info.Conn
+			if tlsConn, ok := conn.(*tls.Conn); ok {
+				conn = tlsConn.NetConn()
+			}
+			if tc, ok := conn.(*trackedConn); ok {
+				tc.mu.Lock()
+				tc.inFlight++
+				tc.mu.Unlock()
+				acquired(tc)
+			}
+		},
+	})
+}
+
+// retire closes the connections matching retire once their requests are
+// done.
+func (t *connTracker) retire(retire func(*trackedConn) bool) {
+	t.mu.Lock()
+	var conns []*trackedConn
+	for tc := range t.conns {
+		if retire(tc) {
+			conns = append(conns, tc)
+		}
+	}
+	t.mu.Unlock()
+	for _, tc := range conns {
+		tc.mu.Lock()
+		tc.retired = true
+		idle := tc.inFlight == 0
+		tc.mu.Unlock()
+		if idle {
+			tc.Close()
+		}
+	}
+}
+
+// release ends a request on the connection, closing it if it was retired,
+// or is past its maximum age, and this was its last request. The response
+// body must be closed first, so that the connection is back in the pool of
+// the transport.
+func (tc *trackedConn) release() {
+	tc.mu.Lock()
+	tc.inFlight--
+	if !tc.expires.IsZero() && time.Now().After(tc.expires) {
+		tc.retired = true
+	}
+	closing := tc.retired && tc.inFlight == 0
+	tc.mu.Unlock()
+	if closing {
+		tc.Close()
+	}
+}
+
+func (tc *trackedConn) Close() error {
+	tc.tracker.mu.Lock()
+	delete(tc.tracker.conns, tc)
+	tc.tracker.mu.Unlock()
+	return tc.Conn.Close()
+}
+
+// refreshDNS re-resolves host every interval and retires the connections to
+// the addresses it no longer resolves to, until stop is closed.
+func (t *connTracker) refreshDNS(host string, interval time.Duration, logger *slog.Logger, degraded *degradedReporter, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			degraded.enter(DegradedDNSRefresh, "", err)
+			if logger != nil {
+				logger.Warn("failed to re-resolve jams server", "host", host, "error", err)
+			}
+			continue
+		}
+		degraded.leave(DegradedDNSRefresh, "")
+		resolved := make(map[string]bool, len(addrs))
+		for _, addr := range addrs {
+			resolved[addr] = true
+		}
+		t.retire(func(tc *trackedConn) bool {
+			// connections through a proxy are dialled to another host.
+			dialled, _, err := net.SplitHostPort(tc.addr)
+			if err != nil || dialled != host {
+				return false
+			}
+			remote, _, err := net.SplitHostPort(tc.RemoteAddr().String())
+			return err == nil && !resolved[remote]
+		})
+	}
+}
+
+// refreshedHost returns the host of baseURL to re-resolve, or false if it is
+// an IP address.
+func refreshedHost(baseURL string) (string, bool) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", false
+	}
+	host := u.Hostname()
+	return host, host != "" && net.ParseIP(host) == nil
+}
@@ -0,0 +1,326 @@
+// Package contract runs model unit tests: predictions expected, within a
+// tolerance, for known inputs, declared in YAML and run against a server to
+// catch silently broken model updates before they are promoted.
+//
+//	tolerance: 1e-6
+//	tests:
+//	  - name: young male in third class
+//	    model: titanic_model
+//	    input:
+//	      pclass: ["3"]
+//	      sex: [male]
+//	      age: [22.0]
+//	    expected:
+//	      predictions: [[0.12]]
+//	    tolerance: 0.01
+//
+// Values within the absolute or relative tolerance match, as do values within
+// ulps units in the last place, e.g. to run the same suite against servers
+// on different architectures; see types.Tolerance.
+//
+// Inputs are in the wire format of the server, one list of values per
+// feature; the YAML type of the values gives the type of the column, e.g.
+// 22.0 is a float and 22 an integer. Expected outputs map output names to
+// rows of values; outputs of the model not listed are not checked.
+//
+//	suite, err := contract.LoadFile("model_tests.yaml")
+//	for _, result := range suite.Run(ctx, client) {
+//		fmt.Println(result.Test, result.Passed())
+//	}
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Predictor makes predictions. It is implemented by *jams.Client.
+type Predictor interface {
+	Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error)
+}
+
+// Suite is a set of tests.
+type Suite struct {
+	// Tolerance is the absolute difference allowed between expected and
+	// actual values by the tests without one of their own.
+	Tolerance float64 `yaml:"tolerance"`
+	// RelativeTolerance is the difference allowed relative to the expected
+	// value, in addition to Tolerance, by the tests without one of their
+	// own.
+	RelativeTolerance float64 `yaml:"relative_tolerance"`
+	// ULPs is the number of units in the last place allowed between
+	// expected and actual values, in addition to the tolerances, by the
+	// tests without one of their own. Float32 counts them in single
+	// precision.
+	ULPs    uint64 `yaml:"ulps"`
+	Float32 bool   `yaml:"float32"`
+	Tests   []Test `yaml:"tests"`
+}
+
+// Test is a model unit test.
+type Test struct {
+	Name  string `yaml:"name"`
+	Model string `yaml:"model"`
+	Input Input  `yaml:"input"`
+	// Expected maps output names to their expected rows.
+	Expected map[string][][]float64 `yaml:"expected"`
+	// Tolerance, RelativeTolerance, ULPs and Float32 override those of the
+	// suite.
+	Tolerance         *float64 `yaml:"tolerance"`
+	RelativeTolerance *float64 `yaml:"relative_tolerance"`
+	ULPs              *uint64  `yaml:"ulps"`
+	Float32           *bool    `yaml:"float32"`
+}
+
+// Input is the input of a test.
+type Input struct {
+	*types.Input
+}
+
+// UnmarshalYAML decodes a mapping of feature names to lists of values, keeping
+// the order of the features and the YAML type of the values.
+func (in *Input) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: input must map features to lists of values", node.Line)
+	}
+	in.Input = types.NewInput()
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, values := node.Content[i].Value, node.Content[i+1]
+		if values.Kind != yaml.SequenceNode {
+			return fmt.Errorf("line %d: feature %q must be a list of values", values.Line, name)
+		}
+		if err := in.addColumn(name, values.Content); err != nil {
+			return fmt.Errorf("line %d: feature %q: %w", values.Line, name, err)
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column of integers, floats or strings. Columns mixing
+// integers and floats are floats.
+func (in *Input) addColumn(name string, nodes []*yaml.Node) error {
+	var ints, floats, strs int
+	for _, n := range nodes {
+		switch n.ShortTag() {
+		case "!!int":
+			ints++
+		case "!!float":
+			floats++
+		case "!!str":
+			strs++
+		default:
+			return fmt.Errorf("unsupported value %q", n.Value)
+		}
+	}
+	switch {
+	case strs > 0 && ints+floats > 0:
+		return errors.New("values mix strings and numbers")
+	case strs > 0:
+		values := make([]string, len(nodes))
+		for i, n := range nodes {
+			values[i] = n.Value
+		}
+		in.AddStrings(name, values...)
+	case floats > 0:
+		values := make([]float64, len(nodes))
+		for i, n := range nodes {
+			if err := n.Decode(&values[i]); err != nil {
+				return err
+			}
+		}
+		in.AddFloats(name, values...)
+	default:
+		values := make([]int, len(nodes))
+		for i, n := range nodes {
+			if err := n.Decode(&values[i]); err != nil {
+				return err
+			}
+		}
+		in.AddInts(name, values...)
+	}
+	return nil
+}
+
+// Load reads a suite and checks its tests.
+func Load(r io.Reader) (*Suite, error) {
+	var suite Suite
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&suite); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid test suite: %w", err)
+	}
+	for i, t := range suite.Tests {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("test %d (%s): %w", i, t.Name, err)
+		}
+	}
+	return &suite, nil
+}
+
+// LoadFile reads the suite at path.
+func LoadFile(path string) (*Suite, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+func (t Test) validate() error {
+	if t.Model == "" {
+		return errors.New("test has no model")
+	}
+	if t.Input.Input == nil {
+		return errors.New("test has no input")
+	}
+	if err := t.Input.Validate(); err != nil {
+		return err
+	}
+	if len(t.Expected) == 0 {
+		return errors.New("test has no expected output")
+	}
+	return nil
+}
+
+// Filter returns the suite restricted to the tests of the given models, or
+// the suite itself when no model is given.
+func (s *Suite) Filter(models ...string) *Suite {
+	if len(models) == 0 {
+		return s
+	}
+	filtered := *s
+	filtered.Tests = nil
+	for _, t := range s.Tests {
+		for _, m := range models {
+			if t.Model == m {
+				filtered.Tests = append(filtered.Tests, t)
+				break
+			}
+		}
+	}
+	return &filtered
+}
+
+// Result is the outcome of a test.
+type Result struct {
+	Test    string        `json:"test"`
+	Model   string        `json:"model"`
+	Latency time.Duration `json:"latency"`
+	// Err is set when the prediction failed.
+	Err error `json:"-"`
+	// Mismatches are the differences found, sorted by output, row and
+	// column.
+	Mismatches []Mismatch `json:"mismatches,omitempty"`
+}
+
+// Passed reports whether the prediction matched the expected outputs.
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Mismatches) == 0
+}
+
+// Mismatch is an expected value the prediction does not match. Column is -1
+// when a row has another number of values, and Row too when the output is
+// missing or has another number of rows.
+type Mismatch struct {
+	Output   string  `json:"output"`
+	Row      int     `json:"row"`
+	Column   int     `json:"column"`
+	Expected float64 `json:"expected"`
+	Actual   float64 `json:"actual"`
+	// Reason describes mismatches other than values out of tolerance.
+	Reason string `json:"reason,omitempty"`
+}
+
+func (m Mismatch) String() string {
+	if m.Reason != "" {
+		return fmt.Sprintf("%s: %s", m.Output, m.Reason)
+	}
+	return fmt.Sprintf("%s[%d][%d]: expected %g, got %g", m.Output, m.Row, m.Column, m.Expected, m.Actual)
+}
+
+// Run runs the tests in order.
+func (s *Suite) Run(ctx context.Context, p Predictor) []Result {
+	results := make([]Result, len(s.Tests))
+	for i, t := range s.Tests {
+		results[i] = s.run(ctx, p, t)
+	}
+	return results
+}
+
+func (s *Suite) run(ctx context.Context, p Predictor, t Test) Result {
+	result := Result{Test: t.Name, Model: t.Model}
+	start := time.Now()
+	prediction, err := p.Predict(ctx, t.Model, t.Input.Input)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Mismatches = compare(t.Expected, prediction, s.TestTolerance(t))
+	return result
+}
+
+// TestTolerance returns the tolerance of t, its own or the suite's.
+func (s *Suite) TestTolerance(t Test) types.Tolerance {
+	tolerance := types.Tolerance{Abs: s.Tolerance, Rel: s.RelativeTolerance, ULPs: s.ULPs, Float32: s.Float32}
+	if t.Tolerance != nil {
+		tolerance.Abs = *t.Tolerance
+	}
+	if t.RelativeTolerance != nil {
+		tolerance.Rel = *t.RelativeTolerance
+	}
+	if t.ULPs != nil {
+		tolerance.ULPs = *t.ULPs
+	}
+	if t.Float32 != nil {
+		tolerance.Float32 = *t.Float32
+	}
+	return tolerance
+}
+
+// compare returns the expected values prediction does not match.
+func compare(expected map[string][][]float64, prediction *types.Prediction, tolerance types.Tolerance) []Mismatch {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mismatches []Mismatch
+	for _, name := range names {
+		want := expected[name]
+		got, ok := prediction.Output(name)
+		if !ok {
+			mismatches = append(mismatches, Mismatch{Output: name, Row: -1, Column: -1, Reason: "missing output"})
+			continue
+		}
+		if len(got) != len(want) {
+			mismatches = append(mismatches, Mismatch{Output: name, Row: -1, Column: -1,
+				Reason: fmt.Sprintf("expected %d rows, got %d", len(want), len(got))})
+			continue
+		}
+		for r := range want {
+			if len(got[r]) != len(want[r]) {
+				mismatches = append(mismatches, Mismatch{Output: name, Row: r, Column: -1,
+					Reason: fmt.Sprintf("row %d: expected %d values, got %d", r, len(want[r]), len(got[r]))})
+				continue
+			}
+			for c, e := range want[r] {
+				if a := got[r][c]; !tolerance.Equal(e, a) {
+					mismatches = append(mismatches, Mismatch{Output: name, Row: r, Column: c, Expected: e, Actual: a})
+				}
+			}
+		}
+	}
+	return mismatches
+}
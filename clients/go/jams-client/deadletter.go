@@ -0,0 +1,74 @@
+package jams_client
+
+import (
+	"context"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Dead letter sources.
+const (
+	DeadLetterSourceBatch    = "batch"
+	DeadLetterSourceSchedule = "schedule"
+	DeadLetterSourceCLI      = "cli"
+)
+
+// DeadLetter is an input set aside after it failed validation or prediction,
+// after the retries configured with WithRetry, with the context of its
+// failure for offline inspection.
+type DeadLetter struct {
+	// Source names the subsystem which gave up on the input, e.g.
+	// DeadLetterSourceBatch.
+	Source string
+	Model  string
+	Time   time.Time
+	// Records is the position of the input within the input of the source,
+	// e.g. the records of a chunk of PredictBatch. It is nil when the source
+	// has no larger input.
+	Records *RecordRange
+	// Input is the input as given to the source, before preprocessing, with
+	// the redactions set with WithRedaction applied unless the source was
+	// asked for unredacted dead letters, e.g. with
+	// BatchOptions.DeadLettersUnredacted.
+	Input *types.Input
+	// RawRecords holds the records given to the source instead of Input when
+	// they could not be made into an input, e.g. the records of a chunk of
+	// PredictStream missing a feature. They are redacted like Input.
+	RawRecords []map[string]any
+	Err        error
+}
+
+// RecordRange is a range of records: Start is the index of the first record
+// and End the index of the record following the last.
+type RecordRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// DeadLetterQueue stores dead letters. Batch predictions, the schedule package
+// and the CLI put the inputs they give up on into one when configured with
+// it, so that a single queue collects the poison inputs of every subsystem.
+// See the dlq package for implementations. Put must be safe for concurrent
+// use.
+type DeadLetterQueue interface {
+	Put(ctx context.Context, l DeadLetter) error
+}
+
+// deadLetterInput returns the input of a dead letter, redacted unless
+// unredacted is set.
+func (c *Client) deadLetterInput(in *types.Input, unredacted bool) *types.Input {
+	if unredacted {
+		return in
+	}
+	return c.RedactInput(in)
+}
+
+// deadLetterRecords returns the raw records of a dead letter, redacted unless
+// unredacted is set.
+func (c *Client) deadLetterRecords(records []map[string]any, unredacted bool) []map[string]any {
+	if unredacted {
+		return records
+	}
+	return c.RedactRecords(records)
+}
@@ -0,0 +1,106 @@
+package jams_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// DebugDumpMaxBytes caps the size of each file written by WithDebugDump.
+// Larger payloads are truncated.
+const DebugDumpMaxBytes = 1 << 20
+
+type debugDumpKey struct{}
+
+// WithDebugDump returns a context whose Predict calls write the input sent
+// and the output received to files in dir, for troubleshooting inputs the
+// server rejects or misreads. Each call writes
+//
+//	<time>-<model>-<request id>.request.json
+//	<time>-<model>-<request id>.response.json
+//
+// pretty-printed, or a .error.txt file instead of the response when the call
+// fails. The request ID matches the PredictionRecord of the call. The
+// redactions set with WithRedaction apply. Failing to write a dump does not
+// fail the call; the error is logged to the logger set with WithLogger.
+func WithDebugDump(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, debugDumpKey{}, dir)
+}
+
+// debugDump is a dump in progress.
+type debugDump struct {
+	dir    string
+	prefix string
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// startDebugDump writes the request dump when ctx was returned by
+// WithDebugDump, returning nil otherwise.
+func (c *Client) startDebugDump(ctx context.Context, model, requestID string, start time.Time, input *types.Input) *debugDump {
+	dir, ok := ctx.Value(debugDumpKey{}).(string)
+	if !ok {
+		return nil
+	}
+	d := &debugDump{
+		dir:    dir,
+		prefix: fmt.Sprintf("%s-%s-%s", start.UTC().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(model, "_"), requestID),
+	}
+	payload, err := c.RedactInput(input).MarshalJSON()
+	if err == nil {
+		err = d.write(".request.json", indent(payload))
+	}
+	if err != nil {
+		c.logDebugDumpError(err)
+	}
+	return d
+}
+
+// finishDebugDump writes the response dump of d, if any.
+func (c *Client) finishDebugDump(d *debugDump, output string, callErr error) {
+	if d == nil {
+		return
+	}
+	var err error
+	if callErr != nil {
+		err = d.write(".error.txt", []byte(callErr.Error()))
+	} else {
+		err = d.write(".response.json", indent([]byte(output)))
+	}
+	if err != nil {
+		c.logDebugDumpError(err)
+	}
+}
+
+func (d *debugDump) write(suffix string, data []byte) error {
+	if len(data) > DebugDumpMaxBytes {
+		data = append(data[:DebugDumpMaxBytes:DebugDumpMaxBytes], fmt.Sprintf("\n... truncated %d bytes\n", len(data)-DebugDumpMaxBytes)...)
+	}
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(d.dir, d.prefix+suffix), data, 0o644)
+}
+
+func (c *Client) logDebugDumpError(err error) {
+	if c.opts.logger != nil {
+		c.opts.logger.Error("failed to write jams debug dump", "error", err)
+	}
+}
+
+// indent pretty-prints JSON, returning data unchanged when it is not JSON.
+func indent(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return data
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
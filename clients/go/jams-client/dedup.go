@@ -0,0 +1,108 @@
+package jams_client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// idempotencyKeyHeader carries the idempotency key of a call, as an HTTP
+// header or gRPC metadata key.
+const idempotencyKeyHeader = "idempotency-key"
+
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey returns a context whose calls carry key, sent in the
+// Idempotency-Key header or gRPC metadata key for servers which deduplicate
+// requests, and identifying the call under WithMutationDedup.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+func idempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
+
+// WithMutationDedup deduplicates AddModel, UpdateModel and DeleteModel calls
+// made within window of each other, e.g. by a double click, so that the
+// server sees a single operation. A duplicate of a call in flight waits for
+// it and returns its error; a duplicate of a call which succeeded less than
+// window ago returns nil without calling the server. Failed calls are not
+// remembered, so they can be retried right away.
+//
+// Calls are duplicates when they have the same method, model and idempotency
+// key, set with WithIdempotencyKey; calls without a key are duplicates of the
+// calls without a key. Give each intended operation its own key to tell a
+// deliberate repetition from an accidental one.
+func WithMutationDedup(window time.Duration) Option {
+	return func(o *options) {
+		o.dedupWindow = window
+	}
+}
+
+type dedupKey struct {
+	method, model, idempotencyKey string
+}
+
+// mutationDedup remembers the mutations made within its window.
+type mutationDedup struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	calls map[dedupKey]*dedupCall
+}
+
+type dedupCall struct {
+	// done is closed once the call returned err, at finished.
+	done     chan struct{}
+	err      error
+	finished time.Time
+}
+
+func newMutationDedup(window time.Duration) *mutationDedup {
+	if window <= 0 {
+		return nil
+	}
+	return &mutationDedup{window: window, calls: make(map[dedupKey]*dedupCall)}
+}
+
+// do runs call unless it duplicates a call in flight or made within the
+// window. A nil mutationDedup always runs call.
+func (d *mutationDedup) do(ctx context.Context, method, model string, call func() error) error {
+	if d == nil {
+		return call()
+	}
+	key := dedupKey{method: method, model: model, idempotencyKey: idempotencyKeyFrom(ctx)}
+	now := time.Now()
+
+	d.mu.Lock()
+	for k, c := range d.calls {
+		if !c.finished.IsZero() && now.Sub(c.finished) >= d.window {
+			delete(d.calls, k)
+		}
+	}
+	if c, ok := d.calls[key]; ok {
+		d.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c := &dedupCall{done: make(chan struct{})}
+	d.calls[key] = c
+	d.mu.Unlock()
+
+	c.err = call()
+	d.mu.Lock()
+	if c.err != nil {
+		delete(d.calls, key)
+	} else {
+		c.finished = time.Now()
+	}
+	d.mu.Unlock()
+	close(c.done)
+	return c.err
+}
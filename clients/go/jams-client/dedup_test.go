@@ -0,0 +1,143 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMutationDedupConcurrent(t *testing.T) {
+	const duplicates = 20
+	d := newMutationDedup(time.Minute)
+	keys := []struct{ method, model, key string }{
+		{"add", "m", ""},
+		{"add", "m", "a"},
+		{"add", "m", "b"},
+		{"update", "m", "a"},
+		{"add", "n", "a"},
+	}
+	gate := make(chan struct{})
+	var (
+		wg    sync.WaitGroup
+		calls [5]atomic.Int64
+	)
+	for i, k := range keys {
+		ctx := context.Background()
+		if k.key != "" {
+			ctx = WithIdempotencyKey(ctx, k.key)
+		}
+		for j := 0; j < duplicates; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := d.do(ctx, k.method, k.model, func() error {
+					calls[i].Add(1)
+					<-gate
+					return nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+	for i, k := range keys {
+		if n := calls[i].Load(); n != 1 {
+			t.Fatalf("%d concurrent %+v calls made %d calls, want 1", duplicates, k, n)
+		}
+	}
+
+	// the calls succeeded within the window
+	if err := d.do(WithIdempotencyKey(context.Background(), "a"), "add", "m", func() error {
+		return errors.New("duplicate called")
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutationDedupFailure(t *testing.T) {
+	d := newMutationDedup(time.Minute)
+	errFailed := errors.New("failed")
+	gate := make(chan struct{})
+	var (
+		wg    sync.WaitGroup
+		calls atomic.Int64
+		errs  [10]error
+	)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.do(context.Background(), "delete", "m", func() error {
+				calls.Add(1)
+				<-gate
+				return errFailed
+			})
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("made %d calls, want 1", calls.Load())
+	}
+	for _, err := range errs {
+		if !errors.Is(err, errFailed) {
+			t.Fatalf("duplicate returned %v, want the error of the call", err)
+		}
+	}
+	// failed calls are not remembered
+	if err := d.do(context.Background(), "delete", "m", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 {
+		t.Fatal("retry deduplicated")
+	}
+}
+
+func TestMutationDedupWindow(t *testing.T) {
+	const window = 50 * time.Millisecond
+	d := newMutationDedup(window)
+	var calls int
+	call := func() error {
+		calls++
+		return nil
+	}
+	for i, wait := range []time.Duration{0, window / 10, 2 * window} {
+		time.Sleep(wait)
+		if err := d.do(context.Background(), "add", "m", call); err != nil {
+			t.Fatal(err)
+		}
+		if want := []int{1, 1, 2}[i]; calls != want {
+			t.Fatalf("call after %s: made %d calls, want %d", wait, calls, want)
+		}
+	}
+}
+
+func TestMutationDedupCancel(t *testing.T) {
+	d := newMutationDedup(time.Minute)
+	gate := make(chan struct{})
+	started := make(chan struct{})
+	go d.do(context.Background(), "add", "m", func() error {
+		close(started)
+		<-gate
+		return nil
+	})
+	<-started
+	defer close(gate)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := d.do(ctx, "add", "m", func() error {
+		return errors.New("duplicate called")
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("duplicate of a call in flight returned %v, want its context error", err)
+	}
+}
@@ -0,0 +1,101 @@
+package jams_client
+
+import (
+	"sync"
+	"time"
+)
+
+// DegradedMode is a way a client keeps working while something is wrong.
+type DegradedMode string
+
+const (
+	// DegradedStaleCache serves stale predictions of a model from the
+	// prediction cache because their background refresh failed.
+	DegradedStaleCache DegradedMode = "stale_cache"
+	// DegradedChannelEvicted replaced an unhealthy connection of the gRPC
+	// channel pool, see WithChannelHealth.
+	DegradedChannelEvicted DegradedMode = "channel_evicted"
+	// DegradedDNSRefresh keeps the HTTP connections in place because the
+	// server host failed to re-resolve, see WithDNSRefresh.
+	DegradedDNSRefresh DegradedMode = "dns_refresh"
+	// DegradedJournal fails to acknowledge journal entries, so that
+	// successful calls will be replayed, see WithJournal.
+	DegradedJournal DegradedMode = "journal"
+)
+
+// DegradedWarning reports that a client entered or left a degraded mode.
+type DegradedWarning struct {
+	Mode DegradedMode
+	// Model is set for the modes specific to a model.
+	Model string
+	Time  time.Time
+	// Recovered is set when the client left the mode. Evictions are single
+	// events, which are never recovered from.
+	Recovered bool
+	// Err is the error which caused the mode, nil on recovery.
+	Err error
+}
+
+// WithDegradedHandler calls handler when the client enters or leaves a
+// degraded mode, e.g. to expose the degraded state in the health checks of a
+// service. Modes are reported once when entered, however many times they
+// occur, and once when left. handler is called synchronously and must not
+// block.
+func WithDegradedHandler(handler func(DegradedWarning)) Option {
+	return func(o *options) {
+		o.degraded = &degradedReporter{handler: handler, active: make(map[degradedKey]bool)}
+	}
+}
+
+type degradedKey struct {
+	mode  DegradedMode
+	model string
+}
+
+// degradedReporter reports the changes of the degraded modes of a client to
+// the handler set with WithDegradedHandler. A nil reporter reports nothing.
+type degradedReporter struct {
+	handler func(DegradedWarning)
+
+	mu     sync.Mutex
+	active map[degradedKey]bool
+}
+
+// enter reports that the client is in mode because of err, unless it already
+// was.
+func (r *degradedReporter) enter(mode DegradedMode, model string, err error) {
+	if r == nil {
+		return
+	}
+	key := degradedKey{mode, model}
+	r.mu.Lock()
+	entered := !r.active[key]
+	r.active[key] = true
+	r.mu.Unlock()
+	if entered {
+		r.handler(DegradedWarning{Mode: mode, Model: model, Time: time.Now(), Err: err})
+	}
+}
+
+// leave reports that the client left mode, if it was in it.
+func (r *degradedReporter) leave(mode DegradedMode, model string) {
+	if r == nil {
+		return
+	}
+	key := degradedKey{mode, model}
+	r.mu.Lock()
+	left := r.active[key]
+	delete(r.active, key)
+	r.mu.Unlock()
+	if left {
+		r.handler(DegradedWarning{Mode: mode, Model: model, Time: time.Now(), Recovered: true})
+	}
+}
+
+// event reports a single degraded event.
+func (r *degradedReporter) event(mode DegradedMode, err error) {
+	if r == nil {
+		return
+	}
+	r.handler(DegradedWarning{Mode: mode, Time: time.Now(), Err: err})
+}
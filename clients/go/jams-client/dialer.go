@@ -0,0 +1,166 @@
+package jams_client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// IPPreference controls which IP families are used to connect to the server.
+type IPPreference int
+
+// IP preferences.
+const (
+	// DualStack uses both families in the order returned by the resolver,
+	// falling back to the other family after the fallback delay. This is the
+	// default.
+	DualStack IPPreference = iota
+	// PreferIPv4 dials IPv4 first and IPv6 after the fallback delay or as
+	// soon as IPv4 fails.
+	PreferIPv4
+	// PreferIPv6 dials IPv6 first and IPv4 after the fallback delay or as
+	// soon as IPv6 fails.
+	PreferIPv6
+	// IPv4Only never uses IPv6.
+	IPv4Only
+	// IPv6Only never uses IPv4.
+	IPv6Only
+)
+
+// WithIPPreference sets which IP families are used to connect to the server,
+// e.g. IPv4Only in networks where IPv6 is advertised but broken.
+func WithIPPreference(p IPPreference) Option {
+	return func(o *options) {
+		o.ipPreference = p
+		o.customDialer = true
+	}
+}
+
+// WithFallbackDelay sets how long to wait for the preferred IP family before
+// also dialling the other one. Zero uses Go's default of 300ms and a negative
+// delay waits for the preferred family to fail before trying the other.
+func WithFallbackDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.fallbackDelay = d
+		o.customDialer = true
+	}
+}
+
+// dialContext dials addr according to the IP preference and fallback delay.
+func (o *options) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:       30 * time.Second,
+		KeepAlive:     30 * time.Second,
+		FallbackDelay: o.fallbackDelay,
+	}
+	switch o.ipPreference {
+	case IPv4Only:
+		return dialer.DialContext(ctx, "tcp4", addr)
+	case IPv6Only:
+		return dialer.DialContext(ctx, "tcp6", addr)
+	case PreferIPv4:
+		return dialPreferred(ctx, dialer, "tcp4", "tcp6", addr)
+	case PreferIPv6:
+		return dialPreferred(ctx, dialer, "tcp6", "tcp4", addr)
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// dialPreferred dials the primary network and, once the fallback delay has
+// passed or the primary dial failed, the fallback network too. The first
+// connection established wins.
+func dialPreferred(ctx context.Context, dialer *net.Dialer, primary, fallback, addr string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		conn    net.Conn
+		err     error
+		primary bool
+	}
+	results := make(chan result, 2)
+	dial := func(network string, primary bool) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		results <- result{conn: conn, err: err, primary: primary}
+	}
+	go dial(primary, true)
+
+	delay := dialer.FallbackDelay
+	if delay == 0 {
+		delay = 300 * time.Millisecond
+	}
+	var timeout <-chan time.Time
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	pending, fallbackStarted := 1, false
+	var firstErr error
+	for {
+		select {
+		case <-timeout:
+			timeout = nil
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				go dial(fallback, false)
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				// close the losing connection if the other dial succeeds too.
+				if pending > 0 {
+					go func() {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}()
+				}
+				return res.conn, nil
+			}
+			if firstErr == nil || res.primary {
+				firstErr = res.err
+			}
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				go dial(fallback, false)
+			}
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+// withDialer returns a copy of client whose transport dials through
+// o.dialContext. Clients with a custom, non *http.Transport RoundTripper are
+// returned unchanged as there is no dialer to replace.
+func withDialer(client *http.Client, o *options) *http.Client {
+	c, transport := cloneTransport(client)
+	if transport == nil {
+		return client
+	}
+	transport.DialContext = o.dialContext
+	return c
+}
+
+// cloneTransport returns a copy of client with a copy of its *http.Transport,
+// to be modified, or a nil transport if client has a custom RoundTripper.
+func cloneTransport(client *http.Client) (*http.Client, *http.Transport) {
+	var transport *http.Transport
+	switch rt := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = rt.Clone()
+	default:
+		return client, nil
+	}
+	c := *client
+	c.Transport = transport
+	return &c, transport
+}
@@ -0,0 +1,548 @@
+// Package diffpred compares the predictions of two models, or of the same
+// model on two servers, record by record, e.g. a champion and a challenger,
+// or recorded traffic and its replay:
+//
+//	d := diffpred.New(diffpred.Options{Tolerance: types.Tolerance{Abs: 1e-6}})
+//	for _, batch := range batches {
+//		a, _ := champion.Predict(ctx, "churn_v1", batch)
+//		b, _ := challenger.Predict(ctx, "churn_v2", batch)
+//		d.Add(batch, a, b)
+//	}
+//	d.Report().WriteText(os.Stdout)
+//
+// The report summarises every column of the outputs both predictions have,
+// lists the records which differ the most and, given the inputs, drills down
+// into the differences by the values of the discrete input features.
+package diffpred
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Defaults of Options.
+const (
+	defaultWorst            = 10
+	defaultMaxFeatureValues = 20
+)
+
+// Options configures a Diff.
+type Options struct {
+	// Tolerance is how far the values of b may be from those of a for them
+	// to agree.
+	Tolerance types.Tolerance
+	// Threshold, when set, is a decision threshold: the report counts the
+	// values which cross it from a to b.
+	Threshold *float64
+	// Worst is the number of most differing records reported, 10 by
+	// default.
+	Worst int
+	// MaxFeatureValues is the number of distinct values of an input feature
+	// drilled down into, 20 by default. Records with other values are left
+	// out of the drill down of the feature.
+	MaxFeatureValues int
+}
+
+// Result is the comparison of the predictions passed to an Add.
+type Result struct {
+	Records int
+	// Mismatched counts the records with a value out of tolerance, or whose
+	// outputs have other rows or columns.
+	Mismatched int
+	// MaxAbsDiff is the largest absolute difference of the values.
+	MaxAbsDiff float64
+}
+
+// Matched reports whether every record matched.
+func (r Result) Matched() bool {
+	return r.Mismatched == 0
+}
+
+// Diff accumulates the differences of pairs of predictions. It is safe for
+// concurrent use.
+type Diff struct {
+	opts Options
+
+	mu       sync.Mutex
+	records  int
+	mismatch int
+	columns  map[columnKey]*columnAcc
+	argmax   map[string]*ArgmaxStats
+	onlyA    map[string]bool
+	onlyB    map[string]bool
+	worst    []RecordDelta
+	features map[string]*featureAcc
+}
+
+type columnKey struct {
+	output string
+	column int
+}
+
+// columnAcc accumulates the sums of the statistics of a column.
+type columnAcc struct {
+	n                      int
+	sumA, sumB             float64
+	sumA2, sumB2, sumAB    float64
+	sumDiff                float64
+	sumAbsDiff, maxAbsDiff float64
+	agree                  int
+	crossedUp, crossedDown int
+}
+
+// featureAcc accumulates the differences by value of an input feature.
+type featureAcc struct {
+	values    map[string]*FeatureValueStats
+	truncated bool
+}
+
+// New returns an empty Diff.
+func New(opts Options) *Diff {
+	if opts.Worst <= 0 {
+		opts.Worst = defaultWorst
+	}
+	if opts.MaxFeatureValues <= 0 {
+		opts.MaxFeatureValues = defaultMaxFeatureValues
+	}
+	return &Diff{
+		opts:     opts,
+		columns:  make(map[columnKey]*columnAcc),
+		argmax:   make(map[string]*ArgmaxStats),
+		onlyA:    make(map[string]bool),
+		onlyB:    make(map[string]bool),
+		features: make(map[string]*featureAcc),
+	}
+}
+
+// Add compares the predictions a and b of the same records, whose input may
+// be nil when there is no drill down by feature.
+func (d *Diff) Add(input *types.Input, a, b *types.Prediction) Result {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var result Result
+	// records miss an output returned by only one of a and b.
+	missing := false
+	for _, name := range a.Names() {
+		result.Records = max(result.Records, len(a.Outputs[name]))
+		if _, ok := b.Output(name); !ok {
+			d.onlyA[name] = true
+			missing = true
+		}
+	}
+	for _, name := range b.Names() {
+		result.Records = max(result.Records, len(b.Outputs[name]))
+		if _, ok := a.Output(name); !ok {
+			d.onlyB[name] = true
+			missing = true
+		}
+	}
+
+	deltas := make([]RecordDelta, result.Records)
+	mismatched := make([]bool, result.Records)
+	for i := range deltas {
+		deltas[i] = RecordDelta{Record: d.records + i, Column: -1}
+		mismatched[i] = missing
+	}
+	for _, name := range a.Names() {
+		rowsA := a.Outputs[name]
+		rowsB, ok := b.Output(name)
+		if !ok {
+			continue
+		}
+		for r := 0; r < result.Records; r++ {
+			if r >= len(rowsA) || r >= len(rowsB) || len(rowsA[r]) != len(rowsB[r]) {
+				mismatched[r] = true
+			}
+			if r >= len(rowsA) || r >= len(rowsB) {
+				continue
+			}
+			rowA, rowB := rowsA[r], rowsB[r]
+			for c := 0; c < min(len(rowA), len(rowB)); c++ {
+				if !d.addValue(name, c, rowA[c], rowB[c]) {
+					mismatched[r] = true
+				}
+				if diff := math.Abs(rowA[c] - rowB[c]); diff > deltas[r].AbsDiff || deltas[r].Column == -1 {
+					deltas[r] = RecordDelta{Record: d.records + r, Output: name, Column: c, A: rowA[c], B: rowB[c], AbsDiff: diff}
+				}
+			}
+			if len(rowA) > 1 && len(rowA) == len(rowB) {
+				stats, ok := d.argmax[name]
+				if !ok {
+					stats = &ArgmaxStats{Output: name}
+					d.argmax[name] = stats
+				}
+				stats.Records++
+				if argmax(rowA) == argmax(rowB) {
+					stats.agreed++
+				}
+			}
+		}
+	}
+
+	for r, delta := range deltas {
+		if mismatched[r] {
+			result.Mismatched++
+		}
+		if !math.IsNaN(delta.AbsDiff) {
+			result.MaxAbsDiff = max(result.MaxAbsDiff, delta.AbsDiff)
+		}
+		d.keepWorst(delta)
+	}
+	if input != nil && input.Len() == result.Records {
+		d.drillDown(input, deltas, mismatched)
+	}
+	d.records += result.Records
+	d.mismatch += result.Mismatched
+	return result
+}
+
+// addValue adds a pair of values of a column and reports whether they agree.
+func (d *Diff) addValue(output string, column int, a, b float64) bool {
+	key := columnKey{output, column}
+	acc, ok := d.columns[key]
+	if !ok {
+		acc = &columnAcc{}
+		d.columns[key] = acc
+	}
+	acc.n++
+	acc.sumA += a
+	acc.sumB += b
+	acc.sumA2 += a * a
+	acc.sumB2 += b * b
+	acc.sumAB += a * b
+	acc.sumDiff += b - a
+	diff := math.Abs(a - b)
+	acc.sumAbsDiff += diff
+	acc.maxAbsDiff = max(acc.maxAbsDiff, diff)
+	if t := d.opts.Threshold; t != nil {
+		switch {
+		case a < *t && b >= *t:
+			acc.crossedUp++
+		case a >= *t && b < *t:
+			acc.crossedDown++
+		}
+	}
+	agree := d.opts.Tolerance.Equal(a, b)
+	if agree {
+		acc.agree++
+	}
+	return agree
+}
+
+// keepWorst keeps delta if it is among the Worst largest. Records without a
+// difference, or with NaN values, are not kept.
+func (d *Diff) keepWorst(delta RecordDelta) {
+	if !(delta.AbsDiff > 0) {
+		return
+	}
+	i := sort.Search(len(d.worst), func(i int) bool { return d.worst[i].AbsDiff < delta.AbsDiff })
+	if i >= d.opts.Worst {
+		return
+	}
+	d.worst = append(d.worst, RecordDelta{})
+	copy(d.worst[i+1:], d.worst[i:])
+	d.worst[i] = delta
+	if len(d.worst) > d.opts.Worst {
+		d.worst = d.worst[:d.opts.Worst]
+	}
+}
+
+// drillDown adds the deltas of the records to the values of the discrete
+// input features, strings and integers, of input.
+func (d *Diff) drillDown(input *types.Input, deltas []RecordDelta, mismatched []bool) {
+	for _, name := range input.Columns() {
+		values, _ := input.Column(name)
+		acc, ok := d.features[name]
+		for r, v := range values {
+			var value string
+			switch v := v.(type) {
+			case string:
+				value = v
+			case int64:
+				value = strconv.FormatInt(v, 10)
+			default:
+				continue
+			}
+			if !ok {
+				acc = &featureAcc{values: make(map[string]*FeatureValueStats)}
+				d.features[name] = acc
+				ok = true
+			}
+			stats, known := acc.values[value]
+			if !known {
+				if len(acc.values) >= d.opts.MaxFeatureValues {
+					acc.truncated = true
+					continue
+				}
+				stats = &FeatureValueStats{Value: value}
+				acc.values[value] = stats
+			}
+			stats.Records++
+			if mismatched[r] {
+				stats.Mismatched++
+			}
+			if diff := deltas[r].AbsDiff; !math.IsNaN(diff) {
+				stats.sumAbsDiff += diff
+			}
+		}
+	}
+}
+
+func argmax(row []float64) int {
+	best := 0
+	for i, v := range row {
+		if v > row[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// Report is the summary of a Diff.
+type Report struct {
+	Records    int `json:"records"`
+	Mismatched int `json:"mismatched"`
+	// OnlyA and OnlyB are the outputs only returned by a or by b.
+	OnlyA   []string      `json:"only_a,omitempty"`
+	OnlyB   []string      `json:"only_b,omitempty"`
+	Columns []ColumnStats `json:"columns"`
+	Argmax  []ArgmaxStats `json:"argmax,omitempty"`
+	// Threshold is the decision threshold of the crossing counts, if any.
+	Threshold *float64 `json:"threshold,omitempty"`
+	// Worst are the records which differ the most, largest difference first.
+	Worst    []RecordDelta  `json:"worst,omitempty"`
+	Features []FeatureStats `json:"features,omitempty"`
+}
+
+// ColumnStats summarises the values of a column of an output.
+type ColumnStats struct {
+	Output  string  `json:"output"`
+	Column  int     `json:"column"`
+	Records int     `json:"records"`
+	MeanA   float64 `json:"mean_a"`
+	MeanB   float64 `json:"mean_b"`
+	StdA    float64 `json:"std_a"`
+	StdB    float64 `json:"std_b"`
+	// Correlation is nil when the values of a or b are constant.
+	Correlation *float64 `json:"correlation,omitempty"`
+	// MeanDiff is the mean of b minus a.
+	MeanDiff    float64 `json:"mean_diff"`
+	MeanAbsDiff float64 `json:"mean_abs_diff"`
+	MaxAbsDiff  float64 `json:"max_abs_diff"`
+	// Agreement is the share of values within the tolerance.
+	Agreement float64 `json:"agreement"`
+	// CrossedUp and CrossedDown count the values below the threshold in a
+	// and not in b, and the reverse. DecisionAgreement is the share of values
+	// on the same side of it. They are only set with a threshold.
+	CrossedUp         int      `json:"crossed_up,omitempty"`
+	CrossedDown       int      `json:"crossed_down,omitempty"`
+	DecisionAgreement *float64 `json:"decision_agreement,omitempty"`
+}
+
+// ArgmaxStats is how often a and b rank the same column of an output with
+// several columns highest, e.g. predict the same class.
+type ArgmaxStats struct {
+	Output    string  `json:"output"`
+	Records   int     `json:"records"`
+	Agreement float64 `json:"agreement"`
+
+	agreed int
+}
+
+// RecordDelta is the largest difference of the values of a record.
+type RecordDelta struct {
+	// Record is the index of the record over all the predictions added.
+	Record  int     `json:"record"`
+	Output  string  `json:"output"`
+	Column  int     `json:"column"`
+	A       float64 `json:"a"`
+	B       float64 `json:"b"`
+	AbsDiff float64 `json:"abs_diff"`
+}
+
+// FeatureStats drills down into the differences by the values of an input
+// feature, the most differing value first.
+type FeatureStats struct {
+	Feature string              `json:"feature"`
+	Values  []FeatureValueStats `json:"values"`
+	// Truncated is set when the feature has more than MaxFeatureValues
+	// values.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// FeatureValueStats is the difference of the records with a value of an
+// input feature.
+type FeatureValueStats struct {
+	Value      string `json:"value"`
+	Records    int    `json:"records"`
+	Mismatched int    `json:"mismatched"`
+	// MeanAbsDiff is the mean of the largest absolute difference of each
+	// record.
+	MeanAbsDiff float64 `json:"mean_abs_diff"`
+
+	sumAbsDiff float64
+}
+
+// Report returns the summary of the predictions added so far. Columns are
+// sorted by output then column, features by name.
+func (d *Diff) Report() Report {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := Report{
+		Records:    d.records,
+		Mismatched: d.mismatch,
+		OnlyA:      sortedKeys(d.onlyA),
+		OnlyB:      sortedKeys(d.onlyB),
+		Columns:    []ColumnStats{},
+		Threshold:  d.opts.Threshold,
+		Worst:      append([]RecordDelta(nil), d.worst...),
+	}
+
+	keys := make([]columnKey, 0, len(d.columns))
+	for key := range d.columns {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].output != keys[j].output {
+			return keys[i].output < keys[j].output
+		}
+		return keys[i].column < keys[j].column
+	})
+	for _, key := range keys {
+		report.Columns = append(report.Columns, d.columns[key].stats(key, d.opts.Threshold != nil))
+	}
+
+	for _, name := range sortedKeys(d.argmax) {
+		stats := *d.argmax[name]
+		stats.Agreement = float64(stats.agreed) / float64(stats.Records)
+		report.Argmax = append(report.Argmax, stats)
+	}
+
+	for _, name := range sortedKeys(d.features) {
+		acc := d.features[name]
+		feature := FeatureStats{Feature: name, Truncated: acc.truncated}
+		for _, v := range acc.values {
+			stats := *v
+			stats.MeanAbsDiff = stats.sumAbsDiff / float64(stats.Records)
+			feature.Values = append(feature.Values, stats)
+		}
+		sort.Slice(feature.Values, func(i, j int) bool {
+			vi, vj := feature.Values[i], feature.Values[j]
+			if vi.MeanAbsDiff != vj.MeanAbsDiff {
+				return vi.MeanAbsDiff > vj.MeanAbsDiff
+			}
+			return vi.Value < vj.Value
+		})
+		report.Features = append(report.Features, feature)
+	}
+	return report
+}
+
+func (acc *columnAcc) stats(key columnKey, threshold bool) ColumnStats {
+	n := float64(acc.n)
+	stats := ColumnStats{
+		Output:      key.output,
+		Column:      key.column,
+		Records:     acc.n,
+		MeanA:       acc.sumA / n,
+		MeanB:       acc.sumB / n,
+		StdA:        std(acc.sumA, acc.sumA2, n),
+		StdB:        std(acc.sumB, acc.sumB2, n),
+		MeanDiff:    acc.sumDiff / n,
+		MeanAbsDiff: acc.sumAbsDiff / n,
+		MaxAbsDiff:  acc.maxAbsDiff,
+		Agreement:   float64(acc.agree) / n,
+	}
+	if stats.StdA > 0 && stats.StdB > 0 {
+		covariance := (acc.sumAB - acc.sumA*acc.sumB/n) / (n - 1)
+		correlation := covariance / (stats.StdA * stats.StdB)
+		stats.Correlation = &correlation
+	}
+	if threshold {
+		stats.CrossedUp, stats.CrossedDown = acc.crossedUp, acc.crossedDown
+		decision := 1 - float64(acc.crossedUp+acc.crossedDown)/n
+		stats.DecisionAgreement = &decision
+	}
+	return stats
+}
+
+// std returns the sample standard deviation of n values from their sum and
+// sum of squares.
+func std(sum, squares, n float64) float64 {
+	if n < 2 {
+		return 0
+	}
+	return math.Sqrt(max(squares-sum*sum/n, 0) / (n - 1))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteText writes the report in a human readable form.
+func (r Report) WriteText(w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("%d records, %d mismatched\n", r.Records, r.Mismatched)
+	for _, name := range r.OnlyA {
+		ew.printf("output %s is only returned by a\n", name)
+	}
+	for _, name := range r.OnlyB {
+		ew.printf("output %s is only returned by b\n", name)
+	}
+	for _, c := range r.Columns {
+		ew.printf("\n%s[%d]: %.2f%% within tolerance, mean %g -> %g (%+g), mean abs diff %g, max abs diff %g",
+			c.Output, c.Column, 100*c.Agreement, c.MeanA, c.MeanB, c.MeanDiff, c.MeanAbsDiff, c.MaxAbsDiff)
+		if c.Correlation != nil {
+			ew.printf(", correlation %.4f", *c.Correlation)
+		}
+		ew.printf("\n")
+		if c.DecisionAgreement != nil {
+			ew.printf("  threshold %g: %.2f%% same side, %d crossed up, %d crossed down\n",
+				*r.Threshold, 100**c.DecisionAgreement, c.CrossedUp, c.CrossedDown)
+		}
+	}
+	for _, a := range r.Argmax {
+		ew.printf("\n%s: same highest column for %.2f%% of %d records\n", a.Output, 100*a.Agreement, a.Records)
+	}
+	if len(r.Worst) > 0 {
+		ew.printf("\nmost differing records:\n")
+		for _, delta := range r.Worst {
+			ew.printf("  #%d %s[%d]: %g -> %g (%g)\n", delta.Record, delta.Output, delta.Column, delta.A, delta.B, delta.AbsDiff)
+		}
+	}
+	for _, f := range r.Features {
+		ew.printf("\nby %s:\n", f.Feature)
+		for _, v := range f.Values {
+			ew.printf("  %s: %d records, %d mismatched, mean abs diff %g\n", v.Value, v.Records, v.Mismatched, v.MeanAbsDiff)
+		}
+		if f.Truncated {
+			ew.printf("  (more than %d values, others left out)\n", len(f.Values))
+		}
+	}
+	return ew.err
+}
+
+// errWriter keeps the first error of a series of writes.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
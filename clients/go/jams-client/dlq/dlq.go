@@ -0,0 +1,163 @@
+// Package dlq stores dead letters as JSON lines, one per failed input, e.g. in
+// a local file, for offline inspection:
+//
+//	q, err := dlq.OpenFile("dead-letters.jsonl")
+//	defer q.Close()
+//	chunks, err := client.PredictBatch(ctx, "churn", input, jams.BatchOptions{DeadLetters: q})
+//
+//	letters, err := dlq.ReadFile("dead-letters.jsonl")
+//	for _, l := range letters {
+//		fmt.Println(l.Source, l.Model, l.Err)
+//	}
+//
+// Each line holds the source, model, time, record range and error message of
+// a dead letter, and its input in the wire format of the server, so that it
+// can be sent again, e.g. with jams-cli predict --input, or the raw records
+// of dead letters without an input. Inputs are written as given, i.e. redacted by the client unless the source was asked for
+// unredacted dead letters, in which case the file holds them in plaintext.
+package dlq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// line is a dead letter as written.
+type line struct {
+	Time    time.Time         `json:"time"`
+	Source  string            `json:"source"`
+	Model   string            `json:"model"`
+	Records *jams.RecordRange `json:"records,omitempty"`
+	Error   string            `json:"error"`
+	Input   json.RawMessage   `json:"input,omitempty"`
+	// RawRecords are the raw records of dead letters without an input.
+	RawRecords []map[string]any `json:"raw_records,omitempty"`
+}
+
+// Writer is a jams.DeadLetterQueue writing JSON lines. It is safe for
+// concurrent use.
+type Writer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriter returns a queue writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// OpenFile returns a queue appending to the file at path, creating it if
+// needed.
+func OpenFile(path string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{w: f, closer: f}, nil
+}
+
+// Put implements jams.DeadLetterQueue. Each dead letter is written at once.
+func (w *Writer) Put(_ context.Context, l jams.DeadLetter) error {
+	if l.Input == nil && l.RawRecords == nil {
+		return jams.ErrNilInput
+	}
+	var input json.RawMessage
+	if l.Input != nil {
+		var err error
+		if input, err = l.Input.MarshalJSON(); err != nil {
+			return fmt.Errorf("failed to encode input: %w", err)
+		}
+	}
+	var msg string
+	if l.Err != nil {
+		msg = l.Err.Error()
+	}
+	data, err := json.Marshal(line{
+		Time:       l.Time,
+		Source:     l.Source,
+		Model:      l.Model,
+		Records:    l.Records,
+		Error:      msg,
+		Input:      input,
+		RawRecords: l.RawRecords,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file of a queue returned by OpenFile. It does nothing for
+// queues returned by NewWriter.
+func (w *Writer) Close() error {
+	if w.closer == nil {
+		return nil
+	}
+	return w.closer.Close()
+}
+
+// Read reads the dead letters written to r. Their Err only holds the error
+// message, and the numbers of their RawRecords are json.Numbers.
+func Read(r io.Reader) ([]jams.DeadLetter, error) {
+	var letters []jams.DeadLetter
+	scanner := bufio.NewScanner(r)
+	// inputs are written on a single line
+	scanner.Buffer(nil, 1<<30)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var l line
+		// numbers of raw records keep telling integers from floats
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		decoder.UseNumber()
+		if err := decoder.Decode(&l); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		letter := jams.DeadLetter{
+			Source:     l.Source,
+			Model:      l.Model,
+			Time:       l.Time,
+			Records:    l.Records,
+			RawRecords: l.RawRecords,
+		}
+		if l.Input != nil {
+			input, err := types.ParseInput(l.Input)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			letter.Input = input
+		}
+		if l.Error != "" {
+			letter.Err = errors.New(l.Error)
+		}
+		letters = append(letters, letter)
+	}
+	return letters, scanner.Err()
+}
+
+// ReadFile reads the dead letters written to the file at path.
+func ReadFile(path string) ([]jams.DeadLetter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,71 @@
+package dlq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+func TestWriterRead(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	now := time.Now().UTC().Truncate(time.Second)
+	letters := []jams.DeadLetter{
+		{
+			Source:  jams.DeadLetterSourceBatch,
+			Model:   "m",
+			Time:    now,
+			Records: &jams.RecordRange{Start: 10, End: 12},
+			Input:   types.NewInput().AddFloats("x", 1, 2.5).AddInts("n", 1, 2),
+			Err:     errors.New("invalid input"),
+		},
+		{
+			Source:     jams.DeadLetterSourceBatch,
+			Model:      "m",
+			Time:       now,
+			Records:    &jams.RecordRange{Start: 20, End: 22},
+			RawRecords: []map[string]any{{"x": 1.5, "n": 1}, {"x": 2.5}},
+			Err:        errors.New("record 1 has 1 features, expected 2"),
+		},
+	}
+	for _, l := range letters {
+		if err := w.Put(context.Background(), l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Put(context.Background(), jams.DeadLetter{Model: "m"}); !errors.Is(err, jams.ErrNilInput) {
+		t.Fatalf("got error %v for a dead letter without input, want ErrNilInput", err)
+	}
+
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(letters) {
+		t.Fatalf("read %d dead letters, want %d", len(read), len(letters))
+	}
+	for i, l := range read {
+		want := letters[i]
+		if l.Source != want.Source || l.Model != want.Model || !l.Time.Equal(want.Time) || *l.Records != *want.Records || l.Err.Error() != want.Err.Error() {
+			t.Fatalf("read %+v, want %+v", l, want)
+		}
+	}
+
+	input, _ := read[0].Input.MarshalJSON()
+	if want, _ := letters[0].Input.MarshalJSON(); !bytes.Equal(input, want) {
+		t.Fatalf("read input %s, want %s", input, want)
+	}
+	if read[0].RawRecords != nil || read[1].Input != nil {
+		t.Fatalf("read %+v and %+v", read[0], read[1])
+	}
+	records := read[1].RawRecords
+	if len(records) != 2 || records[0]["x"] != json.Number("1.5") || records[0]["n"] != json.Number("1") || records[1]["x"] != json.Number("2.5") {
+		t.Fatalf("read raw records %v", records)
+	}
+}
@@ -0,0 +1,313 @@
+// Package dynamic calls a J.A.M.S gRPC server without generated stubs. The
+// services and messages of the server are discovered at runtime with gRPC
+// server reflection, which the server enables by default, so that generic
+// tools such as CLIs and gateways keep working across API versions.
+//
+//	conn, err := grpc.NewClient("localhost:4000", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	client, err := dynamic.New(ctx, conn)
+//	fmt.Println(client.Methods())
+//	prediction, err := client.Predict(ctx, "titanic_model", input)
+//	output, err := client.Invoke(ctx, "GetModels", []byte(`{}`))
+//
+// Requests and responses of Invoke are the protobuf JSON mapping of the
+// messages. The client speaks the v1alpha reflection protocol, the version
+// served by the server.
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// DefaultService is the name of the service used unless another is given
+// with WithService. The package of the service may differ.
+const DefaultService = "ModelServer"
+
+// Client calls the methods of a service discovered with server reflection.
+// It is safe for concurrent use.
+type Client struct {
+	conn    *grpc.ClientConn
+	service protoreflect.ServiceDescriptor
+}
+
+// Option configures a Client.
+type Option func(*config)
+
+type config struct {
+	service string
+}
+
+// WithService selects the service by name, e.g. "jams_v1.ModelServer", or by
+// name without package.
+func WithService(name string) Option {
+	return func(c *config) {
+		c.service = name
+	}
+}
+
+// New discovers the service of the server at conn. The connection is owned
+// by the caller.
+func New(ctx context.Context, conn *grpc.ClientConn, opts ...Option) (*Client, error) {
+	cfg := &config{service: DefaultService}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open reflection stream: %w", err)
+	}
+	defer stream.CloseSend()
+	r := &resolver{stream: stream, files: make(map[string]*descriptorpb.FileDescriptorProto)}
+
+	services, err := r.listServices()
+	if err != nil {
+		return nil, err
+	}
+	var name string
+	for _, s := range services {
+		if s == cfg.service || strings.HasSuffix(s, "."+cfg.service) {
+			name = s
+			break
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("server has no service %q, it has %s", cfg.service, strings.Join(services, ", "))
+	}
+
+	if err := r.fileContainingSymbol(name); err != nil {
+		return nil, err
+	}
+	files, err := r.registry()
+	if err != nil {
+		return nil, err
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve service %q: %w", name, err)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a service", name)
+	}
+	return &Client{conn: conn, service: service}, nil
+}
+
+// Service returns the descriptor of the service.
+func (c *Client) Service() protoreflect.ServiceDescriptor {
+	return c.service
+}
+
+// Methods returns the names of the unary methods of the service.
+func (c *Client) Methods() []string {
+	var names []string
+	methods := c.service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		if m := methods.Get(i); !m.IsStreamingClient() && !m.IsStreamingServer() {
+			names = append(names, string(m.Name()))
+		}
+	}
+	return names
+}
+
+// Invoke calls a unary method with a request in the protobuf JSON mapping and
+// returns the response in the same mapping.
+func (c *Client) Invoke(ctx context.Context, method string, request []byte) ([]byte, error) {
+	m, err := c.method(method)
+	if err != nil {
+		return nil, err
+	}
+	req := dynamicpb.NewMessage(m.Input())
+	if err := protojson.Unmarshal(request, req); err != nil {
+		return nil, fmt.Errorf("invalid %s request: %w", m.Name(), err)
+	}
+	resp, err := c.invoke(ctx, m, req)
+	if err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(resp)
+}
+
+// Predict makes predictions with the Predict method, filling its request by
+// field name: model_name and input, and reading the output field of its
+// response.
+func (c *Client) Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error) {
+	if input == nil {
+		return nil, jams.ErrNilInput
+	}
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	payload, err := input.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := c.method("Predict")
+	if err != nil {
+		return nil, err
+	}
+	req := dynamicpb.NewMessage(m.Input())
+	if err := setString(req, "model_name", modelName); err != nil {
+		return nil, err
+	}
+	if err := setString(req, "input", string(payload)); err != nil {
+		return nil, err
+	}
+	resp, err := c.invoke(ctx, m, req)
+	if err != nil {
+		return nil, err
+	}
+	field := resp.Descriptor().Fields().ByName("output")
+	if field == nil || field.Kind() != protoreflect.StringKind {
+		return nil, fmt.Errorf("%s has no string field output", resp.Descriptor().FullName())
+	}
+	return types.ParsePrediction(resp.Get(field).String())
+}
+
+func (c *Client) method(name string) (protoreflect.MethodDescriptor, error) {
+	m := c.service.Methods().ByName(protoreflect.Name(name))
+	if m == nil {
+		return nil, fmt.Errorf("service %s has no method %q", c.service.FullName(), name)
+	}
+	if m.IsStreamingClient() || m.IsStreamingServer() {
+		return nil, fmt.Errorf("method %q is streaming", name)
+	}
+	return m, nil
+}
+
+func (c *Client) invoke(ctx context.Context, m protoreflect.MethodDescriptor, req proto.Message) (*dynamicpb.Message, error) {
+	resp := dynamicpb.NewMessage(m.Output())
+	method := fmt.Sprintf("/%s/%s", c.service.FullName(), m.Name())
+	if err := c.conn.Invoke(ctx, method, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func setString(msg *dynamicpb.Message, name, value string) error {
+	field := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if field == nil || field.Kind() != protoreflect.StringKind {
+		return fmt.Errorf("%s has no string field %s", msg.Descriptor().FullName(), name)
+	}
+	msg.Set(field, protoreflect.ValueOfString(value))
+	return nil
+}
+
+// resolver fetches file descriptors over a reflection stream.
+type resolver struct {
+	stream rpb.ServerReflection_ServerReflectionInfoClient
+	files  map[string]*descriptorpb.FileDescriptorProto
+}
+
+func (r *resolver) send(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := r.stream.Send(req); err != nil {
+		return nil, fmt.Errorf("reflection request failed: %w", err)
+	}
+	resp, err := r.stream.Recv()
+	if err == io.EOF {
+		return nil, errors.New("reflection stream closed by the server")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reflection request failed: %w", err)
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, fmt.Errorf("reflection request failed: %s", e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+func (r *resolver) listServices() ([]string, error) {
+	resp, err := r.send(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		names = append(names, s.GetName())
+	}
+	return names, nil
+}
+
+func (r *resolver) fileContainingSymbol(symbol string) error {
+	resp, err := r.send(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if err != nil {
+		return err
+	}
+	return r.add(resp)
+}
+
+func (r *resolver) add(resp *rpb.ServerReflectionResponse) error {
+	for _, data := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		file := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(data, file); err != nil {
+			return fmt.Errorf("invalid file descriptor: %w", err)
+		}
+		r.files[file.GetName()] = file
+	}
+	return nil
+}
+
+// registry fetches the missing dependencies of the files, falling back to the
+// well-known types linked into the binary, and builds a registry of them.
+func (r *resolver) registry() (*protoregistry.Files, error) {
+	for missing := r.missing(); missing != ""; missing = r.missing() {
+		resp, err := r.send(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		})
+		if err == nil {
+			err = r.add(resp)
+		}
+		if _, ok := r.files[missing]; !ok {
+			local, lerr := protoregistry.GlobalFiles.FindFileByPath(missing)
+			if lerr != nil {
+				if err == nil {
+					err = fmt.Errorf("server did not return %s", missing)
+				}
+				return nil, fmt.Errorf("failed to resolve %s: %w", missing, err)
+			}
+			r.files[missing] = protodesc.ToFileDescriptorProto(local)
+		}
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range r.files {
+		set.File = append(set.File, file)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptors: %w", err)
+	}
+	return files, nil
+}
+
+// missing returns a dependency not fetched yet, or "".
+func (r *resolver) missing() string {
+	for _, file := range r.files {
+		for _, dep := range file.GetDependency() {
+			if _, ok := r.files[dep]; !ok {
+				return dep
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,258 @@
+// Package encoding applies categorical encoders to model inputs on the client,
+// so models exported without their preprocessing can still be served by
+// J.A.M.S.
+//
+//	encoders, err := encoding.Load("encoders.json")
+//	encoded, err := encoders.Apply(input)
+//	prediction, err := client.Predict(ctx, "lightgbm-churn", encoded)
+//
+// Encoded columns hold float values, like the output of scikit-learn's
+// encoders. Input values are matched against categories by their string
+// form, so the int 3 matches the category "3".
+package encoding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Encoder transforms a model input. Apply must not modify its argument.
+type Encoder interface {
+	Apply(in *types.Input) (*types.Input, error)
+}
+
+// Encoders applies encoders in order.
+type Encoders []Encoder
+
+// Apply implements Encoder.
+func (e Encoders) Apply(in *types.Input) (*types.Input, error) {
+	for _, encoder := range e {
+		var err error
+		if in, err = encoder.Apply(in); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// OneHot replaces a categorical column with one column per category, named
+// "<column>_<category>", set to 1 for the matching category and 0 otherwise.
+type OneHot struct {
+	Column     string
+	Categories []string
+	// IgnoreUnknown encodes unknown categories as all zeros instead of
+	// failing.
+	IgnoreUnknown bool
+	// Keep keeps the original column.
+	Keep bool
+}
+
+// Apply implements Encoder.
+func (e *OneHot) Apply(in *types.Input) (*types.Input, error) {
+	values, err := column(in, e.Column)
+	if err != nil {
+		return nil, err
+	}
+	index := categoryIndex(e.Categories)
+	encoded := make([][]float64, len(e.Categories))
+	for i := range encoded {
+		encoded[i] = make([]float64, len(values))
+	}
+	for n, v := range values {
+		i, ok := index[fmt.Sprint(v)]
+		if !ok {
+			if e.IgnoreUnknown {
+				continue
+			}
+			return nil, fmt.Errorf("column %q: unknown category %q", e.Column, fmt.Sprint(v))
+		}
+		encoded[i][n] = 1
+	}
+
+	out := in.Clone()
+	if !e.Keep {
+		out.Delete(e.Column)
+	}
+	for i, category := range e.Categories {
+		out.AddFloats(e.Column+"_"+category, encoded[i]...)
+	}
+	return out, nil
+}
+
+// Ordinal replaces a categorical column with the index of each value in
+// Categories.
+type Ordinal struct {
+	Column     string
+	Categories []string
+	// UnknownValue is used for unknown categories. Unknown categories fail
+	// when it is nil.
+	UnknownValue *float64
+}
+
+// Apply implements Encoder.
+func (e *Ordinal) Apply(in *types.Input) (*types.Input, error) {
+	values, err := column(in, e.Column)
+	if err != nil {
+		return nil, err
+	}
+	index := categoryIndex(e.Categories)
+	encoded := make([]float64, len(values))
+	for n, v := range values {
+		i, ok := index[fmt.Sprint(v)]
+		switch {
+		case ok:
+			encoded[n] = float64(i)
+		case e.UnknownValue != nil:
+			encoded[n] = *e.UnknownValue
+		default:
+			return nil, fmt.Errorf("column %q: unknown category %q", e.Column, fmt.Sprint(v))
+		}
+	}
+	return in.Clone().AddFloats(e.Column, encoded...), nil
+}
+
+// Hashing replaces a categorical column with Buckets columns, named
+// "<column>_<bucket>", setting the bucket chosen by the FNV-1a hash of each
+// value to 1. Unlike OneHot it needs no list of categories.
+type Hashing struct {
+	Column  string
+	Buckets int
+	// Keep keeps the original column.
+	Keep bool
+}
+
+// Apply implements Encoder.
+func (e *Hashing) Apply(in *types.Input) (*types.Input, error) {
+	if e.Buckets <= 0 {
+		return nil, fmt.Errorf("column %q: buckets must be greater than zero", e.Column)
+	}
+	values, err := column(in, e.Column)
+	if err != nil {
+		return nil, err
+	}
+	encoded := make([][]float64, e.Buckets)
+	for i := range encoded {
+		encoded[i] = make([]float64, len(values))
+	}
+	for n, v := range values {
+		encoded[Bucket(fmt.Sprint(v), e.Buckets)][n] = 1
+	}
+
+	out := in.Clone()
+	if !e.Keep {
+		out.Delete(e.Column)
+	}
+	digits := len(fmt.Sprint(e.Buckets - 1))
+	for i := range encoded {
+		out.AddFloats(fmt.Sprintf("%s_%0*d", e.Column, digits, i), encoded[i]...)
+	}
+	return out, nil
+}
+
+// Bucket returns the hashing bucket of value.
+func Bucket(value string, buckets int) int {
+	h := fnv.New32a()
+	h.Write([]byte(value))
+	return int(h.Sum32() % uint32(buckets))
+}
+
+// Spec is the JSON representation of a list of encoders:
+//
+//	{"encoders": [
+//		{"type": "one_hot", "column": "sex", "categories": ["male", "female"]},
+//		{"type": "ordinal", "column": "pclass", "categories": ["1", "2", "3"], "unknown_value": -1},
+//		{"type": "hashing", "column": "city", "buckets": 32}
+//	]}
+type Spec struct {
+	Encoders []EncoderSpec `json:"encoders" yaml:"encoders"`
+}
+
+// EncoderSpec configures a single encoder. Type is one of "one_hot",
+// "ordinal" or "hashing"; the other fields apply to the encoders which share
+// their name.
+type EncoderSpec struct {
+	Type          string   `json:"type" yaml:"type"`
+	Column        string   `json:"column" yaml:"column"`
+	Categories    []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+	IgnoreUnknown bool     `json:"ignore_unknown,omitempty" yaml:"ignore_unknown,omitempty"`
+	UnknownValue  *float64 `json:"unknown_value,omitempty" yaml:"unknown_value,omitempty"`
+	Buckets       int      `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+	Keep          bool     `json:"keep,omitempty" yaml:"keep,omitempty"`
+}
+
+// Build builds the encoders described by the spec.
+func (s Spec) Build() (Encoders, error) {
+	encoders := make(Encoders, 0, len(s.Encoders))
+	for i, spec := range s.Encoders {
+		encoder, err := spec.Build()
+		if err != nil {
+			return nil, fmt.Errorf("encoder %d: %w", i, err)
+		}
+		encoders = append(encoders, encoder)
+	}
+	return encoders, nil
+}
+
+// Build builds the encoder described by the spec.
+func (s EncoderSpec) Build() (Encoder, error) {
+	if s.Column == "" {
+		return nil, errors.New("a column is required")
+	}
+	switch s.Type {
+	case "one_hot":
+		if len(s.Categories) == 0 {
+			return nil, errors.New("one_hot requires categories")
+		}
+		return &OneHot{Column: s.Column, Categories: s.Categories, IgnoreUnknown: s.IgnoreUnknown, Keep: s.Keep}, nil
+	case "ordinal":
+		if len(s.Categories) == 0 {
+			return nil, errors.New("ordinal requires categories")
+		}
+		return &Ordinal{Column: s.Column, Categories: s.Categories, UnknownValue: s.UnknownValue}, nil
+	case "hashing":
+		if s.Buckets <= 0 {
+			return nil, errors.New("hashing requires buckets greater than zero")
+		}
+		return &Hashing{Column: s.Column, Buckets: s.Buckets, Keep: s.Keep}, nil
+	}
+	return nil, fmt.Errorf("unknown encoder type %q", s.Type)
+}
+
+// Parse builds the encoders described by a JSON encoded Spec.
+func Parse(data []byte) (Encoders, error) {
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse encoding spec: %w", err)
+	}
+	return spec.Build()
+}
+
+// Load builds the encoders described by a JSON encoded Spec file.
+func Load(path string) (Encoders, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func column(in *types.Input, name string) ([]any, error) {
+	values, ok := in.Column(name)
+	if !ok {
+		return nil, fmt.Errorf("input has no column %q", name)
+	}
+	return values, nil
+}
+
+func categoryIndex(categories []string) map[string]int {
+	index := make(map[string]int, len(categories))
+	for i, category := range categories {
+		index[category] = i
+	}
+	return index
+}
@@ -0,0 +1,32 @@
+package jams_client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNilInput is returned by Predict when no input is given.
+var ErrNilInput = errors.New("input must not be nil")
+
+// ErrNoGRPC is returned by NewGRPCClient, and by NewClient for gRPC
+// endpoints, when the client is built with the jams_nogrpc tag or for
+// js/wasm.
+var ErrNoGRPC = errors.New("client built without gRPC support")
+
+// HTTPError is returned when the HTTP server responds with a non 2xx status.
+type HTTPError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Body is the response body, if any.
+	Body string
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Body)
+}
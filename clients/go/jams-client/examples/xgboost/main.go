@@ -0,0 +1,75 @@
+// Command xgboost scores iris flowers with an XGBoost model served by
+// J.A.M.S, decoding the predictions according to the objective read from its
+// JSON model file. It works for binary, multiclass and regression objectives,
+// once the server serves XGBoost predictions.
+//
+//	go run ./examples/xgboost --model xgboost_iris --model-file xgboost_iris.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/frameworks"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/frameworks/xgboost"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+func main() {
+	url := flag.String("url", "http://localhost:3000", "url of the J.A.M.S HTTP API")
+	model := flag.String("model", "xgboost_iris", "name of the model")
+	modelFile := flag.String("model-file", "xgboost_iris.json", "XGBoost JSON model file, to read the objective from")
+	flag.Parse()
+
+	objective, err := xgboost.LoadObjective(*modelFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := jams.NewHTTPClient(*url)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	prediction, err := client.Predict(context.Background(), *model, irisInput())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := printPrediction(objective, prediction); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// irisInput returns three flowers, one of each species.
+func irisInput() *types.Input {
+	return types.NewInput().
+		AddFloats("sepal_length", 5.1, 6.4, 6.3).
+		AddFloats("sepal_width", 3.5, 3.2, 3.3).
+		AddFloats("petal_length", 1.4, 4.5, 6.0).
+		AddFloats("petal_width", 0.2, 1.5, 2.5)
+}
+
+func printPrediction(objective frameworks.Objective, prediction *types.Prediction) error {
+	if objective.Task == frameworks.Regression {
+		values, err := objective.Regress(prediction)
+		if err != nil {
+			return err
+		}
+		for i, v := range values {
+			fmt.Printf("record %d: %.4f\n", i, v)
+		}
+		return nil
+	}
+	classification, err := objective.Classify(prediction)
+	if err != nil {
+		return err
+	}
+	classes := classification.Classes(0.5)
+	for i, probabilities := range classification.Probabilities() {
+		fmt.Printf("record %d: class %d, probabilities %.4f\n", i, classes[i], probabilities)
+	}
+	return nil
+}
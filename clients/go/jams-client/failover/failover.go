@@ -0,0 +1,286 @@
+// Package failover sends calls to a primary J.A.M.S server and switches them
+// over to a warm standby while the primary is unhealthy, for two-site
+// deployments which do not need a cluster:
+//
+//	c, err := failover.New(failover.Config{
+//		Primary: "http://jams.site-a:3000",
+//		Standby: "http://jams.site-b:3000",
+//		OnSwitch: func(s failover.Switch) {
+//			log.Printf("switched from %s to %s: %v", s.From, s.To, s.Err)
+//		},
+//	}, jams.WithRetry(3))
+//	defer c.Close()
+//
+//	prediction, err := c.Predict(ctx, "titanic_model", input)
+//
+// Both servers are health checked in the background. Calls switch to the
+// standby once the primary failed several checks in a row and back once it
+// passed several in a row, so that a flapping primary does not bounce calls
+// between the sites.
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Defaults of Config.
+const (
+	defaultInterval          = 5 * time.Second
+	defaultFailureThreshold  = 3
+	defaultRecoveryThreshold = 5
+)
+
+// Config configures a Client.
+type Config struct {
+	// Primary and Standby are the endpoints of the servers, see
+	// jams.NewClient.
+	Primary string
+	Standby string
+	// Interval is the period of the health checks, which time out after
+	// it. Defaults to 5s.
+	Interval time.Duration
+	// FailureThreshold is the number of health checks in a row the active
+	// server must fail before calls switch to the other one, if it is
+	// healthy. Defaults to 3.
+	FailureThreshold int
+	// RecoveryThreshold is the number of health checks in a row the
+	// primary must pass before calls switch back to it. Defaults to 5.
+	RecoveryThreshold int
+	// OnSwitch is called after calls switched from one server to the other.
+	// It is called from the goroutine of the health checks and must not
+	// block.
+	OnSwitch func(Switch)
+}
+
+// Switch reports that calls switched from one server to the other.
+type Switch struct {
+	From string
+	To   string
+	Time time.Time
+	// Err is the last health check failure of the server switched from, nil
+	// when switching back to a recovered primary.
+	Err error
+}
+
+// ServerStatus is the health of a server as last checked.
+type ServerStatus struct {
+	Endpoint string
+	Healthy  bool
+	// Streak is the number of health checks in a row with the same outcome.
+	Streak int
+	// Err is the last health check failure, nil when healthy.
+	Err error
+}
+
+// Status is the state of a Client.
+type Status struct {
+	// Active is the endpoint calls are sent to.
+	Active  string
+	Primary ServerStatus
+	Standby ServerStatus
+}
+
+// server is a server and the outcome of its health checks. Its status is
+// written by the health checks under Client.mu.
+type server struct {
+	client *jams.Client
+	status ServerStatus
+}
+
+// check records the outcome of a health check.
+func (s *server) check(err error) {
+	healthy := err == nil
+	if healthy == s.status.Healthy {
+		s.status.Streak++
+	} else {
+		s.status.Healthy, s.status.Streak = healthy, 1
+	}
+	s.status.Err = err
+}
+
+// Client sends calls to the primary server or, while it is unhealthy, to the
+// standby. It is safe for concurrent use.
+type Client struct {
+	cfg     Config
+	primary *server
+	standby *server
+	// active is the server calls are sent to.
+	active atomic.Pointer[server]
+
+	// mu guards the statuses of the servers against Status.
+	mu      sync.Mutex
+	done    chan struct{}
+	stopped chan struct{}
+	stop    sync.Once
+}
+
+// New creates a client for both servers of cfg, opts applying to both, and
+// starts health checking them. Calls go to the primary until it fails its
+// first checks.
+func New(cfg Config, opts ...jams.Option) (*Client, error) {
+	if cfg.Primary == "" || cfg.Standby == "" {
+		return nil, errors.New("a primary and a standby endpoint are required")
+	}
+	if cfg.Primary == cfg.Standby {
+		return nil, fmt.Errorf("primary and standby are both %q", cfg.Primary)
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultFailureThreshold
+	}
+	if cfg.RecoveryThreshold <= 0 {
+		cfg.RecoveryThreshold = defaultRecoveryThreshold
+	}
+
+	primary, err := jams.NewClient(cfg.Primary, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("primary: %w", err)
+	}
+	standby, err := jams.NewClient(cfg.Standby, opts...)
+	if err != nil {
+		primary.Close()
+		return nil, fmt.Errorf("standby: %w", err)
+	}
+	c := &Client{
+		cfg: cfg,
+		// servers are presumed healthy until checked.
+		primary: &server{client: primary, status: ServerStatus{Endpoint: cfg.Primary, Healthy: true}},
+		standby: &server{client: standby, status: ServerStatus{Endpoint: cfg.Standby, Healthy: true}},
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	c.active.Store(c.primary)
+	go c.run()
+	return c, nil
+}
+
+func (c *Client) run() {
+	defer close(c.stopped)
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		c.checkServers()
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// checkServers health checks both servers concurrently and switches calls
+// over if needed.
+func (c *Client) checkServers() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Interval)
+	defer cancel()
+	go func() {
+		// a check in flight when the client is closed is abandoned.
+		select {
+		case <-c.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var primaryErr, standbyErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		standbyErr = c.standby.client.HealthCheck(ctx)
+	}()
+	primaryErr = c.primary.client.HealthCheck(ctx)
+	wg.Wait()
+	if ctx.Err() != nil && c.closed() {
+		return
+	}
+
+	c.mu.Lock()
+	c.primary.check(primaryErr)
+	c.standby.check(standbyErr)
+	c.mu.Unlock()
+
+	if sw, ok := c.nextSwitch(); ok && c.cfg.OnSwitch != nil {
+		c.cfg.OnSwitch(sw)
+	}
+}
+
+// nextSwitch switches calls to the other server when the active one failed
+// FailureThreshold checks in a row and the other is healthy, or back to the
+// primary once it passed RecoveryThreshold checks in a row.
+func (c *Client) nextSwitch() (Switch, bool) {
+	from, to := c.active.Load(), c.primary
+	if from == c.primary {
+		to = c.standby
+	}
+	failed := !from.status.Healthy && from.status.Streak >= c.cfg.FailureThreshold && to.status.Healthy
+	recovered := from == c.standby && c.primary.status.Healthy && c.primary.status.Streak >= c.cfg.RecoveryThreshold
+	if !failed && !recovered {
+		return Switch{}, false
+	}
+	c.active.Store(to)
+	sw := Switch{From: from.status.Endpoint, To: to.status.Endpoint, Time: time.Now()}
+	if failed {
+		sw.Err = from.status.Err
+	}
+	return sw, true
+}
+
+func (c *Client) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Active returns the client of the server calls are sent to, e.g. to make
+// calls the failover client does not wrap.
+func (c *Client) Active() *jams.Client {
+	return c.active.Load().client
+}
+
+// Status returns the active endpoint and the health of both servers as last
+// checked.
+func (c *Client) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Status{
+		Active:  c.active.Load().status.Endpoint,
+		Primary: c.primary.status,
+		Standby: c.standby.status,
+	}
+}
+
+// Predict makes predictions with the named model on the active server.
+func (c *Client) Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error) {
+	return c.Active().Predict(ctx, modelName, input)
+}
+
+// GetModels returns the models loaded into the active server.
+func (c *Client) GetModels(ctx context.Context) ([]jams.ModelMetadata, error) {
+	return c.Active().GetModels(ctx)
+}
+
+// HealthCheck checks whether the active server is up.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return c.Active().HealthCheck(ctx)
+}
+
+// Close stops the health checks and closes the clients of both servers.
+func (c *Client) Close() error {
+	c.stop.Do(func() { close(c.done) })
+	<-c.stopped
+	return errors.Join(c.primary.client.Close(), c.standby.client.Close())
+}
@@ -0,0 +1,185 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// fakeServer is a J.A.M.S HTTP API predicting its id for every record.
+type fakeServer struct {
+	id float64
+	// health and predict are the statuses of the health checks and
+	// predictions, 200 when zero.
+	health  atomic.Int32
+	predict atomic.Int32
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := &s.predict
+	if r.URL.Path == "/healthcheck" {
+		status = &s.health
+	}
+	if code := status.Load(); code != 0 {
+		http.Error(w, http.StatusText(int(code)), int(code))
+		return
+	}
+	if r.URL.Path == "/healthcheck" {
+		return
+	}
+	output, _ := json.Marshal(map[string][][]float64{"predictions": {{s.id}}})
+	json.NewEncoder(w).Encode(map[string]string{"output": string(output)})
+}
+
+// predictedBy returns the id of the server which answered a Predict call.
+func predictedBy(t *testing.T, c *Client) float64 {
+	t.Helper()
+	prediction, err := c.Predict(context.Background(), "titanic_model", types.NewInput().AddFloats("x", 1))
+	if err != nil {
+		t.Fatalf("Predict: %v", err)
+	}
+	return prediction.Outputs["predictions"][0][0]
+}
+
+func TestFailover(t *testing.T) {
+	primary, standby := &fakeServer{id: 1}, &fakeServer{id: 2}
+	primarySrv, standbySrv := httptest.NewServer(primary), httptest.NewServer(standby)
+	defer primarySrv.Close()
+	defer standbySrv.Close()
+
+	switches := make(chan Switch, 10)
+	c, err := New(Config{
+		Primary:           primarySrv.URL,
+		Standby:           standbySrv.URL,
+		Interval:          10 * time.Millisecond,
+		FailureThreshold:  2,
+		RecoveryThreshold: 3,
+		OnSwitch:          func(s Switch) { switches <- s },
+	}, jams.WithRetry(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+
+	waitSwitch := func(from, to string) Switch {
+		t.Helper()
+		select {
+		case s := <-switches:
+			if s.From != from || s.To != to {
+				t.Fatalf("switched from %s to %s, want from %s to %s", s.From, s.To, from, to)
+			}
+			if c.Status().Active != to {
+				t.Fatalf("active server is %s after switching to %s", c.Status().Active, to)
+			}
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no switch from %s to %s", from, to)
+		}
+		return Switch{}
+	}
+	// waitChecks waits for the primary to pass or fail n checks in a row.
+	waitChecks := func(healthy bool, n int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			status := c.Status().Primary
+			if status.Healthy == healthy && status.Streak >= n {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("primary status is %+v, want %d checks with healthy %v", status, n, healthy)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if got := predictedBy(t, c); got != 1 {
+		t.Fatalf("predicted by %v, want the primary", got)
+	}
+
+	// application errors fail calls without switching them over.
+	primary.predict.Store(http.StatusInternalServerError)
+	_, err = c.Predict(context.Background(), "titanic_model", types.NewInput().AddFloats("x", 1))
+	var httpErr *jams.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Predict error = %v, want a 500", err)
+	}
+	waitChecks(true, 5)
+	select {
+	case s := <-switches:
+		t.Fatalf("switched from %s to %s on application errors", s.From, s.To)
+	default:
+	}
+	primary.predict.Store(0)
+
+	// an unavailable primary fails over to the standby.
+	primary.health.Store(http.StatusServiceUnavailable)
+	s := waitSwitch(primarySrv.URL, standbySrv.URL)
+	if !errors.As(s.Err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("switch error = %v, want a 503", s.Err)
+	}
+	if status := c.Status().Primary; status.Healthy || status.Streak < 2 {
+		t.Fatalf("primary status is %+v after failing over", status)
+	}
+	if got := predictedBy(t, c); got != 2 {
+		t.Fatalf("predicted by %v, want the standby", got)
+	}
+
+	// calls fail back once the primary passed RecoveryThreshold checks.
+	primary.health.Store(0)
+	s = waitSwitch(standbySrv.URL, primarySrv.URL)
+	if s.Err != nil {
+		t.Fatalf("switch back error = %v, want nil", s.Err)
+	}
+	if status := c.Status().Primary; !status.Healthy || status.Streak < 3 {
+		t.Fatalf("primary status is %+v after failing back", status)
+	}
+	if got := predictedBy(t, c); got != 1 {
+		t.Fatalf("predicted by %v, want the primary", got)
+	}
+}
+
+func TestFailoverUnhealthyStandby(t *testing.T) {
+	primary, standby := &fakeServer{id: 1}, &fakeServer{id: 2}
+	primarySrv, standbySrv := httptest.NewServer(primary), httptest.NewServer(standby)
+	defer primarySrv.Close()
+	defer standbySrv.Close()
+	primary.health.Store(http.StatusServiceUnavailable)
+	standby.health.Store(http.StatusServiceUnavailable)
+
+	var switched atomic.Int32
+	c, err := New(Config{
+		Primary:          primarySrv.URL,
+		Standby:          standbySrv.URL,
+		Interval:         5 * time.Millisecond,
+		FailureThreshold: 1,
+		OnSwitch:         func(Switch) { switched.Add(1) },
+	}, jams.WithRetry(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+
+	// calls stay on the primary while the standby is no better.
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Status().Standby.Streak < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("standby status is %+v", c.Status().Standby)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := switched.Load(); n != 0 {
+		t.Fatalf("switched %d times, want none", n)
+	}
+	if status := c.Status(); status.Active != primarySrv.URL {
+		t.Fatalf("active server is %s, want the primary", status.Active)
+	}
+}
@@ -0,0 +1,100 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers, and gRPC metadata keys, of the fault filter of Envoy, which meshes
+// such as Istio put in front of the servers.
+const (
+	faultDelayHeader     = "x-envoy-fault-delay-request"
+	faultAbortHeader     = "x-envoy-fault-abort-request"
+	faultAbortGRPCHeader = "x-envoy-fault-abort-grpc-request"
+)
+
+// environmentEnv is the environment variable marking the process as running
+// in production, like AppIdentity.Environment.
+const environmentEnv = "JAMS_ENVIRONMENT"
+
+// ErrFaultInProduction is returned by calls given a Fault by a client running
+// in production.
+var ErrFaultInProduction = errors.New("fault injection is disabled in production")
+
+// Fault is a fault for the proxy in front of the server to inject into a
+// call, e.g. to check in staging that an application degrades gracefully
+// when the server is slow or failing. Faults are sent as the headers of the
+// Envoy fault filter, which must be enabled with header faults on the route
+// of the server; other proxies and the server ignore them.
+type Fault struct {
+	// Delay is how long the proxy holds the call before forwarding it,
+	// rounded up to the millisecond.
+	Delay time.Duration
+	// Status is the HTTP status the proxy answers the call with instead of
+	// forwarding it, 0 for none. It also aborts gRPC calls, with the gRPC
+	// code matching the status, unless GRPCCode is set.
+	Status int
+	// GRPCCode is the gRPC status code the proxy answers gRPC calls with,
+	// e.g. uint32(codes.Unavailable), 0 for none.
+	GRPCCode uint32
+}
+
+type faultKey struct{}
+
+// WithFault returns a context whose calls carry f. Calls with a fault fail
+// with ErrFaultInProduction, without being sent, when the client runs in
+// production: its AppIdentity.Environment or the JAMS_ENVIRONMENT environment
+// variable is "production" or "prod". Injected aborts with a retryable status
+// are retried under WithRetry like genuine ones.
+//
+//	ctx = jams.WithFault(ctx, jams.Fault{Delay: 2 * time.Second})
+func WithFault(ctx context.Context, f Fault) context.Context {
+	return context.WithValue(ctx, faultKey{}, f)
+}
+
+// faultPairs returns the headers of the fault of ctx, as key value pairs, for
+// a call over HTTP or gRPC.
+func faultPairs(ctx context.Context, production, grpc bool) ([]string, error) {
+	f, ok := ctx.Value(faultKey{}).(Fault)
+	if !ok || f == (Fault{}) {
+		return nil, nil
+	}
+	if production {
+		return nil, ErrFaultInProduction
+	}
+	if f.Delay < 0 {
+		return nil, fmt.Errorf("invalid fault delay %s", f.Delay)
+	}
+	if f.Status != 0 && (f.Status < 200 || f.Status > 599) {
+		return nil, fmt.Errorf("invalid fault status %d", f.Status)
+	}
+
+	var kv []string
+	if f.Delay > 0 {
+		ms := (f.Delay + time.Millisecond - 1) / time.Millisecond
+		kv = append(kv, faultDelayHeader, strconv.FormatInt(int64(ms), 10))
+	}
+	switch {
+	case grpc && f.GRPCCode != 0:
+		kv = append(kv, faultAbortGRPCHeader, strconv.FormatUint(uint64(f.GRPCCode), 10))
+	case f.Status != 0:
+		kv = append(kv, faultAbortHeader, strconv.Itoa(f.Status))
+	}
+	return kv, nil
+}
+
+// production reports whether a client with the identity runs in production.
+func (id AppIdentity) production() bool {
+	for _, env := range []string{id.Environment, os.Getenv(environmentEnv)} {
+		switch strings.ToLower(strings.TrimSpace(env)) {
+		case "production", "prod":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+// Package catboost decodes the predictions of CatBoost models served by
+// J.A.M.S.
+//
+// The server returns CatBoost's raw formula values rather than probabilities:
+// a single log-odds value per record for binary classifiers, and one value per
+// class for multiclass classifiers, flattened so that every value is a row of
+// its own.
+//
+//	prediction, err := client.Predict(ctx, "catboost-titanic_model", input)
+//	classification, err := catboost.Binary(prediction)
+//	survived := classification.Classes(0.5)
+package catboost
+
+import (
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/frameworks"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Binary decodes the prediction of a binary classifier, e.g. one trained
+// with the Logloss loss function.
+func Binary(p *types.Prediction) (*frameworks.Classification, error) {
+	return frameworks.Objective{Task: frameworks.BinaryClassification, Link: frameworks.Logistic}.Classify(p)
+}
+
+// Multiclass decodes the prediction of a classifier with the given number of
+// classes, e.g. one trained with the MultiClass loss function.
+func Multiclass(p *types.Prediction, classes int) (*frameworks.Classification, error) {
+	return frameworks.Objective{Task: frameworks.MulticlassClassification, Classes: classes, Link: frameworks.SoftmaxLink}.Classify(p)
+}
+
+// Regression returns the predicted value of every record of a regressor,
+// whose raw formula values are the predictions themselves.
+func Regression(p *types.Prediction) ([]float64, error) {
+	return frameworks.Objective{Task: frameworks.Regression}.Regress(p)
+}
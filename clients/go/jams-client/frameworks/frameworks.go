@@ -0,0 +1,245 @@
+// Package frameworks holds what the framework specific decoders in its
+// subpackages share: the Objective describing how to decode predictions, the
+// Classification result and the link functions turning raw model scores into
+// probabilities.
+package frameworks
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Classification holds the class probabilities of every record, decoded from
+// a prediction by a framework specific decoder.
+type Classification struct {
+	// Raw holds the scores returned by the model for every record, before
+	// the link function was applied.
+	Raw [][]float64
+	// probabilities holds one probability per class for every record.
+	probabilities [][]float64
+}
+
+// NewBinary returns the classification of a binary classifier from the
+// probability of the positive class of every record.
+func NewBinary(raw [][]float64, positive []float64) *Classification {
+	probabilities := make([][]float64, len(positive))
+	for i, p := range positive {
+		probabilities[i] = []float64{1 - p, p}
+	}
+	return &Classification{Raw: raw, probabilities: probabilities}
+}
+
+// NewMulticlass returns the classification of a multiclass classifier from
+// the class probabilities of every record.
+func NewMulticlass(raw, probabilities [][]float64) *Classification {
+	return &Classification{Raw: raw, probabilities: probabilities}
+}
+
+// Len returns the number of records.
+func (c *Classification) Len() int {
+	return len(c.probabilities)
+}
+
+// Probabilities returns the probability of every class for every record,
+// i.e. [1-p, p] for binary classifiers, like scikit-learn's predict_proba.
+func (c *Classification) Probabilities() [][]float64 {
+	return c.probabilities
+}
+
+// Classes returns the predicted class of every record. Binary classifiers
+// predict class 1 when its probability is at least threshold, commonly 0.5.
+// Multiclass classifiers predict the most probable class, or -1 when its
+// probability is below threshold.
+func (c *Classification) Classes(threshold float64) []int {
+	classes := make([]int, len(c.probabilities))
+	for i, row := range c.probabilities {
+		if len(row) == 2 {
+			if row[1] >= threshold {
+				classes[i] = 1
+			}
+			continue
+		}
+		best := -1
+		for class, p := range row {
+			if best == -1 || p > row[best] {
+				best = class
+			}
+		}
+		if best != -1 && row[best] < threshold {
+			best = -1
+		}
+		classes[i] = best
+	}
+	return classes
+}
+
+// Sigmoid maps a log-odds score to a probability.
+func Sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// Softmax maps the scores of every class to probabilities summing to one.
+func Softmax(scores []float64) []float64 {
+	probabilities := make([]float64, len(scores))
+	if len(scores) == 0 {
+		return probabilities
+	}
+	highest := scores[0]
+	for _, s := range scores[1:] {
+		highest = math.Max(highest, s)
+	}
+	var sum float64
+	for i, s := range scores {
+		// subtracting the highest score avoids overflowing exp.
+		probabilities[i] = math.Exp(s - highest)
+		sum += probabilities[i]
+	}
+	for i := range probabilities {
+		probabilities[i] /= sum
+	}
+	return probabilities
+}
+
+// Task is the kind of problem a model solves.
+type Task int
+
+// Tasks.
+const (
+	Regression Task = iota
+	BinaryClassification
+	MulticlassClassification
+)
+
+// Link is the function mapping the values returned by the server to
+// predictions.
+type Link int
+
+// Links.
+const (
+	// Identity keeps the values, e.g. for regressors or models already
+	// returning probabilities.
+	Identity Link = iota
+	// Logistic applies Sigmoid to every value, e.g. to log-odds.
+	Logistic
+	// SoftmaxLink applies Softmax to the values of every record.
+	SoftmaxLink
+	// Exponential applies exp to every value, e.g. to the log of the expected
+	// count of a Poisson regressor.
+	Exponential
+	// ClassIndex reads a single value per record as the index of the
+	// predicted class, whose probability is then 1.
+	ClassIndex
+)
+
+// Objective describes how to decode the predictions of a model, independently
+// of its framework. The framework packages build it from their objective
+// names.
+type Objective struct {
+	Task Task
+	// Classes is the number of classes of multiclass classifiers.
+	Classes int
+	Link    Link
+}
+
+// Classify decodes the prediction of a classifier.
+func (o Objective) Classify(p *types.Prediction) (*Classification, error) {
+	values, err := Values(p)
+	if err != nil {
+		return nil, err
+	}
+	switch o.Task {
+	case BinaryClassification:
+		raw := make([][]float64, len(values))
+		positive := make([]float64, len(values))
+		for i, v := range values {
+			raw[i] = []float64{v}
+			positive[i] = o.apply(v)
+		}
+		return NewBinary(raw, positive), nil
+	case MulticlassClassification:
+		if o.Classes < 2 {
+			return nil, fmt.Errorf("a multiclass classifier has at least 2 classes, got %d", o.Classes)
+		}
+		width := o.Classes
+		if o.Link == ClassIndex {
+			width = 1
+		}
+		if len(values)%width != 0 {
+			return nil, fmt.Errorf("prediction has %d values, expected a multiple of %d classes", len(values), width)
+		}
+		raw := make([][]float64, len(values)/width)
+		probabilities := make([][]float64, len(raw))
+		for i := range raw {
+			raw[i] = values[i*width : (i+1)*width]
+			if probabilities[i], err = o.classProbabilities(raw[i]); err != nil {
+				return nil, fmt.Errorf("record %d: %w", i, err)
+			}
+		}
+		return NewMulticlass(raw, probabilities), nil
+	}
+	return nil, errors.New("objective is not a classification")
+}
+
+// Regress decodes the prediction of a regressor.
+func (o Objective) Regress(p *types.Prediction) ([]float64, error) {
+	if o.Task != Regression {
+		return nil, errors.New("objective is not a regression")
+	}
+	values, err := Values(p)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		values[i] = o.apply(v)
+	}
+	return values, nil
+}
+
+// apply applies an element wise link function.
+func (o Objective) apply(v float64) float64 {
+	switch o.Link {
+	case Logistic:
+		return Sigmoid(v)
+	case Exponential:
+		return math.Exp(v)
+	}
+	return v
+}
+
+func (o Objective) classProbabilities(raw []float64) ([]float64, error) {
+	switch o.Link {
+	case SoftmaxLink:
+		return Softmax(raw), nil
+	case ClassIndex:
+		class := int(raw[0])
+		if class < 0 || class >= o.Classes || float64(class) != raw[0] {
+			return nil, fmt.Errorf("invalid class index %v", raw[0])
+		}
+		probabilities := make([]float64, o.Classes)
+		probabilities[class] = 1
+		return probabilities, nil
+	}
+	probabilities := make([]float64, len(raw))
+	for i, v := range raw {
+		probabilities[i] = o.apply(v)
+	}
+	return probabilities, nil
+}
+
+// Values returns the values of the default output in order, whether the
+// server returned them one per row or one row per record. Multiclass models
+// of several frameworks return one row per class and record.
+func Values(p *types.Prediction) ([]float64, error) {
+	rows, ok := p.Output(types.DefaultOutput)
+	if !ok {
+		return nil, fmt.Errorf("prediction has no output %q", types.DefaultOutput)
+	}
+	values := make([]float64, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row...)
+	}
+	return values, nil
+}
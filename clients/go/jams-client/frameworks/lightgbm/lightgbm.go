@@ -0,0 +1,118 @@
+// Package lightgbm decodes the predictions of LightGBM models served by
+// J.A.M.S according to their objective.
+//
+// The server returns LightGBM's normal predictions: probabilities for binary
+// and multiclass classifiers, with one value per class flattened so that every
+// value is a row of its own, and values in target space for regressors. The
+// objective is read from the model file or given by name:
+//
+//	objective, err := lightgbm.LoadObjective("lightgbm_iris.txt")
+//	prediction, err := client.Predict(ctx, "lightgbm_iris", input)
+//	classification, err := objective.Classify(prediction)
+package lightgbm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/frameworks"
+)
+
+// ParseObjective returns the objective of a model trained with the given
+// LightGBM objective, e.g. "binary" or "multiclass num_class:3" as written to
+// model files. classes is the number of classes of multiclass models and may
+// be zero when the objective names it.
+//
+// rawScore is set for raw scores, as returned by LightGBM with
+// raw_score=True, in which case the objective's link function is applied.
+// J.A.M.S returns normal predictions.
+func ParseObjective(objective string, classes int, rawScore bool) (frameworks.Objective, error) {
+	fields := strings.Fields(objective)
+	if len(fields) == 0 {
+		return frameworks.Objective{}, errors.New("objective is required")
+	}
+	name := fields[0]
+	for _, field := range fields[1:] {
+		if value, ok := strings.CutPrefix(field, "num_class:"); ok && classes == 0 {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return frameworks.Objective{}, fmt.Errorf("invalid number of classes %q", value)
+			}
+			classes = n
+		}
+	}
+
+	link := func(l frameworks.Link) frameworks.Link {
+		if rawScore {
+			return l
+		}
+		return frameworks.Identity
+	}
+	switch name {
+	case "regression", "regression_l2", "l2", "mean_squared_error", "mse", "l2_root", "root_mean_squared_error", "rmse",
+		"regression_l1", "l1", "mean_absolute_error", "mae", "huber", "fair", "quantile", "mape", "mean_absolute_percentage_error",
+		"lambdarank", "rank_xendcg", "xendcg", "xe_ndcg", "xe_ndcg_mart", "xendcg_mart":
+		return frameworks.Objective{Task: frameworks.Regression}, nil
+	case "poisson", "gamma", "tweedie":
+		return frameworks.Objective{Task: frameworks.Regression, Link: link(frameworks.Exponential)}, nil
+	case "binary", "cross_entropy", "xentropy":
+		return frameworks.Objective{Task: frameworks.BinaryClassification, Link: link(frameworks.Logistic)}, nil
+	case "multiclass", "softmax":
+		return multiclass(classes, link(frameworks.SoftmaxLink))
+	case "multiclassova", "multiclass_ova", "ova", "ovr":
+		return multiclass(classes, link(frameworks.Logistic))
+	}
+	return frameworks.Objective{}, fmt.Errorf("unsupported LightGBM objective %q", name)
+}
+
+func multiclass(classes int, link frameworks.Link) (frameworks.Objective, error) {
+	if classes < 2 {
+		return frameworks.Objective{}, fmt.Errorf("multiclass objectives need the number of classes, got %d", classes)
+	}
+	return frameworks.Objective{Task: frameworks.MulticlassClassification, Classes: classes, Link: link}, nil
+}
+
+// LoadObjective reads the objective of a model from its text model file, as
+// saved by Booster.save_model, for normal predictions.
+func LoadObjective(path string) (frameworks.Objective, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return frameworks.Objective{}, err
+	}
+	defer f.Close()
+
+	var objective string
+	var classes int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "objective="); ok {
+			objective = value
+		}
+		if value, ok := strings.CutPrefix(line, "num_class="); ok {
+			if classes, err = strconv.Atoi(value); err != nil {
+				return frameworks.Objective{}, fmt.Errorf("invalid num_class %q", value)
+			}
+		}
+		// the header ends before the first tree.
+		if strings.HasPrefix(line, "Tree=") {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return frameworks.Objective{}, err
+	}
+	if objective == "" {
+		return frameworks.Objective{}, fmt.Errorf("%s has no objective, is it a LightGBM text model?", path)
+	}
+	if classes == 1 {
+		// binary and regression models have a single class.
+		classes = 0
+	}
+	return ParseObjective(objective, classes, false)
+}
@@ -0,0 +1,104 @@
+// Package xgboost decodes the predictions of XGBoost models according to
+// their objective, following the conventions of XGBoost's predict: class
+// probabilities, or the predicted class for multi:softmax, with one value per
+// class flattened so that every value is a row of its own.
+//
+// The J.A.M.S server registers the xgboost framework but does not serve its
+// predictions yet; the decoders are ready for when it does.
+//
+//	objective, err := xgboost.LoadObjective("xgboost_iris.json")
+//	prediction, err := client.Predict(ctx, "xgboost_iris", input)
+//	classification, err := objective.Classify(prediction)
+package xgboost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/frameworks"
+)
+
+// ParseObjective returns the objective of a model trained with the named
+// XGBoost objective, e.g. "binary:logistic". classes is the number of classes
+// of multiclass models.
+//
+// outputMargin is set for untransformed margins, as returned by XGBoost with
+// output_margin=True, in which case the objective's link function is applied.
+func ParseObjective(name string, classes int, outputMargin bool) (frameworks.Objective, error) {
+	link := func(l frameworks.Link) frameworks.Link {
+		if outputMargin {
+			return l
+		}
+		return frameworks.Identity
+	}
+	switch name {
+	case "reg:squarederror", "reg:squaredlogerror", "reg:pseudohubererror", "reg:absoluteerror", "reg:quantileerror",
+		"reg:linear", "rank:pairwise", "rank:ndcg", "rank:map":
+		return frameworks.Objective{Task: frameworks.Regression}, nil
+	case "reg:logistic":
+		return frameworks.Objective{Task: frameworks.Regression, Link: link(frameworks.Logistic)}, nil
+	case "count:poisson", "reg:gamma", "reg:tweedie", "survival:cox":
+		return frameworks.Objective{Task: frameworks.Regression, Link: link(frameworks.Exponential)}, nil
+	case "binary:logistic":
+		return frameworks.Objective{Task: frameworks.BinaryClassification, Link: link(frameworks.Logistic)}, nil
+	case "binary:logitraw":
+		// logitraw always predicts log-odds.
+		return frameworks.Objective{Task: frameworks.BinaryClassification, Link: frameworks.Logistic}, nil
+	case "binary:hinge":
+		// hinge predicts 0 or 1, which are the probabilities of class 1.
+		return frameworks.Objective{Task: frameworks.BinaryClassification}, nil
+	case "multi:softprob":
+		return multiclass(classes, link(frameworks.SoftmaxLink))
+	case "multi:softmax":
+		if outputMargin {
+			return multiclass(classes, frameworks.SoftmaxLink)
+		}
+		return multiclass(classes, frameworks.ClassIndex)
+	}
+	return frameworks.Objective{}, fmt.Errorf("unsupported XGBoost objective %q", name)
+}
+
+func multiclass(classes int, link frameworks.Link) (frameworks.Objective, error) {
+	if classes < 2 {
+		return frameworks.Objective{}, fmt.Errorf("multiclass objectives need the number of classes, got %d", classes)
+	}
+	return frameworks.Objective{Task: frameworks.MulticlassClassification, Classes: classes, Link: link}, nil
+}
+
+// jsonModel holds the fields of a JSON model file describing its objective.
+type jsonModel struct {
+	Learner struct {
+		Objective struct {
+			Name string `json:"name"`
+		} `json:"objective"`
+		LearnerModelParam struct {
+			NumClass string `json:"num_class"`
+		} `json:"learner_model_param"`
+	} `json:"learner"`
+}
+
+// LoadObjective reads the objective of a model from its JSON model file, as
+// saved by Booster.save_model with a .json extension.
+func LoadObjective(path string) (frameworks.Objective, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return frameworks.Objective{}, err
+	}
+	var model jsonModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return frameworks.Objective{}, fmt.Errorf("failed to parse XGBoost model: %w", err)
+	}
+	if model.Learner.Objective.Name == "" {
+		return frameworks.Objective{}, errors.New("model has no objective, is it an XGBoost JSON model?")
+	}
+	var classes int
+	if n := model.Learner.LearnerModelParam.NumClass; n != "" {
+		if classes, err = strconv.Atoi(n); err != nil {
+			return frameworks.Objective{}, fmt.Errorf("invalid num_class %q", n)
+		}
+	}
+	return ParseObjective(model.Learner.Objective.Name, classes, false)
+}
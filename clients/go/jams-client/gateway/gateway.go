@@ -0,0 +1,222 @@
+// Package gateway serves the J.A.M.S REST API on top of a client, so that
+// HTTP-only consumers can reach gRPC-only deployments through a sidecar:
+//
+//	client, err := jams.NewGRPCClient("jams:4000")
+//	http.ListenAndServe(":3000", gateway.New(client))
+//
+// Requests and responses follow the server: failed predictions answer with an
+// empty output. Status codes map the gRPC status of failures, e.g.
+// InvalidArgument to 400 and Unavailable to 503, where the server answers 500.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Client is the client requests are forwarded to. *jams.Client implements it.
+type Client interface {
+	HealthCheck(ctx context.Context) error
+	Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error)
+	GetModels(ctx context.Context) ([]jams.ModelMetadata, error)
+	AddModel(ctx context.Context, modelName string) error
+	UpdateModel(ctx context.Context, modelName string) error
+	DeleteModel(ctx context.Context, modelName string) error
+}
+
+// Option configures the gateway.
+type Option func(*gateway)
+
+// WithLogger sets the logger failed requests are reported to.
+func WithLogger(logger *slog.Logger) Option {
+	return func(g *gateway) {
+		g.logger = logger
+	}
+}
+
+type gateway struct {
+	client Client
+	logger *slog.Logger
+}
+
+type modelRequest struct {
+	ModelName string `json:"model_name"`
+}
+
+type predictRequest struct {
+	ModelName string `json:"model_name"`
+	Input     string `json:"input"`
+}
+
+type predictResponse struct {
+	Output string `json:"output"`
+}
+
+type getModelsResponse struct {
+	Total  int                  `json:"total"`
+	Models []jams.ModelMetadata `json:"models"`
+}
+
+// New returns a handler serving the REST API by forwarding to client.
+func New(client Client, opts ...Option) http.Handler {
+	g := &gateway{client: client}
+	for _, opt := range opts {
+		opt(g)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthcheck", g.healthCheck)
+	mux.HandleFunc("POST /api/predict", g.predict)
+	mux.HandleFunc("GET /api/models", g.getModels)
+	mux.HandleFunc("POST /api/models", g.addModel)
+	mux.HandleFunc("PUT /api/models", g.updateModel)
+	mux.HandleFunc("DELETE /api/models", g.deleteModel)
+	return mux
+}
+
+func (g *gateway) healthCheck(w http.ResponseWriter, r *http.Request) {
+	if err := g.client.HealthCheck(r.Context()); err != nil {
+		g.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (g *gateway) predict(w http.ResponseWriter, r *http.Request) {
+	var req predictRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		g.failJSON(w, r, errBadRequest{err}, predictResponse{})
+		return
+	}
+	input, err := types.ParseInput([]byte(req.Input))
+	if err != nil {
+		g.failJSON(w, r, errBadRequest{err}, predictResponse{})
+		return
+	}
+	prediction, err := g.client.Predict(r.Context(), req.ModelName, input)
+	if err != nil {
+		g.failJSON(w, r, err, predictResponse{})
+		return
+	}
+	output, err := json.Marshal(prediction.Outputs)
+	if err != nil {
+		g.failJSON(w, r, err, predictResponse{})
+		return
+	}
+	writeJSON(w, http.StatusOK, predictResponse{Output: string(output)})
+}
+
+func (g *gateway) getModels(w http.ResponseWriter, r *http.Request) {
+	models, err := g.client.GetModels(r.Context())
+	if err != nil {
+		g.failJSON(w, r, err, getModelsResponse{Models: []jams.ModelMetadata{}})
+		return
+	}
+	if models == nil {
+		models = []jams.ModelMetadata{}
+	}
+	writeJSON(w, http.StatusOK, getModelsResponse{Total: len(models), Models: models})
+}
+
+func (g *gateway) addModel(w http.ResponseWriter, r *http.Request) {
+	g.modelCall(w, r, g.client.AddModel)
+}
+
+func (g *gateway) updateModel(w http.ResponseWriter, r *http.Request) {
+	g.modelCall(w, r, g.client.UpdateModel)
+}
+
+// modelCall forwards a request whose body names a model.
+func (g *gateway) modelCall(w http.ResponseWriter, r *http.Request, call func(ctx context.Context, modelName string) error) {
+	var req modelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		g.fail(w, r, errBadRequest{err})
+		return
+	}
+	if err := call(r.Context(), req.ModelName); err != nil {
+		g.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (g *gateway) deleteModel(w http.ResponseWriter, r *http.Request) {
+	if err := g.client.DeleteModel(r.Context(), r.URL.Query().Get("model_name")); err != nil {
+		g.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// errBadRequest is a request the gateway cannot decode.
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return "invalid request: " + e.err.Error()
+}
+
+func (g *gateway) fail(w http.ResponseWriter, r *http.Request, err error) {
+	g.log(r, err)
+	w.WriteHeader(statusCode(err))
+}
+
+func (g *gateway) failJSON(w http.ResponseWriter, r *http.Request, err error, body any) {
+	g.log(r, err)
+	writeJSON(w, statusCode(err), body)
+}
+
+func (g *gateway) log(r *http.Request, err error) {
+	if g.logger != nil {
+		g.logger.Error("jams gateway request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+}
+
+// statusCode maps an error to the status code of the response.
+func statusCode(err error) int {
+	var httpErr *jams.HTTPError
+	switch {
+	case errors.As(err, new(errBadRequest)):
+		return http.StatusBadRequest
+	case errors.Is(err, jams.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -1 +1,341 @@
+//go:build !jams_nogrpc && !js
+
 package jams_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	// registers the weighted_round_robin balancer.
+	_ "google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/gagansingh894/jams-rs/clients/go/jams-client/pkg/pb/jams"
+)
+
+// grpcBuilt reports whether the gRPC transport is part of the build.
+const grpcBuilt = true
+
+// grpcOptions are the options of the gRPC transport which depend on gRPC.
+type grpcOptions struct {
+	dialOptions []grpc.DialOption
+}
+
+// WithDialOptions appends gRPC dial options used by the gRPC transport.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+type grpcTransport struct {
+	// target and dialOptions dial the replacements of evicted channels.
+	target      string
+	dialOptions []grpc.DialOption
+	health      *ChannelHealth
+	maxAge      time.Duration
+	logger      *slog.Logger
+	degraded    *degradedReporter
+
+	mu       sync.RWMutex
+	channels []*grpcChannel
+	// evictions counts the channels evicted from each slot of the pool.
+	evictions []int
+	// next rotates the channel the least-loaded search starts from, spreading
+	// calls evenly between idle channels.
+	next atomic.Uint32
+}
+
+// NewGRPCClient returns a Client which talks to the J.A.M.S gRPC API at target,
+// e.g. "localhost:4000". Connections are insecure unless transport credentials
+// are passed with WithDialOptions.
+//
+// When WithBackoff is given, re-dials use the same timing: ExponentialBackoff
+// and ConstantBackoff map directly onto gRPC's connect backoff, while
+// DecorrelatedJitterBackoff is approximated by an exponential backoff with
+// the same bounds. Other Backoff implementations leave gRPC's default in place.
+//
+// A single connection caps the number of concurrent calls to the streams the
+// server allows on it; WithGRPCChannels opens a pool of connections instead.
+//
+// gRPC resolves the target itself and dials each resolved address in turn, so
+// WithIPPreference only skips addresses of the unwanted family and
+// WithFallbackDelay has no effect on targets resolved to IP addresses.
+func NewGRPCClient(target string, opts ...Option) (*Client, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.appIdentity.validate(); err != nil {
+		return nil, err
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(clientInterceptor(o.appIdentity), warningInterceptor),
+		grpc.WithStatsHandler(usageStatsHandler{}),
+		grpc.WithStatsHandler(stageStatsHandler{}),
+		grpc.WithUserAgent(o.appIdentity.userAgent()),
+	}
+	if params, ok := connectParams(o); ok {
+		dialOpts = append(dialOpts, grpc.WithConnectParams(params))
+	}
+	if o.maxConnIdle > 0 {
+		dialOpts = append(dialOpts, grpc.WithIdleTimeout(o.maxConnIdle))
+	}
+	if o.bearerToken != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(bearerCredentials(o.bearerToken)))
+	}
+	if o.customDialer {
+		dialOpts = append(dialOpts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return o.dialContext(ctx, "tcp", addr)
+		}))
+	}
+	if o.loadReports != nil {
+		dialOpts = append(dialOpts, o.loadReports.serviceConfig())
+	}
+	dialOpts = append(dialOpts, o.dialOptions...)
+
+	t := &grpcTransport{target: target, dialOptions: dialOpts, health: o.channelHealth, maxAge: o.maxConnAge, logger: o.logger, degraded: o.degraded}
+	for i := 0; i < max(o.grpcChannels, 1); i++ {
+		ch, err := t.dial()
+		if err != nil {
+			t.close()
+			return nil, err
+		}
+		t.channels = append(t.channels, ch)
+	}
+	t.evictions = make([]int, len(t.channels))
+	return newClient(t, o), nil
+}
+
+func (t *grpcTransport) healthCheck(ctx context.Context) error {
+	client, done := t.channel()
+	_, err := client.HealthCheck(ctx, &emptypb.Empty{})
+	done(err)
+	return err
+}
+
+func (t *grpcTransport) predict(ctx context.Context, modelName, input string) (string, error) {
+	client, done := t.channel()
+	resp, err := client.Predict(ctx, &pb.PredictRequest{ModelName: modelName, Input: input})
+	done(err)
+	if err != nil {
+		return "", err
+	}
+	return resp.GetOutput(), nil
+}
+
+func (t *grpcTransport) getModels(ctx context.Context) ([]ModelMetadata, error) {
+	client, done := t.channel()
+	resp, err := client.GetModels(ctx, &emptypb.Empty{})
+	done(err)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]ModelMetadata, 0, len(resp.GetModels()))
+	for _, m := range resp.GetModels() {
+		models = append(models, ModelMetadata{
+			Name:        m.GetName(),
+			Framework:   m.GetFramework(),
+			Path:        m.GetPath(),
+			LastUpdated: m.GetLastUpdated(),
+		})
+	}
+	return models, nil
+}
+
+func (t *grpcTransport) addModel(ctx context.Context, modelName string) error {
+	client, done := t.channel()
+	_, err := client.AddModel(ctx, &pb.AddModelRequest{ModelName: modelName})
+	done(err)
+	return err
+}
+
+func (t *grpcTransport) updateModel(ctx context.Context, modelName string) error {
+	client, done := t.channel()
+	_, err := client.UpdateModel(ctx, &pb.UpdateModelRequest{ModelName: modelName})
+	done(err)
+	return err
+}
+
+func (t *grpcTransport) deleteModel(ctx context.Context, modelName string) error {
+	client, done := t.channel()
+	_, err := client.DeleteModel(ctx, &pb.DeleteModelRequest{ModelName: modelName})
+	done(err)
+	return err
+}
+
+func (t *grpcTransport) retryable(err error) (bool, time.Duration) {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true, 0
+	}
+	return false, 0
+}
+
+func (t *grpcTransport) name() string {
+	return "grpc"
+}
+
+func (t *grpcTransport) features() []Feature {
+	return nil
+}
+
+func (t *grpcTransport) close() error {
+	var errs []error
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, ch := range t.channels {
+		errs = append(errs, ch.close())
+	}
+	return errors.Join(errs...)
+}
+
+// warningInterceptor collects the warnings sent in the response headers and
+// trailers of a call.
+func warningInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var header, trailer metadata.MD
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header), grpc.Trailer(&trailer))...)
+	collectWarnings(ctx, func(key string) []string {
+		return append(header.Get(key), trailer.Get(key)...)
+	})
+	return err
+}
+
+// defaultMinConnectTimeout is the minimum connect timeout gRPC uses when it
+// is given no ConnectParams, which have no way of leaving it unset.
+const defaultMinConnectTimeout = 20 * time.Second
+
+// connectParams returns the connect parameters of the Backoff and the Dial
+// timeout of o, if either is set.
+func connectParams(o *options) (grpc.ConnectParams, bool) {
+	config, ok := connectBackoff(o)
+	if !ok && o.timeouts.Dial <= 0 {
+		return grpc.ConnectParams{}, false
+	}
+	if !ok {
+		config = backoff.DefaultConfig
+	}
+	// gRPC gives each connect attempt the longest of the minimum connect
+	// timeout and the backoff delay, so a zero minimum would cut attempts
+	// short to the delay
+	params := grpc.ConnectParams{Backoff: config, MinConnectTimeout: defaultMinConnectTimeout}
+	if o.timeouts.Dial > 0 {
+		params.MinConnectTimeout = o.timeouts.Dial
+	}
+	return params, true
+}
+
+// connectBackoff translates the Backoff given with WithBackoff into gRPC's
+// connect backoff configuration. Zero delays and multipliers are those of
+// backoff.DefaultConfig, as gRPC would otherwise re-dial without waiting.
+func connectBackoff(o *options) (backoff.Config, bool) {
+	if !o.backoffSet {
+		return backoff.Config{}, false
+	}
+	var config backoff.Config
+	switch b := o.backoff.(type) {
+	case ExponentialBackoff:
+		config = backoff.Config{BaseDelay: b.Base, Multiplier: b.Multiplier, Jitter: b.Jitter, MaxDelay: b.Max}
+	case ConstantBackoff:
+		config = backoff.Config{BaseDelay: b.Interval, Multiplier: 1, MaxDelay: b.Interval}
+	case DecorrelatedJitterBackoff:
+		config = backoff.Config{BaseDelay: b.Base, Multiplier: 2, Jitter: 0.5, MaxDelay: b.Max}
+	default:
+		return backoff.Config{}, false
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = backoff.DefaultConfig.BaseDelay
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = max(backoff.DefaultConfig.MaxDelay, config.BaseDelay)
+	}
+	if config.Multiplier <= 0 {
+		config.Multiplier = backoff.DefaultConfig.Multiplier
+	}
+	return config, true
+}
+
+// bearerCredentials sends a bearer token in the metadata of every call. It is
+// allowed over insecure connections, which the server uses by default.
+type bearerCredentials string
+
+func (c bearerCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(c)}, nil
+}
+
+func (c bearerCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// clientInterceptor sends the client version and the identity of the
+// application in the metadata of every call, with its Fault if any. The
+// User-Agent is set with grpc.WithUserAgent.
+func clientInterceptor(identity AppIdentity) grpc.UnaryClientInterceptor {
+	kv := append([]string{clientHeader, "go/" + Version}, identity.pairs()...)
+	production := identity.production()
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		fault, err := faultPairs(ctx, production, true)
+		if err != nil {
+			return err
+		}
+		pairs := append(hookMetadata(ctx), kv...)
+		if key := idempotencyKeyFrom(ctx); key != "" {
+			pairs = append(pairs, idempotencyKeyHeader, key)
+		}
+		pairs = append(pairs, fault...)
+		ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// grpcCanceled reports whether err is the gRPC error of a cancelled call.
+func grpcCanceled(err error) bool {
+	switch status.Code(err) {
+	case codes.Canceled, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// serviceConfig returns the gRPC service config selecting the
+// weighted_round_robin balancer.
+func (r LoadReports) serviceConfig() grpc.DialOption {
+	type config struct {
+		EnableOOBLoadReport     bool    `json:"enableOobLoadReport,omitempty"`
+		OOBReportingPeriod      string  `json:"oobReportingPeriod,omitempty"`
+		BlackoutPeriod          string  `json:"blackoutPeriod,omitempty"`
+		ErrorUtilizationPenalty float64 `json:"errorUtilizationPenalty,omitempty"`
+	}
+	duration := func(d time.Duration) string {
+		if d <= 0 {
+			return ""
+		}
+		return fmt.Sprintf("%.9fs", d.Seconds())
+	}
+	sc, _ := json.Marshal(map[string]any{
+		"loadBalancingConfig": []map[string]config{{
+			"weighted_round_robin": {
+				EnableOOBLoadReport:     r.OutOfBand,
+				OOBReportingPeriod:      duration(r.Period),
+				BlackoutPeriod:          duration(r.Blackout),
+				ErrorUtilizationPenalty: r.ErrorPenalty,
+			},
+		}},
+	})
+	return grpc.WithDefaultServiceConfig(string(sc))
+}
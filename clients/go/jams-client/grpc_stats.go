@@ -0,0 +1,70 @@
+//go:build !jams_nogrpc && !js
+
+package jams_client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/stats"
+)
+
+// stageStatsHandler times the network, server and receive stages of gRPC
+// calls made with a stage timer in their context.
+type stageStatsHandler struct{}
+
+func (stageStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (stageStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	timer := stageTimerFrom(ctx)
+	if timer == nil {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.Begin:
+		timer.enter(StageNetwork, s.BeginTime)
+	case *stats.OutPayload:
+		timer.enter(StageServer, s.SentTime)
+	case *stats.InHeader:
+		timer.enter(StageReceive, time.Now())
+	case *stats.End:
+		timer.enter("", s.EndTime)
+	}
+}
+
+func (stageStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (stageStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+// usageStatsHandler reports gRPC payload sizes to the usage collector of the
+// call context.
+type usageStatsHandler struct{}
+
+func (usageStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (usageStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	collector := usageFrom(ctx)
+	if collector == nil {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.OutPayload:
+		collector.requestBytes.Add(int64(s.Length))
+		collector.requestWireBytes.Add(int64(s.WireLength))
+	case *stats.InPayload:
+		collector.responseBytes.Add(int64(s.Length))
+		collector.responseWireBytes.Add(int64(s.WireLength))
+	}
+}
+
+func (usageStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (usageStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
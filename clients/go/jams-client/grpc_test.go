@@ -0,0 +1,81 @@
+//go:build !jams_nogrpc && !js
+
+package jams_client
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/backoff"
+)
+
+func TestConnectBackoff(t *testing.T) {
+	defaults := backoff.DefaultConfig
+	tests := []struct {
+		name    string
+		backoff Backoff
+		want    backoff.Config
+	}{
+		{
+			name:    "exponential",
+			backoff: ExponentialBackoff{Base: time.Second, Max: time.Minute, Multiplier: 3, Jitter: 0.1},
+			want:    backoff.Config{BaseDelay: time.Second, Multiplier: 3, Jitter: 0.1, MaxDelay: time.Minute},
+		},
+		{
+			name:    "zero exponential",
+			backoff: ExponentialBackoff{},
+			want:    backoff.Config{BaseDelay: defaults.BaseDelay, Multiplier: defaults.Multiplier, MaxDelay: defaults.MaxDelay},
+		},
+		{
+			name:    "zero constant",
+			backoff: ConstantBackoff{},
+			want:    backoff.Config{BaseDelay: defaults.BaseDelay, Multiplier: 1, MaxDelay: defaults.MaxDelay},
+		},
+		{
+			name:    "zero decorrelated jitter",
+			backoff: DecorrelatedJitterBackoff{},
+			want:    backoff.Config{BaseDelay: defaults.BaseDelay, Multiplier: 2, Jitter: 0.5, MaxDelay: defaults.MaxDelay},
+		},
+		{
+			name:    "base above the default max",
+			backoff: DecorrelatedJitterBackoff{Base: 5 * time.Minute},
+			want:    backoff.Config{BaseDelay: 5 * time.Minute, Multiplier: 2, Jitter: 0.5, MaxDelay: 5 * time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := defaultOptions()
+			WithBackoff(tt.backoff)(o)
+			config, ok := connectBackoff(o)
+			if !ok || config != tt.want {
+				t.Fatalf("got %+v, want %+v", config, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantSet     bool
+		wantTimeout time.Duration
+	}{
+		{name: "defaults"},
+		{name: "backoff only", opts: []Option{WithBackoff(DefaultBackoff)}, wantSet: true, wantTimeout: defaultMinConnectTimeout},
+		{name: "dial timeout only", opts: []Option{WithTimeouts(Timeouts{Dial: 3 * time.Second})}, wantSet: true, wantTimeout: 3 * time.Second},
+		{name: "both", opts: []Option{WithBackoff(DefaultBackoff), WithTimeouts(Timeouts{Dial: 3 * time.Second})}, wantSet: true, wantTimeout: 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := defaultOptions()
+			for _, opt := range tt.opts {
+				opt(o)
+			}
+			params, ok := connectParams(o)
+			if ok != tt.wantSet || params.MinConnectTimeout != tt.wantTimeout {
+				t.Fatalf("got %+v (set %v), want a minimum connect timeout of %s (set %v)", params, ok, tt.wantTimeout, tt.wantSet)
+			}
+		})
+	}
+}
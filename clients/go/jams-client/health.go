@@ -0,0 +1,54 @@
+package jams_client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EndpointHealth is the health of a server probed by HealthCheckAll.
+type EndpointHealth struct {
+	// Endpoint is the probed endpoint, as given to HealthCheckAll.
+	Endpoint string
+	Healthy  bool
+	// Latency is the duration of the health check, including retries.
+	Latency time.Duration
+	// Err is the reason the server is unhealthy, nil when healthy.
+	Err error
+}
+
+// HealthCheckAll probes the servers at endpoints concurrently and returns
+// their health in the order of endpoints, e.g. for fleet dashboards or to
+// verify a deployment before shifting traffic to it. Endpoints are probed
+// over HTTP or gRPC as chosen by NewClient. opts apply to the client of every
+// endpoint, e.g. WithTimeouts to bound slow servers; each client is closed
+// once probed.
+func HealthCheckAll(ctx context.Context, endpoints []string, opts ...Option) []EndpointHealth {
+	report := make([]EndpointHealth, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report[i] = healthCheck(ctx, endpoint, opts)
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func healthCheck(ctx context.Context, endpoint string, opts []Option) EndpointHealth {
+	health := EndpointHealth{Endpoint: endpoint}
+	client, err := NewClient(endpoint, opts...)
+	if err != nil {
+		health.Err = err
+		return health
+	}
+	defer client.Close()
+
+	start := time.Now()
+	health.Err = client.HealthCheck(ctx)
+	health.Latency = time.Since(start)
+	health.Healthy = health.Err == nil
+	return health
+}
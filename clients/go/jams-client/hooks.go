@@ -0,0 +1,118 @@
+package jams_client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CallInfo identifies an attempt of a call in Hooks.
+type CallInfo struct {
+	Method string
+	// Model is empty for calls which do not target a model.
+	Model string
+	// Attempt is 1 for the first attempt and grows with the retries of
+	// WithRetry.
+	Attempt int
+}
+
+// Hooks are callbacks run around the attempts of every call, e.g. to add
+// headers, sample calls or raise alerts. For every attempt, hooks run in this
+// order:
+//
+//  1. OnRequest, before the attempt is sent;
+//  2. OnResponse if the attempt succeeded, OnError if it failed;
+//  3. OnRetry, if the attempt failed and is retried, before the backoff.
+//
+// Calls which fail before anything is sent, e.g. on an invalid input or a
+// quota, run no hook, nor do predictions served from the prediction cache.
+// Hooks run synchronously, in the goroutine of the call, and must not block.
+// Nil hooks are skipped.
+type Hooks struct {
+	// OnRequest may add headers to header, sent as HTTP headers or gRPC
+	// metadata with the attempt. Headers set by the client itself, such as
+	// User-Agent and Authorization, take precedence.
+	OnRequest func(ctx context.Context, call CallInfo, header http.Header)
+	// OnResponse is given the duration of the attempt.
+	OnResponse func(ctx context.Context, call CallInfo, elapsed time.Duration)
+	// OnError is given the duration and error of the attempt.
+	OnError func(ctx context.Context, call CallInfo, elapsed time.Duration, err error)
+	// OnRetry is given the delay before the next attempt and the error of
+	// the attempt retried.
+	OnRetry func(ctx context.Context, call CallInfo, delay time.Duration, err error)
+}
+
+// WithHooks adds lifecycle hooks to every call. Hooks added by several
+// WithHooks run in the order they were added.
+func WithHooks(h Hooks) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, h)
+	}
+}
+
+type requestHeaderKey struct{}
+
+// requestHeaderFrom returns the headers added by OnRequest hooks to the
+// attempt made with ctx, if any.
+func requestHeaderFrom(ctx context.Context) http.Header {
+	header, _ := ctx.Value(requestHeaderKey{}).(http.Header)
+	return header
+}
+
+// beforeAttempt runs the OnRequest hooks and returns the context of the
+// attempt, carrying the headers they added.
+func (c *Client) beforeAttempt(ctx context.Context, call CallInfo) context.Context {
+	header := make(http.Header)
+	for _, h := range c.opts.hooks {
+		if h.OnRequest != nil {
+			h.OnRequest(ctx, call, header)
+		}
+	}
+	if len(header) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, requestHeaderKey{}, header)
+}
+
+// afterAttempt runs the OnResponse or OnError hooks.
+func (c *Client) afterAttempt(ctx context.Context, call CallInfo, elapsed time.Duration, err error) {
+	for _, h := range c.opts.hooks {
+		switch {
+		case err == nil && h.OnResponse != nil:
+			h.OnResponse(ctx, call, elapsed)
+		case err != nil && h.OnError != nil:
+			h.OnError(ctx, call, elapsed, err)
+		}
+	}
+}
+
+func (c *Client) beforeRetry(ctx context.Context, call CallInfo, delay time.Duration, err error) {
+	for _, h := range c.opts.hooks {
+		if h.OnRetry != nil {
+			h.OnRetry(ctx, call, delay, err)
+		}
+	}
+}
+
+// hookMetadata returns the headers added by OnRequest hooks to the attempt
+// made with ctx as gRPC metadata key value pairs, without the keys set by
+// the client or reserved by gRPC, as metadata keys take several values
+// rather than being replaced.
+func hookMetadata(ctx context.Context) []string {
+	var kv []string
+	for key, values := range requestHeaderFrom(ctx) {
+		key = strings.ToLower(key)
+		switch key {
+		case "user-agent", "authorization", "content-type", clientHeader, idempotencyKeyHeader, appNameHeader, appVersionHeader, appEnvironmentHeader:
+			continue
+		}
+		if strings.HasPrefix(key, "grpc-") {
+			continue
+		}
+		for _, v := range values {
+			kv = append(kv, key, v)
+		}
+	}
+	return kv
+}
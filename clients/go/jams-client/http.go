@@ -1 +1,369 @@
 package jams_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	healthCheckPath = "/healthcheck"
+	predictPath     = "/api/predict"
+	modelsPath      = "/api/models"
+)
+
+type httpTransport struct {
+	baseURL     string
+	client      *http.Client
+	bearerToken string
+	identity    AppIdentity
+	userAgent   string
+	// production refuses calls with a Fault.
+	production bool
+	// conns tracks the connections of the transport when they are recycled,
+	// and stop stops their recycling.
+	conns *connTracker
+	stop  func()
+	// codecs decode responses by their Content-Encoding, all accepted in
+	// acceptEncoding.
+	codecs           map[string]Codec
+	acceptEncoding   string
+	compression      compressionRule
+	modelCompression map[string]compressionRule
+	// uncompressed is set once the server rejected a compressed request.
+	uncompressed atomic.Bool
+}
+
+type modelRequest struct {
+	ModelName string `json:"model_name"`
+}
+
+type predictRequest struct {
+	ModelName string `json:"model_name"`
+	Input     string `json:"input"`
+}
+
+type predictResponse struct {
+	Output string `json:"output"`
+}
+
+type getModelsResponse struct {
+	Total  int             `json:"total"`
+	Models []ModelMetadata `json:"models"`
+}
+
+// NewHTTPClient returns a Client which talks to the J.A.M.S HTTP API at
+// baseURL, e.g. "http://localhost:3000".
+func NewHTTPClient(baseURL string, opts ...Option) (*Client, error) {
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.appIdentity.validate(); err != nil {
+		return nil, err
+	}
+
+	client := o.httpClient
+	if o.customDialer && !fetchTransport {
+		client = withDialer(client, o)
+	}
+	t := &httpTransport{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		bearerToken: o.bearerToken,
+		identity:    o.appIdentity,
+		userAgent:   o.appIdentity.userAgent(),
+		production:  o.appIdentity.production(),
+		stop:        func() {},
+
+		codecs:           make(map[string]Codec, len(o.codecs)),
+		compression:      o.compression,
+		modelCompression: o.modelCompression,
+	}
+	names := make([]string, len(o.codecs))
+	for i, codec := range o.codecs {
+		t.codecs[codec.Name()] = codec
+		names[i] = codec.Name()
+	}
+	t.acceptEncoding = strings.Join(names, ", ")
+	host, refresh := refreshedHost(baseURL)
+	refresh = refresh && o.dnsRefresh > 0
+	if !fetchTransport && (refresh || o.maxConnAge > 0 || o.maxConnIdle > 0 || o.timeouts.Dial > 0) {
+		if c, transport := cloneTransport(client); transport != nil {
+			client = c
+			if o.maxConnIdle > 0 {
+				transport.IdleConnTimeout = o.maxConnIdle
+			}
+			if o.timeouts.Dial > 0 {
+				withDialTimeout(transport, o.timeouts.Dial)
+			}
+			if refresh || o.maxConnAge > 0 {
+				t.conns = withConnTracking(transport, o.maxConnAge)
+			}
+			if refresh {
+				stop := make(chan struct{})
+				t.stop = sync.OnceFunc(func() { close(stop) })
+				go t.conns.refreshDNS(host, o.dnsRefresh, o.logger, o.degraded, stop)
+			}
+		}
+	}
+	t.client = client
+	return newClient(t, o), nil
+}
+
+func (t *httpTransport) healthCheck(ctx context.Context) error {
+	return t.do(ctx, http.MethodGet, healthCheckPath, nil, nil)
+}
+
+func (t *httpTransport) predict(ctx context.Context, modelName, input string) (string, error) {
+	var resp predictResponse
+	req := predictRequest{ModelName: modelName, Input: input}
+	compression, ok := t.modelCompression[modelName]
+	if !ok {
+		compression = t.compression
+	}
+	if err := t.send(ctx, http.MethodPost, predictPath, req, &resp, compression); err != nil {
+		return "", err
+	}
+	return resp.Output, nil
+}
+
+func (t *httpTransport) getModels(ctx context.Context) ([]ModelMetadata, error) {
+	var resp getModelsResponse
+	if err := t.do(ctx, http.MethodGet, modelsPath, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Models, nil
+}
+
+func (t *httpTransport) addModel(ctx context.Context, modelName string) error {
+	return t.do(ctx, http.MethodPost, modelsPath, modelRequest{ModelName: modelName}, nil)
+}
+
+func (t *httpTransport) updateModel(ctx context.Context, modelName string) error {
+	return t.do(ctx, http.MethodPut, modelsPath, modelRequest{ModelName: modelName}, nil)
+}
+
+func (t *httpTransport) deleteModel(ctx context.Context, modelName string) error {
+	path := modelsPath + "?" + url.Values{"model_name": {modelName}}.Encode()
+	return t.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+func (t *httpTransport) retryable(err error) (bool, time.Duration) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true, httpErr.RetryAfter
+		}
+		return false, 0
+	}
+	// errors from the round trip itself, e.g. connection refused.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr), 0
+}
+
+func (t *httpTransport) name() string {
+	return "http"
+}
+
+func (t *httpTransport) features() []Feature {
+	return []Feature{FeatureGzip, FeatureCompression}
+}
+
+func (t *httpTransport) close() error {
+	t.stop()
+	t.client.CloseIdleConnections()
+	return nil
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response
+// into out when out is not nil.
+func (t *httpTransport) do(ctx context.Context, method, path string, in, out any) error {
+	return t.send(ctx, method, path, in, out, compressionRule{})
+}
+
+// send is do with the body compressed according to compression.
+func (t *httpTransport) send(ctx context.Context, method, path string, in, out any, compression compressionRule) error {
+	fault, err := faultPairs(ctx, t.production, false)
+	if err != nil {
+		return err
+	}
+	usage := usageFrom(ctx)
+	timer := stageTimerFrom(ctx)
+	var (
+		body       io.Reader
+		compressed bool
+	)
+	if in != nil {
+		start := time.Now()
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		wire := payload
+		if compression.codec != nil && len(payload) >= compression.minSize && !t.uncompressed.Load() {
+			if wire, err = compression.codec.Compress(payload); err != nil {
+				return fmt.Errorf("failed to compress request: %w", err)
+			}
+			compressed = true
+		}
+		timer.since(StageMarshal, start)
+		body = bytes.NewReader(wire)
+		if usage != nil {
+			usage.requestBytes.Add(int64(len(payload)))
+			usage.requestWireBytes.Add(int64(len(wire)))
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range requestHeaderFrom(ctx) {
+		req.Header[key] = values
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if compressed {
+		req.Header.Set("Content-Encoding", compression.codec.Name())
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+	req.Header.Set(clientHeader, "go/"+Version)
+	t.identity.setHeaders(req.Header)
+	if t.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+t.bearerToken)
+	}
+	if key := idempotencyKeyFrom(ctx); key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	for i := 0; i < len(fault); i += 2 {
+		req.Header.Set(fault[i], fault[i+1])
+	}
+	// asking for gzip explicitly turns off the transparent decompression of
+	// net/http, so that the compressed size can be measured. Fetch does not
+	// let it be set.
+	if !fetchTransport {
+		req.Header.Set("Accept-Encoding", t.acceptEncoding)
+	}
+	if timer != nil {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), timer.clientTrace()))
+		defer timer.done()
+	}
+	if t.conns != nil {
+		var conn *trackedConn
+		req = req.WithContext(t.conns.trace(req.Context(), func(tc *trackedConn) {
+			// the transport retries requests which failed on a reused
+			// connection before anything was sent on a new one.
+			if conn != nil {
+				conn.release()
+			}
+			conn = tc
+		}))
+		// runs after the response body is closed.
+		defer func() {
+			if conn != nil {
+				conn.release()
+			}
+		}()
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	collectWarnings(ctx, resp.Header.Values)
+
+	if compressed && resp.StatusCode == http.StatusUnsupportedMediaType {
+		// the server does not accept compressed requests.
+		t.uncompressed.Store(true)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return t.send(ctx, method, path, in, out, compressionRule{})
+	}
+
+	respBody, err := t.responseBody(resp, usage)
+	if err != nil {
+		return err
+	}
+	// drain the body so the connection can be reused and its size counted.
+	defer io.Copy(io.Discard, respBody)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(respBody, 4096))
+		return &HTTPError{
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(msg)),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(respBody).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// responseBody returns the decompressed body of resp, counting its compressed
+// and uncompressed size into usage when not nil. Over Fetch, the body is
+// already decompressed and both sizes are the decompressed one.
+func (t *httpTransport) responseBody(resp *http.Response, usage *usageCollector) (io.Reader, error) {
+	var body io.Reader = resp.Body
+	if usage != nil {
+		body = countingReader{r: body, n: &usage.responseWireBytes}
+	}
+	if encoding := resp.Header.Get("Content-Encoding"); !fetchTransport && encoding != "" && encoding != "identity" {
+		codec, ok := t.codecs[encoding]
+		if !ok {
+			return nil, fmt.Errorf("unsupported response encoding %q", encoding)
+		}
+		decoded, err := codec.NewReader(body)
+		switch {
+		case errors.Is(err, io.EOF):
+			// an empty body.
+		case err != nil:
+			return nil, fmt.Errorf("failed to decompress response: %w", err)
+		default:
+			body = decoded
+		}
+	}
+	if usage != nil {
+		body = countingReader{r: body, n: &usage.responseBytes}
+	}
+	return body, nil
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package jams_client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Headers, and gRPC metadata keys, carrying the AppIdentity of a client.
+const (
+	appNameHeader        = "x-jams-app-name"
+	appVersionHeader     = "x-jams-app-version"
+	appEnvironmentHeader = "x-jams-app-environment"
+)
+
+// AppIdentity identifies the application using a client to the operators of
+// the server, e.g. to track down a misbehaving caller.
+type AppIdentity struct {
+	// Name is the name of the application, e.g. "churn-api".
+	Name string `json:"name,omitempty"`
+	// Version is the version of the application, e.g. "1.4.2".
+	Version string `json:"version,omitempty"`
+	// Environment is where the application runs, e.g. "production".
+	Environment string `json:"environment,omitempty"`
+}
+
+// WithAppIdentity sends the identity of the application with every call, in
+// the X-Jams-App-Name, X-Jams-App-Version and X-Jams-App-Environment headers
+// or gRPC metadata keys, and prepends its name and version to the
+// User-Agent, e.g. "churn-api/1.4.2 jams-go-client/0.1.0 (...)". The
+// identity is also set on every PredictionRecord. Empty fields are not sent;
+// the name and version must not contain spaces or slashes.
+func WithAppIdentity(identity AppIdentity) Option {
+	return func(o *options) {
+		o.appIdentity = identity
+	}
+}
+
+func (id AppIdentity) validate() error {
+	for _, f := range []struct {
+		name, value string
+		// token is set for the fields of the User-Agent product token
+		token bool
+	}{
+		{"name", id.Name, true},
+		{"version", id.Version, true},
+		{"environment", id.Environment, false},
+	} {
+		if strings.ContainsAny(f.value, "\r\n") || f.token && strings.ContainsAny(f.value, " /") {
+			return fmt.Errorf("invalid app %s %q", f.name, f.value)
+		}
+	}
+	return nil
+}
+
+// userAgent returns the User-Agent sent by a client with the identity.
+func (id AppIdentity) userAgent() string {
+	switch {
+	case id.Name == "":
+		return userAgent
+	case id.Version == "":
+		return id.Name + " " + userAgent
+	default:
+		return id.Name + "/" + id.Version + " " + userAgent
+	}
+}
+
+// pairs returns the headers of the identity, as key value pairs.
+func (id AppIdentity) pairs() []string {
+	var kv []string
+	for _, h := range []struct{ key, value string }{
+		{appNameHeader, id.Name},
+		{appVersionHeader, id.Version},
+		{appEnvironmentHeader, id.Environment},
+	} {
+		if h.value != "" {
+			kv = append(kv, h.key, h.value)
+		}
+	}
+	return kv
+}
+
+func (id AppIdentity) setHeaders(h http.Header) {
+	kv := id.pairs()
+	for i := 0; i < len(kv); i += 2 {
+		h.Set(kv[i], kv[i+1])
+	}
+}
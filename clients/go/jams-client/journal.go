@@ -0,0 +1,120 @@
+package jams_client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// JournalEntry is a Predict call persisted by a Journal before it is sent.
+type JournalEntry struct {
+	// RequestID matches the PredictionRecord of the call.
+	RequestID string `json:"request_id"`
+	Model     string `json:"model"`
+	// Time is when the call started.
+	Time time.Time `json:"time"`
+	// Input is the JSON payload sent to the server, after preprocessing.
+	Input json.RawMessage `json:"input"`
+}
+
+// Journal persists Predict calls before they are sent, so that the calls left
+// unanswered by a crash can be replayed with ReplayJournal. See the journal
+// package for a file-based implementation.
+type Journal interface {
+	// Append persists an entry. Predict fails without sending its input when
+	// Append fails, e.g. because the journal is full.
+	Append(e JournalEntry) error
+	// Ack marks the entry of a call as done.
+	Ack(requestID string) error
+}
+
+// WithJournal makes Predict append every call to j before sending it, once
+// the call is admitted by the quotas and the concurrency limit, and
+// acknowledge it once it succeeded or failed in a way a replay would not fix,
+// e.g. for an unknown model or an invalid input. Calls failing with a
+// retryable error, or whose outcome is unknown because they were cancelled
+// in flight, stay in the journal, to be replayed. The redactions set with
+// WithRedaction do not apply, as the journal must hold the input to replay
+// it.
+func WithJournal(j Journal) Option {
+	return func(o *options) {
+		o.journal = j
+	}
+}
+
+// ReplayJournal sends the inputs of entries again, e.g. the pending entries of
+// a journal after a crash, in order, and passes each prediction or error to
+// handle. Entries for which handle returns nil are acknowledged in the
+// journal set with WithJournal; the others stay pending. The input of an
+// entry is sent as is, without preprocessing, while postprocessing, sinks and
+// debug dumps apply as in Predict, under the request ID of the entry.
+// ReplayJournal stops when ctx is done or an entry cannot be acknowledged.
+func (c *Client) ReplayJournal(ctx context.Context, entries []JournalEntry, handle func(JournalEntry, *types.Prediction, error) error) error {
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var prediction *types.Prediction
+		input, err := types.ParseInput(e.Input)
+		if err == nil {
+			prediction, err = c.predictPayload(ctx, e.Model, input, e.Input, e.RequestID, nil)
+		}
+		if handle(e, prediction, err) != nil || c.opts.journal == nil {
+			continue
+		}
+		if err := c.opts.journal.Ack(e.RequestID); err != nil {
+			return fmt.Errorf("failed to acknowledge journal entry %s: %w", e.RequestID, err)
+		}
+	}
+	return nil
+}
+
+// journalAppend persists a call to the journal set with WithJournal, if any.
+func (c *Client) journalAppend(modelName, requestID string, payload []byte) error {
+	if c.opts.journal == nil {
+		return nil
+	}
+	err := c.opts.journal.Append(JournalEntry{
+		RequestID: requestID,
+		Model:     modelName,
+		Time:      time.Now(),
+		Input:     payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to journal prediction: %w", err)
+	}
+	return nil
+}
+
+// journalSettle acknowledges a journaled call unless it failed with a
+// retryable error or was cancelled in flight, which leaves it pending to be
+// replayed.
+func (c *Client) journalSettle(requestID string, err error) {
+	if err != nil {
+		if retry, _ := c.transport.retryable(err); retry || !answered(err) {
+			return
+		}
+	}
+	c.journalAck(requestID)
+}
+
+// journalAck acknowledges a call in the journal set with WithJournal, if any.
+// A failed acknowledgement does not fail the call; it is logged and the call
+// is replayed later.
+func (c *Client) journalAck(requestID string) {
+	if c.opts.journal == nil {
+		return
+	}
+	err := c.opts.journal.Ack(requestID)
+	if err == nil {
+		c.opts.degraded.leave(DegradedJournal, "")
+		return
+	}
+	c.opts.degraded.enter(DegradedJournal, "", err)
+	if c.opts.logger != nil {
+		c.opts.logger.Error("failed to acknowledge journal entry", "request_id", requestID, "error", err)
+	}
+}
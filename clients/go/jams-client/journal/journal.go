@@ -0,0 +1,401 @@
+// Package journal implements a write-ahead journal of Predict calls in a
+// local file, for pipelines where every record must be scored even when the
+// process crashes:
+//
+//	j, err := journal.Open("/var/lib/scorer/predict.journal", journal.Config{Key: key})
+//	client, err := jams.NewGRPCClient("localhost:4000", jams.WithJournal(j))
+//	// after a crash, before scoring new records
+//	err = client.ReplayJournal(ctx, j.Pending(), func(e jams.JournalEntry, p *types.Prediction, err error) error {
+//		if err != nil {
+//			return err
+//		}
+//		return store(e.RequestID, p)
+//	})
+//
+// Calls are replayed when the process crashed after the server answered but
+// before the call was acknowledged, so scoring is at least once: stores
+// should be idempotent on the request ID.
+//
+// Records are appended to the file and synced before the call is sent. With a
+// key, each record is sealed with AES-GCM, so that inputs are not readable at
+// rest. The file is compacted when every entry is acknowledged or when it
+// reaches Config.MaxBytes; appends fail with ErrFull when the pending entries
+// alone reach it.
+package journal
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// DefaultMaxBytes is the size of the journal file used unless Config.MaxBytes
+// is set.
+const DefaultMaxBytes = 64 << 20
+
+// ErrFull is returned by Append when the pending entries fill the journal.
+var ErrFull = errors.New("journal is full")
+
+// ErrClosed is returned once the journal is closed.
+var ErrClosed = errors.New("journal is closed")
+
+// Record kinds.
+const (
+	kindAppend byte = 'a'
+	kindAck    byte = 'k'
+)
+
+// maxRecordBytes bounds the length read from a record header, so that a
+// corrupt header does not allocate the length it claims.
+const maxRecordBytes = 1 << 30
+
+// Config configures a journal.
+type Config struct {
+	// Key is an AES key of 16, 24 or 32 bytes sealing every record. Records
+	// are stored in plain text when it is nil. A journal must be opened with
+	// the key it was written with.
+	Key []byte
+	// MaxBytes bounds the size of the file, DefaultMaxBytes when zero.
+	MaxBytes int64
+	// NoSync skips syncing the file after every record, trading durability
+	// on power loss for throughput. Records still survive a crash of the
+	// process.
+	NoSync bool
+}
+
+// Journal is a jams.Journal stored in a file. It is safe for concurrent use.
+type Journal struct {
+	mu     sync.Mutex
+	path   string
+	config Config
+	aead   cipher.AEAD
+	file   *os.File
+	size   int64
+	// pending holds the unacknowledged entries by request ID, and order
+	// their request IDs in the order they were appended. Acknowledged IDs
+	// are removed from order lazily.
+	pending map[string]jams.JournalEntry
+	order   []string
+}
+
+// Open opens the journal at path, creating it if needed, and loads its
+// pending entries. A record truncated by a crash while it was written is
+// dropped.
+func Open(path string, config Config) (*Journal, error) {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = DefaultMaxBytes
+	}
+	j := &Journal{path: path, config: config, pending: make(map[string]jams.JournalEntry)}
+	if config.Key != nil {
+		block, err := aes.NewCipher(config.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid journal key: %w", err)
+		}
+		if j.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	size, err := j.load(file)
+	if err == nil {
+		// drop a truncated record and append after the last complete one
+		err = file.Truncate(size)
+	}
+	if err == nil {
+		_, err = file.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to load journal %s: %w", path, err)
+	}
+	j.file, j.size = file, size
+	return j, nil
+}
+
+// load reads the records of file, returning the size of its complete ones.
+func (j *Journal) load(file *os.File) (int64, error) {
+	r := bufio.NewReader(file)
+	var size int64
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return size, nil
+		}
+		n := binary.BigEndian.Uint32(header[:])
+		if n == 0 || n > maxRecordBytes {
+			return 0, fmt.Errorf("invalid record length %d at offset %d", n, size)
+		}
+		sealed := make([]byte, n)
+		if _, err := io.ReadFull(r, sealed); err != nil {
+			return size, nil
+		}
+		record, err := j.open(sealed)
+		if err != nil {
+			return 0, fmt.Errorf("record at offset %d: %w", size, err)
+		}
+		if err := j.apply(record); err != nil {
+			return 0, fmt.Errorf("record at offset %d: %w", size, err)
+		}
+		size += int64(len(header) + len(sealed))
+	}
+}
+
+// apply replays a record onto the pending entries.
+func (j *Journal) apply(record []byte) error {
+	if len(record) == 0 {
+		return errors.New("empty record")
+	}
+	switch kind, data := record[0], record[1:]; kind {
+	case kindAppend:
+		var e jams.JournalEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+		if _, ok := j.pending[e.RequestID]; !ok {
+			j.order = append(j.order, e.RequestID)
+		}
+		j.pending[e.RequestID] = e
+	case kindAck:
+		delete(j.pending, string(data))
+	default:
+		return fmt.Errorf("unknown record kind %q", kind)
+	}
+	return nil
+}
+
+// Append implements jams.Journal.
+func (j *Journal) Append(e jams.JournalEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	record := append([]byte{kindAppend}, data...)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return ErrClosed
+	}
+	if j.size+j.recordSize(record) > j.config.MaxBytes {
+		if err := j.compact(); err != nil {
+			return err
+		}
+		if j.size+j.recordSize(record) > j.config.MaxBytes {
+			return fmt.Errorf("%w: %d pending entries", ErrFull, len(j.pending))
+		}
+	}
+	if err := j.write(record); err != nil {
+		return err
+	}
+	if _, ok := j.pending[e.RequestID]; !ok {
+		j.order = append(j.order, e.RequestID)
+	}
+	j.pending[e.RequestID] = e
+	return nil
+}
+
+// Ack implements jams.Journal. Acknowledging an unknown entry does nothing.
+func (j *Journal) Ack(requestID string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return ErrClosed
+	}
+	if _, ok := j.pending[requestID]; !ok {
+		return nil
+	}
+	if len(j.pending) == 1 {
+		// nothing left to replay
+		delete(j.pending, requestID)
+		return j.reset()
+	}
+	record := append([]byte{kindAck}, requestID...)
+	if j.size+j.recordSize(record) > j.config.MaxBytes {
+		delete(j.pending, requestID)
+		return j.compact()
+	}
+	if err := j.write(record); err != nil {
+		return err
+	}
+	delete(j.pending, requestID)
+	return nil
+}
+
+// Pending returns the unacknowledged entries, in the order they were
+// appended.
+func (j *Journal) Pending() []jams.JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := make([]jams.JournalEntry, 0, len(j.pending))
+	for _, id := range j.liveOrder() {
+		entries = append(entries, j.pending[id])
+	}
+	return entries
+}
+
+// Len returns the number of unacknowledged entries.
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.pending)
+}
+
+// Close closes the file. Pending entries are kept for the next Open.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// liveOrder drops acknowledged IDs from j.order and returns it.
+func (j *Journal) liveOrder() []string {
+	live := j.order[:0]
+	for _, id := range j.order {
+		if _, ok := j.pending[id]; ok {
+			live = append(live, id)
+		}
+	}
+	clear(j.order[len(live):])
+	j.order = live
+	return live
+}
+
+// reset empties the file.
+func (j *Journal) reset() error {
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	j.size = 0
+	j.order = j.order[:0]
+	return j.sync(j.file)
+}
+
+// compact rewrites the file with the pending entries only, replacing it
+// atomically.
+func (j *Journal) compact() error {
+	tmp, err := os.OpenFile(j.path+".tmp", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	var size int64
+	for _, id := range j.liveOrder() {
+		data, err := json.Marshal(j.pending[id])
+		if err == nil {
+			var sealed []byte
+			sealed, err = j.seal(append([]byte{kindAppend}, data...))
+			size += int64(4 + len(sealed))
+			if err == nil {
+				err = writeRecord(w, sealed)
+			}
+		}
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	err = w.Flush()
+	if err == nil {
+		err = j.sync(tmp)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), j.path)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+	j.file.Close()
+	j.file, j.size = tmp, size
+	return nil
+}
+
+func (j *Journal) write(record []byte) error {
+	sealed, err := j.seal(record)
+	if err != nil {
+		return err
+	}
+	if err := writeRecord(j.file, sealed); err != nil {
+		// a partial record is dropped by the next Open, but would hide the
+		// records appended after it
+		j.file.Truncate(j.size)
+		j.file.Seek(j.size, io.SeekStart)
+		return err
+	}
+	j.size += int64(4 + len(sealed))
+	return j.sync(j.file)
+}
+
+func writeRecord(w io.Writer, sealed []byte) error {
+	buf := make([]byte, 4, 4+len(sealed))
+	binary.BigEndian.PutUint32(buf, uint32(len(sealed)))
+	_, err := w.Write(append(buf, sealed...))
+	return err
+}
+
+func (j *Journal) sync(f *os.File) error {
+	if j.config.NoSync {
+		return nil
+	}
+	return f.Sync()
+}
+
+// recordSize returns the size of record once written.
+func (j *Journal) recordSize(record []byte) int64 {
+	size := int64(4 + len(record))
+	if j.aead != nil {
+		size += int64(j.aead.NonceSize() + j.aead.Overhead())
+	}
+	return size
+}
+
+// seal encrypts record, prefixed with its nonce, when the journal has a key.
+func (j *Journal) seal(record []byte) ([]byte, error) {
+	if j.aead == nil {
+		return record, nil
+	}
+	nonce := make([]byte, j.aead.NonceSize(), j.aead.NonceSize()+len(record)+j.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return j.aead.Seal(nonce, nonce, record, nil), nil
+}
+
+func (j *Journal) open(sealed []byte) ([]byte, error) {
+	if j.aead == nil {
+		return sealed, nil
+	}
+	if len(sealed) < j.aead.NonceSize() {
+		return nil, errors.New("record too short")
+	}
+	nonce, ciphertext := sealed[:j.aead.NonceSize()], sealed[j.aead.NonceSize():]
+	record, err := j.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt record: wrong key or corrupt journal")
+	}
+	return record, nil
+}
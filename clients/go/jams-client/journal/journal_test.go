@@ -0,0 +1,120 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// server answers predictions for the models "ok" and "limited", 400 for the
+// model "invalid", 404 for "missing" and 503 for the others.
+func server(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ModelName string `json:"model_name"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.ModelName {
+		case "ok", "limited":
+			json.NewEncoder(w).Encode(map[string]string{"output": `{"predictions": [[0.5]]}`})
+		case "invalid":
+			http.Error(w, "invalid input", http.StatusBadRequest)
+		case "missing":
+			http.Error(w, "model not found", http.StatusNotFound)
+		default:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestFailedCallsDoNotFillJournal(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		model string
+		opts  []jams.Option
+		// allowed is the number of calls which succeed before the others
+		// fail.
+		allowed int
+	}{
+		{name: "invalid input", ctx: context.Background(), model: "invalid"},
+		{name: "unknown model", ctx: context.Background(), model: "missing"},
+		{name: "cancelled before sending", ctx: cancelled, model: "ok"},
+		{
+			name: "quota exceeded", ctx: context.Background(), model: "limited", allowed: 1,
+			opts: []jams.Option{jams.WithQuota("limited", jams.Quota{Requests: 1, Window: time.Hour})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := Open(filepath.Join(t.TempDir(), "predict.journal"), Config{MaxBytes: 4096})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer j.Close()
+			ts := server(t)
+			client, err := jams.NewHTTPClient(ts.URL, append(tt.opts, jams.WithJournal(j))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			input := types.NewInput().AddFloats("x", 1, 2, 3)
+			// enough calls to fill the journal several times over.
+			for i := 0; i < 200; i++ {
+				_, err := client.Predict(tt.ctx, tt.model, input)
+				switch {
+				case errors.Is(err, ErrFull):
+					t.Fatalf("call %d: %v", i, err)
+				case i < tt.allowed && err != nil:
+					t.Fatalf("call %d: %v", i, err)
+				case i >= tt.allowed && err == nil:
+					t.Fatalf("call %d succeeded", i)
+				}
+			}
+			if n := j.Len(); n != 0 {
+				t.Fatalf("%d failed calls pending", n)
+			}
+			if _, err := client.Predict(context.Background(), "ok", input); err != nil {
+				t.Fatalf("predict after failures: %v", err)
+			}
+		})
+	}
+}
+
+func TestRetryableFailuresStayPending(t *testing.T) {
+	j, err := Open(filepath.Join(t.TempDir(), "predict.journal"), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	client, err := jams.NewHTTPClient(server(t).URL, jams.WithJournal(j))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	input := types.NewInput().AddFloats("x", 1)
+	if _, err := client.Predict(context.Background(), "unavailable", input); err == nil {
+		t.Fatal("call to an unavailable model succeeded")
+	}
+	if _, err := client.Predict(context.Background(), "ok", input); err != nil {
+		t.Fatal(err)
+	}
+	pending := j.Pending()
+	if len(pending) != 1 || pending[0].Model != "unavailable" {
+		t.Fatalf("pending entries are %+v, want the call to the unavailable model", pending)
+	}
+}
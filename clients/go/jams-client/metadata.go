@@ -0,0 +1,31 @@
+package jams_client
+
+import (
+	"context"
+	"maps"
+)
+
+type callMetadataKey struct{}
+
+// WithCallMetadata returns a context whose calls carry md, business metadata
+// such as a user or experiment ID, merged over the metadata ctx already
+// carries. The metadata stays in the process: it is not sent to the server
+// but is set on the PredictionRecord, Usage and CancelEvent of the calls,
+// so that sinks and hooks can label them, e.g. per experiment.
+//
+//	ctx = jams.WithCallMetadata(ctx, map[string]string{"experiment": "ranker-v2"})
+func WithCallMetadata(ctx context.Context, md map[string]string) context.Context {
+	merged := make(map[string]string, len(md))
+	if parent, ok := ctx.Value(callMetadataKey{}).(map[string]string); ok {
+		maps.Copy(merged, parent)
+	}
+	maps.Copy(merged, md)
+	return context.WithValue(ctx, callMetadataKey{}, merged)
+}
+
+// CallMetadata returns the metadata set on ctx with WithCallMetadata, nil if
+// none. The map must not be modified.
+func CallMetadata(ctx context.Context) map[string]string {
+	md, _ := ctx.Value(callMetadataKey{}).(map[string]string)
+	return md
+}
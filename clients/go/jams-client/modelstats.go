@@ -0,0 +1,131 @@
+package jams_client
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ModelStats summarises the Predict calls of a model over a period.
+type ModelStats struct {
+	Model    string `json:"model"`
+	Requests uint64 `json:"requests"`
+	Failures uint64 `json:"failures"`
+	// Rate is the requests per second over the period.
+	Rate float64 `json:"rate"`
+	// ErrorRate is the fraction of requests which failed.
+	ErrorRate float64      `json:"error_rate"`
+	Latency   LatencyStats `json:"latency"`
+}
+
+// ModelStatsReport holds the stats of the models called between Start and End,
+// see WithModelStatsReporter.
+type ModelStatsReport struct {
+	Start  time.Time    `json:"start"`
+	End    time.Time    `json:"end"`
+	Models []ModelStats `json:"models"`
+}
+
+// WithModelStatsReporter calls report every interval with the stats of the
+// Predict calls of each model made during the interval, e.g. to publish the
+// traffic of models to their owners. Models without calls in the interval are
+// left out. The calls since the last report are reported once more by Close.
+// report is called from a goroutine of the client, one report at a time.
+func WithModelStatsReporter(interval time.Duration, report func(ModelStatsReport)) Option {
+	return func(o *options) {
+		o.modelStatsInterval = interval
+		o.modelStatsReport = report
+	}
+}
+
+// ModelStats returns the stats of the Predict calls of each model since the
+// client was created, sorted by model, giving model owners the usage of their
+// models without server side analytics.
+func (c *Client) ModelStats() []ModelStats {
+	return c.stats.modelStats()
+}
+
+func (r *statsRecorder) modelStats() []ModelStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counters := make(map[string]*callCounters)
+	for key, c := range r.calls {
+		if key.method == MethodPredict && key.model != "" {
+			counters[key.model] = c
+		}
+	}
+	return summariseModels(counters, time.Since(r.since))
+}
+
+// takeWindow returns the start of the current window of model counters and
+// the counters, and starts a new window at now.
+func (r *statsRecorder) takeWindow(now time.Time) (time.Time, map[string]*callCounters) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start, window := r.windowStart, r.window
+	r.windowStart, r.window = now, make(map[string]*callCounters)
+	return start, window
+}
+
+// summariseModels returns the stats of the models of counters over period,
+// sorted by model.
+func summariseModels(counters map[string]*callCounters, period time.Duration) []ModelStats {
+	models := make([]ModelStats, 0, len(counters))
+	for model, c := range counters {
+		if c.requests == 0 {
+			continue
+		}
+		stats := ModelStats{
+			Model:     model,
+			Requests:  c.requests,
+			Failures:  c.failures,
+			ErrorRate: float64(c.failures) / float64(c.requests),
+			Latency:   c.latency(),
+		}
+		if period > 0 {
+			stats.Rate = float64(c.requests) / period.Seconds()
+		}
+		models = append(models, stats)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Model < models[j].Model
+	})
+	return models
+}
+
+// startModelStatsReports reports the model stats every interval until the
+// returned function is called, which reports the remaining calls and waits
+// for the reports to end.
+func (c *Client) startModelStatsReports() func() {
+	if c.opts.modelStatsInterval <= 0 || c.opts.modelStatsReport == nil {
+		return func() {}
+	}
+	c.stats.takeWindow(time.Now())
+	stop, done := make(chan struct{}), make(chan struct{})
+	report := func(now time.Time) {
+		start, window := c.stats.takeWindow(now)
+		if models := summariseModels(window, now.Sub(start)); len(models) > 0 {
+			c.opts.modelStatsReport(ModelStatsReport{Start: start, End: now, Models: models})
+		}
+	}
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.opts.modelStatsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				report(now)
+			case <-stop:
+				report(time.Now())
+				return
+			}
+		}
+	}()
+	return sync.OnceFunc(func() {
+		close(stop)
+		<-done
+	})
+}
@@ -0,0 +1,38 @@
+package jams_client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// PredictResult is the outcome of a prediction made by PredictMulti.
+type PredictResult struct {
+	Prediction *types.Prediction
+	Err        error
+}
+
+// PredictMulti makes predictions with several models in parallel. inputs maps
+// model names to their input. Every call shares the deadline of ctx and the
+// result of each model is reported separately, so one failing model does not
+// hide the predictions of the others.
+func (c *Client) PredictMulti(ctx context.Context, inputs map[string]*types.Input) map[string]PredictResult {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]PredictResult, len(inputs))
+	)
+	for model, input := range inputs {
+		wg.Add(1)
+		go func(model string, input *types.Input) {
+			defer wg.Done()
+			prediction, err := c.Predict(ctx, model, input)
+			mu.Lock()
+			results[model] = PredictResult{Prediction: prediction, Err: err}
+			mu.Unlock()
+		}(model, input)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,29 @@
+//go:build jams_nogrpc || js
+
+package jams_client
+
+// The jams_nogrpc build tag leaves out the gRPC transport, and with it the
+// gRPC and protobuf modules, for HTTP-only binaries such as lambdas. So do
+// js/wasm builds, which cannot open the sockets gRPC needs. The API is
+// unchanged: gRPC clients fail to be created with ErrNoGRPC and the
+// gRPC-only options have no effect.
+
+// grpcBuilt reports whether the gRPC transport is part of the build.
+const grpcBuilt = false
+
+// grpcOptions holds no options without the gRPC transport.
+type grpcOptions struct{}
+
+// NewGRPCClient returns ErrNoGRPC without the gRPC transport.
+func NewGRPCClient(target string, opts ...Option) (*Client, error) {
+	return nil, ErrNoGRPC
+}
+
+// Channels returns nil without the gRPC transport.
+func (c *Client) Channels() []ChannelStatus {
+	return nil
+}
+
+func grpcCanceled(error) bool {
+	return false
+}
@@ -0,0 +1,201 @@
+package jams_client
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Option configures a Client.
+type Option func(*options)
+
+type options struct {
+	httpClient *http.Client
+	grpcOptions
+	// grpcChannels is the number of connections of the gRPC transport.
+	grpcChannels int
+	maxAttempts  int
+	backoff      Backoff
+	// backoffSet records whether the Backoff was chosen by the user, in which
+	// case it also drives gRPC re-dials.
+	backoffSet bool
+	// customDialer is set when the IP preference or fallback delay were
+	// changed, in which case both transports dial through dialContext.
+	customDialer  bool
+	ipPreference  IPPreference
+	fallbackDelay time.Duration
+	// templates are merged by Predict into the input of the model they are
+	// registered for, before its preprocessor.
+	templates map[string]*types.Template
+	// preprocessors are applied by Predict to the input of the model they are
+	// registered for.
+	preprocessors map[string]Preprocessor
+	// postprocessors are applied by Predict to the predictions of the model
+	// they are registered for.
+	postprocessors map[string]Postprocessor
+	// modelColumns are the known columns of a model, in the order sent.
+	modelColumns map[string][]string
+	// cacheEntries enables the prediction cache when greater than zero.
+	cacheEntries       int
+	cachePolicy        CachePolicy
+	modelCachePolicies map[string]CachePolicy
+	sinks              []PredictionSink
+	warningHandler     func(Warning)
+	logger             *slog.Logger
+	usageHook          func(Usage)
+	quotas             []*quotaRule
+	bearerToken        string
+	parseOptions       types.ParseOptions
+	uncertainties      map[string]types.Uncertainty
+	redactions         []*Redaction
+	maxConcurrency     int
+	journal            Journal
+	stageTimings       bool
+	profilingLabels    bool
+	appIdentity        AppIdentity
+	hooks              []Hooks
+	channelHealth      *ChannelHealth
+	dnsRefresh         time.Duration
+	maxConnAge         time.Duration
+	maxConnIdle        time.Duration
+	timeouts           Timeouts
+	degraded           *degradedReporter
+	codecs             []Codec
+	compression        compressionRule
+	modelCompression   map[string]compressionRule
+	loadReports        *LoadReports
+	dedupWindow        time.Duration
+	modelStatsInterval time.Duration
+	modelStatsReport   func(ModelStatsReport)
+}
+
+func defaultOptions() *options {
+	return &options{
+		httpClient:  http.DefaultClient,
+		maxAttempts: 1,
+		backoff:     DefaultBackoff,
+		codecs:      []Codec{GzipCodec{}},
+	}
+}
+
+// WithHTTPClient sets the *http.Client used by the HTTP transport. The client
+// is copied, not modified, when WithIPPreference or WithFallbackDelay are
+// given too.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithBearerToken sends token as a bearer token with every call, in the
+// Authorization header or gRPC metadata, e.g. for servers behind an
+// authenticating proxy.
+func WithBearerToken(token string) Option {
+	return func(o *options) {
+		o.bearerToken = token
+	}
+}
+
+// WithGRPCChannels makes the gRPC transport open n connections to the server
+// and send each call on the one with the fewest calls in flight, for
+// throughput beyond the concurrent streams of a single HTTP/2 connection. It
+// has no effect on the HTTP transport.
+func WithGRPCChannels(n int) Option {
+	return func(o *options) {
+		o.grpcChannels = n
+	}
+}
+
+// WithRetry makes the client try every call up to maxAttempts times. Calls are
+// retried on connection errors, HTTP 429, 502, 503 and 504 responses and gRPC
+// Unavailable and ResourceExhausted errors, waiting between attempts according
+// to the Backoff or the Retry-After header of the response, whichever is
+// longer. Note that mutating calls such as AddModel are retried too.
+func WithRetry(maxAttempts int) Option {
+	return func(o *options) {
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+		o.maxAttempts = maxAttempts
+	}
+}
+
+// WithBackoff sets the Backoff used between retries, by WaitUntilHealthy and
+// by WatchModels after failed polls. The gRPC transport also uses it when
+// re-dialling; see NewGRPCClient. Defaults to DefaultBackoff.
+func WithBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+		o.backoffSet = true
+	}
+}
+
+// WithTemplate makes Predict merge the static columns of t into every input
+// sent to the named model, before its preprocessor, so that call sites only
+// pass the features which change between calls. Columns of the input take
+// precedence. Registering a model twice replaces its template.
+func WithTemplate(modelName string, t *types.Template) Option {
+	return func(o *options) {
+		if o.templates == nil {
+			o.templates = make(map[string]*types.Template)
+		}
+		o.templates[modelName] = t
+	}
+}
+
+// WithPreprocessor makes Predict apply p to every input sent to the named
+// model. Registering a model twice replaces its preprocessor.
+func WithPreprocessor(modelName string, p Preprocessor) Option {
+	return func(o *options) {
+		if o.preprocessors == nil {
+			o.preprocessors = make(map[string]Preprocessor)
+		}
+		o.preprocessors[modelName] = p
+	}
+}
+
+// WithPostprocessor makes Predict apply p to every prediction of the named
+// model. Registering a model twice replaces its postprocessor.
+func WithPostprocessor(modelName string, p Postprocessor) Option {
+	return func(o *options) {
+		if o.postprocessors == nil {
+			o.postprocessors = make(map[string]Postprocessor)
+		}
+		o.postprocessors[modelName] = p
+	}
+}
+
+// WithColumns declares the columns of the named model. Predict sends them in
+// the given order and rejects inputs with missing or unknown columns, e.g.
+// misspelt features, before they are sent.
+func WithColumns(modelName string, columns ...string) Option {
+	return func(o *options) {
+		if o.modelColumns == nil {
+			o.modelColumns = make(map[string][]string)
+		}
+		o.modelColumns[modelName] = columns
+	}
+}
+
+// WithParseOptions sets how Predict parses prediction outputs, e.g.
+// types.ParseStrict to reject malformed outputs. Defaults to
+// types.ParseDefault.
+func WithParseOptions(opts types.ParseOptions) Option {
+	return func(o *options) {
+		o.parseOptions = opts
+	}
+}
+
+// WithUncertainty declares how the outputs of the named model express
+// uncertainty, so that the intervals of its predictions can be read with
+// Prediction.Interval.
+func WithUncertainty(modelName string, u types.Uncertainty) Option {
+	return func(o *options) {
+		if o.uncertainties == nil {
+			o.uncertainties = make(map[string]types.Uncertainty)
+		}
+		o.uncertainties[modelName] = u
+	}
+}
@@ -0,0 +1,156 @@
+// Package pipeline runs models in sequence, feeding the output of each model
+// into the next, e.g. for two stage ranking or classification.
+//
+//	p, err := pipeline.New(client,
+//		pipeline.Stage{Model: "lightgbm-candidates"},
+//		pipeline.Stage{Model: "catboost-ranker"},
+//	)
+//	result, err := p.Run(ctx, input)
+//	fmt.Println(result.Prediction.Values(), result.Latency)
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Predictor makes predictions. It is implemented by *jams.Client.
+type Predictor interface {
+	Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error)
+}
+
+// Transform builds the input of a stage from the input and prediction of the
+// previous stage. It must not modify its arguments.
+type Transform func(input *types.Input, prediction *types.Prediction) (*types.Input, error)
+
+// Stage is a model of a pipeline.
+type Stage struct {
+	Model string
+	// Transform builds the input of the stage. It defaults to AppendOutputs
+	// with the previous model name as prefix and is not used for the first
+	// stage, which receives the pipeline input.
+	Transform Transform
+}
+
+// Pipeline runs stages in order. It is safe for concurrent use.
+type Pipeline struct {
+	predictor Predictor
+	stages    []Stage
+}
+
+// New returns a pipeline running the stages with predictor.
+func New(predictor Predictor, stages ...Stage) (*Pipeline, error) {
+	if len(stages) == 0 {
+		return nil, errors.New("a pipeline needs at least one stage")
+	}
+	stages = append([]Stage(nil), stages...)
+	for i := range stages {
+		if stages[i].Model == "" {
+			return nil, fmt.Errorf("stage %d has no model", i)
+		}
+		if i > 0 && stages[i].Transform == nil {
+			stages[i].Transform = AppendOutputs(stages[i-1].Model + "_")
+		}
+	}
+	return &Pipeline{predictor: predictor, stages: stages}, nil
+}
+
+// Result is the outcome of a pipeline run.
+type Result struct {
+	// Prediction is the prediction of the last stage.
+	Prediction *types.Prediction
+	// Stages holds the result of every stage which ran.
+	Stages []StageResult
+	// Latency is the duration of the whole run.
+	Latency time.Duration
+}
+
+// StageResult is the outcome of a single stage.
+type StageResult struct {
+	Model      string
+	Input      *types.Input
+	Prediction *types.Prediction
+	// Latency includes the stage's Transform.
+	Latency time.Duration
+}
+
+// StageError is returned when a stage fails.
+type StageError struct {
+	Stage int
+	Model string
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("pipeline stage %d (%s): %v", e.Stage, e.Model, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Run runs every stage in order. On failure the returned Result holds the
+// stages which completed and the error is a *StageError.
+func (p *Pipeline) Run(ctx context.Context, input *types.Input) (*Result, error) {
+	start := time.Now()
+	result := &Result{Stages: make([]StageResult, 0, len(p.stages))}
+	var prediction *types.Prediction
+	for i, stage := range p.stages {
+		stageStart := time.Now()
+		if i > 0 {
+			var err error
+			if input, err = stage.Transform(input, prediction); err != nil {
+				result.Latency = time.Since(start)
+				return result, &StageError{Stage: i, Model: stage.Model, Err: fmt.Errorf("transform failed: %w", err)}
+			}
+		}
+		var err error
+		if prediction, err = p.predictor.Predict(ctx, stage.Model, input); err != nil {
+			result.Latency = time.Since(start)
+			return result, &StageError{Stage: i, Model: stage.Model, Err: err}
+		}
+		result.Stages = append(result.Stages, StageResult{
+			Model:      stage.Model,
+			Input:      input,
+			Prediction: prediction,
+			Latency:    time.Since(stageStart),
+		})
+	}
+	result.Prediction = prediction
+	result.Latency = time.Since(start)
+	return result, nil
+}
+
+// AppendOutputs returns a Transform adding every output value of the previous
+// prediction to its input as a float column named
+// "<prefix><output>_<k>", e.g. "churn_predictions_0".
+func AppendOutputs(prefix string) Transform {
+	return func(input *types.Input, prediction *types.Prediction) (*types.Input, error) {
+		out := input.Clone()
+		for _, name := range prediction.Names() {
+			rows := prediction.Outputs[name]
+			if len(rows) != input.Len() {
+				return nil, fmt.Errorf("output %q has %d rows, expected %d", name, len(rows), input.Len())
+			}
+			width := 0
+			for _, row := range rows {
+				width = max(width, len(row))
+			}
+			for k := 0; k < width; k++ {
+				column := make([]float64, len(rows))
+				for i, row := range rows {
+					if k >= len(row) {
+						return nil, fmt.Errorf("output %q row %d has %d values, expected %d", name, i, len(row), width)
+					}
+					column[i] = row[k]
+				}
+				out.AddFloats(fmt.Sprintf("%s%s_%d", prefix, name, k), column...)
+			}
+		}
+		return out, nil
+	}
+}
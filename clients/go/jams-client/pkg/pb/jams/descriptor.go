@@ -0,0 +1,39 @@
+package jams
+
+import (
+	_ "embed"
+
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// ProtoSource is the source of jams.proto, which the package is generated
+// from.
+//
+//go:embed jams.proto
+var ProtoSource string
+
+// FileDescriptor returns the descriptor of jams.proto, for tools building on
+// the schema, e.g. dynamic clients, mocks or gateways.
+func FileDescriptor() protoreflect.FileDescriptor {
+	return File_jams_proto
+}
+
+// ServiceDescriptor returns the descriptor of the ModelServer service.
+func ServiceDescriptor() protoreflect.ServiceDescriptor {
+	return File_jams_proto.Services().ByName("ModelServer")
+}
+
+// FileDescriptorSet returns jams.proto and its dependencies as a
+// FileDescriptorSet, in dependency order, as written by protoc
+// --descriptor_set_out --include_imports.
+func FileDescriptorSet() *descriptorpb.FileDescriptorSet {
+	return &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(emptypb.File_google_protobuf_empty_proto),
+			protodesc.ToFileDescriptorProto(File_jams_proto),
+		},
+	}
+}
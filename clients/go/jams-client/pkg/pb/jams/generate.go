@@ -0,0 +1,11 @@
+package jams
+
+// jams.proto is a copy of internal/jams-proto/proto/api/v1/jams.proto, the
+// definition shared with the server, kept in the module so that the code can
+// be regenerated and the source embedded. Regenerate with buf from the
+// repository:
+//
+//	go generate ./pkg/pb/jams
+
+//go:generate cp ../../../../../../internal/jams-proto/proto/api/v1/jams.proto jams.proto
+//go:generate sh -c "cd ../../.. && buf generate"
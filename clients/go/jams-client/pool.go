@@ -0,0 +1,62 @@
+package jams_client
+
+import "time"
+
+// ChannelHealth configures the health scoring of the connections of the gRPC
+// channel pool, see WithChannelHealth. Zero fields take their defaults.
+type ChannelHealth struct {
+	// MaxErrorRate is the recent rate of failed calls above which a channel
+	// is evicted. Defaults to 0.5.
+	MaxErrorRate float64
+	// MaxLatencyRatio is how many times slower than the median of the other
+	// channels a channel may recently be before it is evicted. Defaults to 3.
+	MaxLatencyRatio float64
+	// MinCalls is the number of calls a channel makes before it is scored,
+	// and so before it may be evicted. Defaults to 20.
+	MinCalls int
+}
+
+// WithChannelHealth scores the connections of the gRPC channel pool on the
+// error rate and latency of their recent calls, weighs the choice of the
+// channel of each call by its score, so that traffic drains away from a
+// degraded connection, and evicts connections whose score crosses the limits
+// of h. An evicted connection is replaced by a new one, which re-resolves the
+// target, and closed once its calls in flight are done; evictions are logged
+// to the logger set with WithLogger.
+//
+// Calls failing with Unavailable errors, which gRPC returns when the
+// connection fails, count as failed. Other errors say nothing of the
+// connection: jams-serve returns Internal errors for unknown models, bad
+// inputs and failed inferences, and DeadlineExceeded errors mostly come from
+// the deadlines of callers. It has no effect on the HTTP transport.
+func WithChannelHealth(h ChannelHealth) Option {
+	if h.MaxErrorRate <= 0 {
+		h.MaxErrorRate = 0.5
+	}
+	if h.MaxLatencyRatio <= 0 {
+		h.MaxLatencyRatio = 3
+	}
+	if h.MinCalls <= 0 {
+		h.MinCalls = 20
+	}
+	return func(o *options) {
+		o.channelHealth = &h
+	}
+}
+
+// ChannelStatus is the health of a connection of the gRPC channel pool.
+type ChannelStatus struct {
+	InFlight int64 `json:"in_flight"`
+	// Calls counts the calls finished on the connection since it was opened,
+	// whatever their outcome. A connection replacing an evicted one starts
+	// over from zero.
+	Calls int64 `json:"calls"`
+	// ErrorRate and Latency are averaged over roughly the last 20 calls
+	// which succeeded or failed because of the connection. Latency is zero
+	// until a call succeeds.
+	ErrorRate float64       `json:"error_rate"`
+	Latency   time.Duration `json:"latency"`
+	// Evictions counts the unhealthy connections evicted from this slot of
+	// the pool.
+	Evictions int `json:"evictions"`
+}
@@ -0,0 +1,254 @@
+//go:build !jams_nogrpc && !js
+
+package jams_client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/gagansingh894/jams-rs/clients/go/jams-client/pkg/pb/jams"
+)
+
+// healthWeight is the weight of the latest call in the error rate and latency
+// of a channel, averaging them over roughly the last 20 calls.
+const healthWeight = 0.1
+
+// Channels reports the health of the connections of the gRPC channel pool,
+// in pool order, or nil for the HTTP transport.
+func (c *Client) Channels() []ChannelStatus {
+	t, ok := c.transport.(*grpcTransport)
+	if !ok {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	statuses := make([]ChannelStatus, len(t.channels))
+	for i, ch := range t.channels {
+		ch.mu.Lock()
+		statuses[i] = ChannelStatus{
+			InFlight:  ch.inFlight.Load(),
+			Calls:     ch.calls.Load(),
+			ErrorRate: ch.errorRate,
+			Latency:   time.Duration(ch.latency),
+			Evictions: t.evictions[i],
+		}
+		ch.mu.Unlock()
+	}
+	return statuses
+}
+
+// grpcChannel is a connection of the pool set with WithGRPCChannels.
+type grpcChannel struct {
+	conn     *grpc.ClientConn
+	client   pb.ModelServerClient
+	inFlight atomic.Int64
+	// calls counts the calls finished on the channel.
+	calls atomic.Int64
+	// evicted is set once the channel is replaced in the pool, after which it
+	// is closed by the last of its calls in flight.
+	evicted   atomic.Bool
+	closeOnce sync.Once
+	// expires is when the channel reaches its maximum age, if any.
+	expires time.Time
+
+	mu sync.Mutex
+	// scored counts the calls whose outcome went into errorRate and latency,
+	// in nanoseconds, which are moving averages of the recent ones.
+	scored    int64
+	errorRate float64
+	latency   float64
+}
+
+func (t *grpcTransport) dial() (*grpcChannel, error) {
+	conn, err := grpc.NewClient(t.target, t.dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	ch := &grpcChannel{conn: conn, client: pb.NewModelServerClient(conn)}
+	if t.maxAge > 0 {
+		ch.expires = time.Now().Add(jitterAge(t.maxAge))
+	}
+	return ch, nil
+}
+
+// channel returns the client of the channel with the fewest calls in flight,
+// weighed by its health under WithChannelHealth, and the function to call
+// with the error of the call once it is done.
+func (t *grpcTransport) channel() (pb.ModelServerClient, func(error)) {
+	t.mu.RLock()
+	ch := t.channels[0]
+	if n := len(t.channels); n > 1 {
+		start := int(t.next.Add(1))
+		best := -1.0
+		for i := 0; i < n; i++ {
+			c := t.channels[(start+i)%n]
+			if cost := t.cost(c); best < 0 || cost < best {
+				ch, best = c, cost
+			}
+		}
+	}
+	ch.inFlight.Add(1)
+	t.mu.RUnlock()
+
+	start := time.Now()
+	return ch.client, func(err error) {
+		ch.calls.Add(1)
+		switch {
+		case t.observe(ch, time.Since(start), err):
+			t.evict(ch, true)
+		case !ch.expires.IsZero() && time.Now().After(ch.expires):
+			t.evict(ch, false)
+		}
+		if ch.inFlight.Add(-1) == 0 && ch.evicted.Load() {
+			ch.close()
+		}
+	}
+}
+
+// cost ranks the channels of the pool: the number of calls in flight, scaled
+// up by the error rate and latency of the channel when it is scored.
+func (t *grpcTransport) cost(ch *grpcChannel) float64 {
+	cost := float64(ch.inFlight.Load() + 1)
+	if t.health == nil {
+		return cost
+	}
+	ch.mu.Lock()
+	scored, errorRate, latency := ch.scored, ch.errorRate, ch.latency
+	ch.mu.Unlock()
+	if scored < int64(t.health.MinCalls) {
+		return cost
+	}
+	cost /= max(1-errorRate, 0.01)
+	if median := t.medianLatency(ch); median > 0 && latency > median {
+		cost *= latency / median
+	}
+	return cost
+}
+
+// observe records the outcome of a call on ch and reports whether ch is to
+// be evicted.
+func (t *grpcTransport) observe(ch *grpcChannel, elapsed time.Duration, err error) bool {
+	failed := false
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unavailable:
+		failed = true
+	default:
+		// the call failed for a reason unrelated to the connection, e.g. an
+		// Internal error of the server, which jams-serve returns for unknown
+		// models and bad inputs, or the deadline of the caller.
+		return false
+	}
+
+	sample := 0.0
+	if failed {
+		sample = 1
+	}
+	ch.mu.Lock()
+	ch.scored++
+	ch.errorRate = average(ch.errorRate, sample, ch.scored == 1)
+	if !failed {
+		ch.latency = average(ch.latency, float64(elapsed), ch.latency == 0)
+	}
+	scored := t.health != nil && ch.scored >= int64(t.health.MinCalls)
+	errorRate, latency := ch.errorRate, ch.latency
+	ch.mu.Unlock()
+
+	if !scored || ch.evicted.Load() {
+		return false
+	}
+	if errorRate > t.health.MaxErrorRate {
+		return true
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	median := t.medianLatency(ch)
+	return median > 0 && latency > t.health.MaxLatencyRatio*median
+}
+
+// average folds sample into the moving average avg, or starts it with sample
+// when first is set.
+func average(avg, sample float64, first bool) float64 {
+	if first {
+		return sample
+	}
+	return avg + healthWeight*(sample-avg)
+}
+
+// medianLatency returns the median latency of the scored channels of the
+// pool other than ch, zero if there are none. The pool must be locked for
+// reading and no channel locked.
+func (t *grpcTransport) medianLatency(ch *grpcChannel) float64 {
+	var latencies []float64
+	for _, c := range t.channels {
+		if c == ch {
+			continue
+		}
+		c.mu.Lock()
+		if c.scored >= int64(t.health.MinCalls) && c.latency > 0 {
+			latencies = append(latencies, c.latency)
+		}
+		c.mu.Unlock()
+	}
+	if len(latencies) == 0 {
+		return 0
+	}
+	slices.Sort(latencies)
+	return latencies[len(latencies)/2]
+}
+
+// evict replaces ch in the pool with a new connection, because it is
+// unhealthy or else past its maximum age. ch is closed once its calls in
+// flight are done.
+func (t *grpcTransport) evict(ch *grpcChannel, unhealthy bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := slices.Index(t.channels, ch)
+	if i < 0 || ch.evicted.Load() {
+		return
+	}
+	replacement, err := t.dial()
+	if err != nil {
+		t.log(slog.LevelError, "failed to replace jams gRPC channel", "channel", i, "error", err)
+		return
+	}
+	if unhealthy {
+		ch.mu.Lock()
+		errorRate, latency := ch.errorRate, time.Duration(ch.latency)
+		ch.mu.Unlock()
+		t.log(slog.LevelWarn, "evicting unhealthy jams gRPC channel", "channel", i, "error_rate", errorRate, "latency", latency)
+		t.degraded.event(DegradedChannelEvicted, fmt.Errorf("unhealthy gRPC channel %d: error rate %.2f, latency %s", i, errorRate, latency))
+		t.evictions[i]++
+	} else {
+		t.log(slog.LevelDebug, "recycling jams gRPC channel past its maximum age", "channel", i)
+	}
+
+	t.channels[i] = replacement
+	ch.evicted.Store(true)
+	if ch.inFlight.Load() == 0 {
+		ch.close()
+	}
+}
+
+func (t *grpcTransport) log(level slog.Level, msg string, args ...any) {
+	if t.logger != nil {
+		t.logger.Log(context.Background(), level, msg, args...)
+	}
+}
+
+func (ch *grpcChannel) close() error {
+	var err error
+	ch.closeOnce.Do(func() {
+		err = ch.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,73 @@
+//go:build !jams_nogrpc && !js
+
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestChannelStatusCalls(t *testing.T) {
+	transport := &grpcTransport{channels: []*grpcChannel{{}}, evictions: []int{0}}
+	client := newClient(transport, defaultOptions())
+	for _, err := range []error{
+		nil,
+		status.Error(codes.Unavailable, "unavailable"),
+		status.Error(codes.InvalidArgument, "invalid input"),
+		status.Error(codes.NotFound, "unknown model"),
+		context.Canceled,
+		errors.New("failed"),
+	} {
+		_, done := transport.channel()
+		done(err)
+	}
+	_, done := transport.channel()
+
+	statuses := client.Channels()
+	if len(statuses) != 1 {
+		t.Fatalf("got %d channels, want 1", len(statuses))
+	}
+	// all the calls count, only those telling about the connection are in
+	// the error rate
+	if s := statuses[0]; s.Calls != 6 || s.InFlight != 1 || s.ErrorRate != 0.1 {
+		t.Fatalf("got %+v, want 6 calls, 1 in flight and an error rate of 0.1", s)
+	}
+	done(nil)
+	if calls := client.Channels()[0].Calls; calls != 7 {
+		t.Fatalf("got %d calls, want 7", calls)
+	}
+}
+
+func TestChannelHealthFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantEvict bool
+	}{
+		{name: "unavailable", err: status.Error(codes.Unavailable, "connection refused"), wantEvict: true},
+		{name: "internal", err: status.Error(codes.Internal, "unknown model")},
+		{name: "invalid argument", err: status.Error(codes.InvalidArgument, "invalid input")},
+		{name: "deadline exceeded", err: status.Error(codes.DeadlineExceeded, "deadline exceeded")},
+		{name: "canceled", err: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := defaultOptions()
+			WithChannelHealth(ChannelHealth{})(o)
+			transport := &grpcTransport{channels: []*grpcChannel{{}}, evictions: []int{0}, health: o.channelHealth}
+			ch := transport.channels[0]
+			evicted := false
+			for i := 0; i < 100 && !evicted; i++ {
+				evicted = transport.observe(ch, time.Millisecond, tt.err)
+			}
+			if evicted != tt.wantEvict {
+				t.Fatalf("evicted %v after %v errors, want %v", evicted, tt.err, tt.wantEvict)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+// Package postprocess maps regression outputs from model space back to
+// business units on the client, e.g. undoing a log transform of the target
+// and clipping the result to a valid range.
+//
+// Pipelines are usually loaded from a profile mapping model names to their
+// postprocessing and registered with the client, so that Predict applies them
+// automatically:
+//
+//	profile, err := postprocess.LoadProfile("postprocessing.yaml")
+//	opts, err := profile.Options()
+//	client, err := jams.NewHTTPClient("http://localhost:3000", opts...)
+//
+// Steps transform every column of an output; per column parameters apply to
+// the columns in order. NaN values are kept.
+package postprocess
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Step transforms a prediction. Apply must not modify its argument.
+type Step interface {
+	Apply(p *types.Prediction) (*types.Prediction, error)
+}
+
+// Pipeline applies steps in order. It implements jams.Postprocessor.
+type Pipeline []Step
+
+// Apply implements Step.
+func (p Pipeline) Apply(prediction *types.Prediction) (*types.Prediction, error) {
+	for _, step := range p {
+		var err error
+		if prediction, err = step.Apply(prediction); err != nil {
+			return nil, err
+		}
+	}
+	return prediction, nil
+}
+
+// Clip limits the values of an output to [Min, Max]. A nil bound leaves that
+// side unbounded.
+type Clip struct {
+	// Output defaults to types.DefaultOutput.
+	Output string
+	Min    *float64
+	Max    *float64
+}
+
+// Apply implements Step.
+func (s *Clip) Apply(p *types.Prediction) (*types.Prediction, error) {
+	return transform(p, s.Output, func(_ int, y float64) float64 {
+		if s.Min != nil && y < *s.Min {
+			y = *s.Min
+		}
+		if s.Max != nil && y > *s.Max {
+			y = *s.Max
+		}
+		return y
+	})
+}
+
+// Expm1 computes exp(y) - 1, inverting a log1p transform of the target.
+type Expm1 struct {
+	Output string
+}
+
+// Apply implements Step.
+func (s *Expm1) Apply(p *types.Prediction) (*types.Prediction, error) {
+	return transform(p, s.Output, func(_ int, y float64) float64 {
+		return math.Expm1(y)
+	})
+}
+
+// Exp computes exp(y), inverting a log transform of the target.
+type Exp struct {
+	Output string
+}
+
+// Apply implements Step.
+func (s *Exp) Apply(p *types.Prediction) (*types.Prediction, error) {
+	return transform(p, s.Output, func(_ int, y float64) float64 {
+		return math.Exp(y)
+	})
+}
+
+// InverseStandardScaler computes y * Scale[i] + Mean[i], inverting a
+// scikit-learn StandardScaler fitted on the target. A single Mean or Scale
+// applies to every column.
+type InverseStandardScaler struct {
+	Output string
+	Mean   []float64
+	Scale  []float64
+}
+
+// Apply implements Step.
+func (s *InverseStandardScaler) Apply(p *types.Prediction) (*types.Prediction, error) {
+	if len(s.Mean) == 0 || len(s.Scale) == 0 {
+		return nil, errors.New("inverse standard scaler: mean and scale are required")
+	}
+	return transform(p, s.Output, func(i int, y float64) float64 {
+		return y*param(s.Scale, i) + param(s.Mean, i)
+	})
+}
+
+// Linear computes y * Multiplier + Offset, e.g. converting the California
+// housing target from units of $100,000 to dollars.
+type Linear struct {
+	Output     string
+	Multiplier float64
+	Offset     float64
+}
+
+// Apply implements Step.
+func (s *Linear) Apply(p *types.Prediction) (*types.Prediction, error) {
+	return transform(p, s.Output, func(_ int, y float64) float64 {
+		return y*s.Multiplier + s.Offset
+	})
+}
+
+// Spec is the serialized form of a Pipeline:
+//
+//	{"steps": [
+//		{"type": "expm1"},
+//		{"type": "linear", "multiplier": 100000},
+//		{"type": "clip", "min": 0}
+//	]}
+//
+// Step types are "clip", "exp", "expm1", "inverse_standard_scaler", "linear"
+// and "heads", which splits the output of a multi-head model with
+// types.Heads.
+type Spec struct {
+	Steps []StepSpec `json:"steps" yaml:"steps"`
+}
+
+// StepSpec configures a single step. Output defaults to types.DefaultOutput.
+type StepSpec struct {
+	Type   string `json:"type" yaml:"type"`
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+	// Min and Max are the bounds of "clip".
+	Min *float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+	// Mean and Scale are the scikit-learn mean_ and scale_ of
+	// "inverse_standard_scaler".
+	Mean  []float64 `json:"mean,omitempty" yaml:"mean,omitempty"`
+	Scale []float64 `json:"scale,omitempty" yaml:"scale,omitempty"`
+	// Multiplier, defaulting to 1, and Offset configure "linear".
+	Multiplier *float64 `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	Offset     float64  `json:"offset,omitempty" yaml:"offset,omitempty"`
+	// Heads are the heads of "heads", in column order.
+	Heads []types.Head `json:"heads,omitempty" yaml:"heads,omitempty"`
+}
+
+// Build builds the pipeline described by the spec.
+func (s Spec) Build() (Pipeline, error) {
+	pipeline := make(Pipeline, 0, len(s.Steps))
+	for i, spec := range s.Steps {
+		step, err := spec.Build()
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		pipeline = append(pipeline, step)
+	}
+	return pipeline, nil
+}
+
+// Build builds the step described by the spec.
+func (s StepSpec) Build() (Step, error) {
+	switch s.Type {
+	case "clip":
+		if s.Min == nil && s.Max == nil {
+			return nil, errors.New("clip requires a min or a max")
+		}
+		return &Clip{Output: s.Output, Min: s.Min, Max: s.Max}, nil
+	case "exp":
+		return &Exp{Output: s.Output}, nil
+	case "expm1":
+		return &Expm1{Output: s.Output}, nil
+	case "inverse_standard_scaler":
+		if len(s.Mean) == 0 || len(s.Scale) == 0 {
+			return nil, errors.New("inverse standard scaler requires a mean and a scale")
+		}
+		return &InverseStandardScaler{Output: s.Output, Mean: s.Mean, Scale: s.Scale}, nil
+	case "linear":
+		linear := &Linear{Output: s.Output, Multiplier: 1, Offset: s.Offset}
+		if s.Multiplier != nil {
+			linear.Multiplier = *s.Multiplier
+		}
+		return linear, nil
+	case "heads":
+		if len(s.Heads) == 0 {
+			return nil, errors.New("heads requires at least one head")
+		}
+		return types.Heads{Output: s.Output, Heads: s.Heads}, nil
+	case "":
+		return nil, errors.New("step type is required")
+	}
+	return nil, fmt.Errorf("unknown step type %q", s.Type)
+}
+
+// Profile maps model names to the postprocessing applied to their
+// predictions.
+//
+//	models:
+//	  california_housing:
+//	    steps:
+//	      - type: linear
+//	        multiplier: 100000
+//	      - type: clip
+//	        min: 0
+type Profile struct {
+	Models map[string]Spec `json:"models" yaml:"models"`
+}
+
+// LoadProfile reads a profile from a YAML file, or a JSON file when its
+// extension is .json.
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var profile Profile
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &profile)
+	} else {
+		err = yaml.Unmarshal(data, &profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse postprocessing profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// Options builds the pipeline of every model and returns the client options
+// registering them.
+func (p *Profile) Options() ([]jams.Option, error) {
+	opts := make([]jams.Option, 0, len(p.Models))
+	for model, spec := range p.Models {
+		pipeline, err := spec.Build()
+		if err != nil {
+			return nil, fmt.Errorf("model %q: %w", model, err)
+		}
+		opts = append(opts, jams.WithPostprocessor(model, pipeline))
+	}
+	return opts, nil
+}
+
+// transform applies fn to every value of the output, fn receiving the column
+// index, and returns a copy of the prediction with the transformed output.
+func transform(p *types.Prediction, output string, fn func(i int, y float64) float64) (*types.Prediction, error) {
+	if output == "" {
+		output = types.DefaultOutput
+	}
+	rows, ok := p.Output(output)
+	if !ok {
+		return nil, fmt.Errorf("prediction has no output %q", output)
+	}
+	out := *p
+	out.Outputs = make(map[string][][]float64, len(p.Outputs))
+	for name, values := range p.Outputs {
+		out.Outputs[name] = values
+	}
+	transformed := make([][]float64, len(rows))
+	for n, row := range rows {
+		transformed[n] = make([]float64, len(row))
+		for i, y := range row {
+			if !math.IsNaN(y) {
+				y = fn(i, y)
+			}
+			transformed[n][i] = y
+		}
+	}
+	out.Outputs[output] = transformed
+	return &out, nil
+}
+
+// param returns the parameter of column i, a single parameter applying to
+// every column.
+func param(params []float64, i int) float64 {
+	if len(params) == 1 {
+		return params[0]
+	}
+	if i < len(params) {
+		return params[i]
+	}
+	return math.NaN()
+}
@@ -0,0 +1,321 @@
+// Package preprocess scales and imputes numeric features on the client, using
+// parameters fitted elsewhere, e.g. exported from scikit-learn.
+//
+// Pipelines are usually loaded from a profile mapping model names to their
+// preprocessing and registered with the client, so that Predict applies them
+// automatically:
+//
+//	profile, err := preprocess.LoadProfile("preprocessing.yaml")
+//	opts, err := profile.Options()
+//	client, err := jams.NewHTTPClient("http://localhost:3000", opts...)
+//
+// Missing values are nil or NaN. Processed columns hold float values.
+package preprocess
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/encoding"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Step transforms a model input. Apply must not modify its argument.
+// encoding.Encoder values are steps too.
+type Step interface {
+	Apply(in *types.Input) (*types.Input, error)
+}
+
+// Pipeline applies steps in order. It implements jams.Preprocessor.
+type Pipeline []Step
+
+// Apply implements Step.
+func (p Pipeline) Apply(in *types.Input) (*types.Input, error) {
+	for _, step := range p {
+		var err error
+		if in, err = step.Apply(in); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// StandardScaler computes (x - Mean[i]) / Scale[i] for every column, matching
+// scikit-learn's StandardScaler mean_ and scale_. A nil Mean or Scale skips
+// centering or scaling.
+type StandardScaler struct {
+	Columns []string
+	Mean    []float64
+	Scale   []float64
+}
+
+// Apply implements Step.
+func (s *StandardScaler) Apply(in *types.Input) (*types.Input, error) {
+	if err := checkLengths(s.Columns, s.Mean, s.Scale); err != nil {
+		return nil, fmt.Errorf("standard scaler: %w", err)
+	}
+	return transform(in, s.Columns, func(i int, x float64) float64 {
+		if s.Mean != nil {
+			x -= s.Mean[i]
+		}
+		if s.Scale != nil && s.Scale[i] != 0 {
+			x /= s.Scale[i]
+		}
+		return x
+	})
+}
+
+// MinMaxScaler computes x * Scale[i] + Min[i] for every column, matching
+// scikit-learn's MinMaxScaler scale_ and min_.
+type MinMaxScaler struct {
+	Columns []string
+	Min     []float64
+	Scale   []float64
+}
+
+// Apply implements Step.
+func (s *MinMaxScaler) Apply(in *types.Input) (*types.Input, error) {
+	if s.Min == nil || s.Scale == nil {
+		return nil, errors.New("min max scaler: min and scale are required")
+	}
+	if err := checkLengths(s.Columns, s.Min, s.Scale); err != nil {
+		return nil, fmt.Errorf("min max scaler: %w", err)
+	}
+	return transform(in, s.Columns, func(i int, x float64) float64 {
+		return x*s.Scale[i] + s.Min[i]
+	})
+}
+
+// Imputer replaces missing values of every column with Values[i], e.g. the
+// statistics_ of a fitted scikit-learn SimpleImputer or a constant. When
+// Values is nil the mean of the present values in the input is used.
+type Imputer struct {
+	Columns []string
+	Values  []float64
+}
+
+// Apply implements Step.
+func (s *Imputer) Apply(in *types.Input) (*types.Input, error) {
+	if err := checkLengths(s.Columns, s.Values); err != nil {
+		return nil, fmt.Errorf("imputer: %w", err)
+	}
+	out := in.Clone()
+	for i, name := range s.Columns {
+		values, err := floats(in, name)
+		if err != nil {
+			return nil, fmt.Errorf("imputer: %w", err)
+		}
+		fill := mean(values)
+		if s.Values != nil {
+			fill = s.Values[i]
+		}
+		for n, v := range values {
+			if math.IsNaN(v) {
+				values[n] = fill
+			}
+		}
+		out.AddFloats(name, values...)
+	}
+	return out, nil
+}
+
+// Spec is the serialized form of a Pipeline:
+//
+//	{"steps": [
+//		{"type": "simple_imputer", "columns": ["age"], "statistics": [29.7]},
+//		{"type": "standard_scaler", "columns": ["age", "fare"], "mean": [29.7, 32.2], "scale": [13.0, 49.7]},
+//		{"type": "one_hot", "column": "sex", "categories": ["male", "female"]}
+//	]}
+//
+// Besides "standard_scaler", "min_max_scaler", "simple_imputer" and "coerce",
+// which usually comes last, step types include the encoders of the encoding
+// package.
+type Spec struct {
+	Steps []StepSpec `json:"steps" yaml:"steps"`
+}
+
+// StepSpec configures a single step. Columns lists the processed columns,
+// i.e. scikit-learn's feature_names_in_. The scaler and imputer fields are
+// named after the scikit-learn attributes they hold.
+type StepSpec struct {
+	encoding.EncoderSpec `yaml:",inline"`
+
+	Columns []string  `json:"columns,omitempty" yaml:"columns,omitempty"`
+	Mean    []float64 `json:"mean,omitempty" yaml:"mean,omitempty"`
+	Scale   []float64 `json:"scale,omitempty" yaml:"scale,omitempty"`
+	Min     []float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	// Strategy is the SimpleImputer strategy. "constant" fills with
+	// FillValue, any other strategy with Statistics or, without them, the
+	// mean of the input.
+	Strategy   string    `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	Statistics []float64 `json:"statistics,omitempty" yaml:"statistics,omitempty"`
+	FillValue  *float64  `json:"fill_value,omitempty" yaml:"fill_value,omitempty"`
+	// Framework and Types configure "coerce", converting the input to the
+	// types the model expects with a types.Schema.
+	Framework string                      `json:"framework,omitempty" yaml:"framework,omitempty"`
+	Types     map[string]types.ColumnType `json:"types,omitempty" yaml:"types,omitempty"`
+}
+
+// Build builds the pipeline described by the spec.
+func (s Spec) Build() (Pipeline, error) {
+	pipeline := make(Pipeline, 0, len(s.Steps))
+	for i, spec := range s.Steps {
+		step, err := spec.Build()
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		pipeline = append(pipeline, step)
+	}
+	return pipeline, nil
+}
+
+// Build builds the step described by the spec.
+func (s StepSpec) Build() (Step, error) {
+	switch s.Type {
+	case "standard_scaler", "min_max_scaler", "simple_imputer":
+		if len(s.Columns) == 0 {
+			return nil, errors.New("at least one column is required")
+		}
+	}
+	switch s.Type {
+	case "standard_scaler":
+		return &StandardScaler{Columns: s.Columns, Mean: s.Mean, Scale: s.Scale}, nil
+	case "min_max_scaler":
+		return &MinMaxScaler{Columns: s.Columns, Min: s.Min, Scale: s.Scale}, nil
+	case "simple_imputer":
+		imputer := &Imputer{Columns: s.Columns, Values: s.Statistics}
+		if s.Strategy == "constant" {
+			if s.FillValue == nil {
+				return nil, errors.New("constant imputation requires a fill_value")
+			}
+			imputer.Values = make([]float64, len(s.Columns))
+			for i := range imputer.Values {
+				imputer.Values[i] = *s.FillValue
+			}
+		}
+		return imputer, nil
+	case "coerce":
+		return types.Schema{Framework: s.Framework, Columns: s.Types}, nil
+	}
+	return s.EncoderSpec.Build()
+}
+
+// Profile maps model names to the preprocessing applied to their inputs.
+//
+//	models:
+//	  titanic_model:
+//	    steps:
+//	      - type: standard_scaler
+//	        columns: [age]
+//	        mean: [29.7]
+//	        scale: [14.5]
+type Profile struct {
+	Models map[string]Spec `json:"models" yaml:"models"`
+}
+
+// LoadProfile reads a profile from a YAML file, or a JSON file when its
+// extension is .json.
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var profile Profile
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &profile)
+	} else {
+		err = yaml.Unmarshal(data, &profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse preprocessing profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// Options builds the pipeline of every model and returns the client options
+// registering them.
+func (p *Profile) Options() ([]jams.Option, error) {
+	opts := make([]jams.Option, 0, len(p.Models))
+	for model, spec := range p.Models {
+		pipeline, err := spec.Build()
+		if err != nil {
+			return nil, fmt.Errorf("model %q: %w", model, err)
+		}
+		opts = append(opts, jams.WithPreprocessor(model, pipeline))
+	}
+	return opts, nil
+}
+
+// transform applies fn to every present value of the columns and returns the
+// result as float columns. Missing values are kept.
+func transform(in *types.Input, columns []string, fn func(i int, x float64) float64) (*types.Input, error) {
+	out := in.Clone()
+	for i, name := range columns {
+		values, err := floats(in, name)
+		if err != nil {
+			return nil, err
+		}
+		for n, v := range values {
+			if !math.IsNaN(v) {
+				values[n] = fn(i, v)
+			}
+		}
+		out.AddFloats(name, values...)
+	}
+	return out, nil
+}
+
+// floats returns the values of a numeric column with missing values as NaN.
+func floats(in *types.Input, name string) ([]float64, error) {
+	column, ok := in.Column(name)
+	if !ok {
+		return nil, fmt.Errorf("input has no column %q", name)
+	}
+	values := make([]float64, len(column))
+	for n, v := range column {
+		switch v := v.(type) {
+		case nil:
+			values[n] = math.NaN()
+		case int64:
+			values[n] = float64(v)
+		case float64:
+			values[n] = v
+		default:
+			return nil, fmt.Errorf("column %q: record %d is not numeric", name, n)
+		}
+	}
+	return values, nil
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	var n int
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// checkLengths checks that every non nil parameter list has one value per
+// column.
+func checkLengths(columns []string, params ...[]float64) error {
+	for _, p := range params {
+		if p != nil && len(p) != len(columns) {
+			return fmt.Errorf("expected %d parameters, got %d", len(columns), len(p))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package jams_client
+
+import (
+	"context"
+	"sync"
+)
+
+// Priority orders the predictions waiting for a slot when the concurrency of
+// the client is limited with WithMaxConcurrency.
+type Priority int
+
+const (
+	// PriorityInteractive is the priority of predictions by default.
+	PriorityInteractive Priority = iota
+	// PriorityBatch is the priority of the chunks of PredictBatch. Batch
+	// predictions only get a slot when no interactive prediction waits.
+	PriorityBatch
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose predictions have priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// WithMaxConcurrency limits the predictions in flight to n, queueing the
+// others by priority then arrival. Interactive predictions overtake the
+// queued chunks of PredictBatch, which bounds their latency while a backfill
+// runs through the same client. Cached predictions do not take a slot.
+func WithMaxConcurrency(n int) Option {
+	return func(o *options) {
+		o.maxConcurrency = n
+	}
+}
+
+// admission hands out the slots of WithMaxConcurrency.
+type admission struct {
+	mu   sync.Mutex
+	free int
+	// waiting holds the queued predictions of each priority, first come
+	// first. A slot is handed over by closing the channel.
+	waiting [PriorityBatch + 1][]chan struct{}
+}
+
+func newAdmission(n int) *admission {
+	if n <= 0 {
+		return nil
+	}
+	return &admission{free: n}
+}
+
+// acquire waits for a slot until ctx is done.
+func (a *admission) acquire(ctx context.Context) error {
+	if a == nil {
+		return nil
+	}
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	p = min(max(p, PriorityInteractive), PriorityBatch)
+
+	a.mu.Lock()
+	if a.free > 0 && a.queued(p) == 0 {
+		a.free--
+		a.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	a.waiting[p] = append(a.waiting[p], ready)
+	a.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		for i, w := range a.waiting[p] {
+			if w == ready {
+				a.waiting[p] = append(a.waiting[p][:i:i], a.waiting[p][i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// the slot was handed over meanwhile
+		a.releaseLocked()
+		return ctx.Err()
+	}
+}
+
+// queued returns the number of predictions of priority p or higher waiting.
+func (a *admission) queued(p Priority) int {
+	n := 0
+	for q := PriorityInteractive; q <= p; q++ {
+		n += len(a.waiting[q])
+	}
+	return n
+}
+
+// release returns a slot, handing it to the first prediction waiting.
+func (a *admission) release() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.releaseLocked()
+}
+
+func (a *admission) releaseLocked() {
+	for p := range a.waiting {
+		if len(a.waiting[p]) > 0 {
+			close(a.waiting[p][0])
+			a.waiting[p] = a.waiting[p][1:]
+			return
+		}
+	}
+	a.free++
+}
@@ -0,0 +1,155 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// queuedCalls waits until n predictions of priority p or higher wait for a
+// slot of client.
+func queuedCalls(t *testing.T, client *Client, p Priority, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		client.admission.mu.Lock()
+		queued := client.admission.queued(p)
+		client.admission.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d predictions queued, want %d", queued, n)
+		}
+	}
+}
+
+// gatedTransport returns a transport whose predictions each wait for a value
+// from gate, or for gate to be closed, and records the first index of their
+// inputs, in the order they are sent.
+func gatedTransport(gate chan struct{}) (*fakeTransport, func() []int) {
+	var (
+		mu    sync.Mutex
+		order []int
+	)
+	transport := &fakeTransport{predictFunc: func(_ context.Context, _, input string) (string, error) {
+		mu.Lock()
+		order = append(order, firstIndex(input))
+		mu.Unlock()
+		<-gate
+		return echo(input)
+	}}
+	return transport, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(order)
+	}
+}
+
+// predictIndex makes a prediction for a single record of index i.
+func predictIndex(ctx context.Context, client *Client, i int) error {
+	_, err := client.Predict(ctx, "m", types.NewInput().AddFloats("i", float64(i)))
+	return err
+}
+
+func TestPriorityOrder(t *testing.T) {
+	gate := make(chan struct{})
+	transport, order := gatedTransport(gate)
+	client := newTestClient(transport, WithMaxConcurrency(1))
+	batch := WithPriority(context.Background(), PriorityBatch)
+
+	var wg sync.WaitGroup
+	predict := func(ctx context.Context, i int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := predictIndex(ctx, client, i); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// 0 holds the slot, batch predictions queue before interactive ones
+	predict(context.Background(), 0)
+	for transport.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i, ctx := range []context.Context{batch, batch, batch, context.Background(), context.Background()} {
+		predict(ctx, i+1)
+		queuedCalls(t, client, PriorityBatch, i+1)
+	}
+	close(gate)
+	wg.Wait()
+
+	if want := []int{0, 4, 5, 1, 2, 3}; !slices.Equal(order(), want) {
+		t.Fatalf("predictions sent in the order %v, want %v", order(), want)
+	}
+}
+
+func TestPriorityContention(t *testing.T) {
+	const slots, interactive, batch = 3, 40, 40
+	gate := make(chan struct{})
+	transport, order := gatedTransport(gate)
+	client := newTestClient(transport, WithMaxConcurrency(slots))
+
+	var wg sync.WaitGroup
+	predict := func(ctx context.Context, i int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := predictIndex(ctx, client, i); err != nil && !errors.Is(err, context.Canceled) {
+				t.Error(err)
+			}
+		}()
+	}
+	// the slots are taken by batch predictions, which the interactive ones
+	// queued afterwards overtake, some of them being cancelled while queued
+	for i := 0; i < slots; i++ {
+		predict(WithPriority(context.Background(), PriorityBatch), 1000+i)
+	}
+	for transport.calls.Load() < slots {
+		time.Sleep(time.Millisecond)
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	for i := 0; i < batch; i++ {
+		predict(WithPriority(context.Background(), PriorityBatch), 1000+slots+i)
+	}
+	for i := 0; i < interactive; i++ {
+		ctx := context.Background()
+		if i%4 == 0 {
+			ctx = cancelled
+		}
+		predict(ctx, i)
+	}
+	queuedCalls(t, client, PriorityBatch, batch+interactive)
+	cancel()
+	queued := batch + interactive - interactive/4
+	queuedCalls(t, client, PriorityBatch, queued)
+	// predictions are let through one at a time, so that they are sent in
+	// the order they got a slot
+	for n := int64(slots); n < slots+int64(queued); n++ {
+		gate <- struct{}{}
+		for transport.calls.Load() == n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(gate)
+	wg.Wait()
+
+	sent := order()[slots:]
+	if len(sent) != queued {
+		t.Fatalf("sent %d queued predictions, want %d", len(sent), queued)
+	}
+	for k, i := range sent {
+		if interactiveSent := i < 1000; interactiveSent != (k < interactive-interactive/4) {
+			t.Fatalf("batch predictions sent before interactive ones: %v", sent)
+		}
+	}
+	// every slot was returned
+	if client.admission.free != slots {
+		t.Fatalf("%d slots free, want %d", client.admission.free, slots)
+	}
+}
@@ -0,0 +1,51 @@
+package jams_client
+
+import (
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// Profiling labels set by WithProfilingLabels.
+const (
+	ProfilingLabelMethod = "jams_method"
+	ProfilingLabelModel  = "jams_model"
+)
+
+// WithProfilingLabels tags the goroutine making each call with the pprof
+// labels jams_method and jams_model, and wraps the call in a runtime/trace
+// region named after its method and model, e.g. "jams.Predict churn", so
+// that CPU profiles and execution traces of the service attribute the time
+// spent in the client to models. Predict calls are labelled as a whole,
+// preprocessing and parsing included.
+//
+//	go tool pprof -tagfocus jams_model=churn cpu.pprof
+func WithProfilingLabels() Option {
+	return func(o *options) {
+		o.profilingLabels = true
+	}
+}
+
+// profiled runs call with the profiling labels and trace region of method
+// and model, if enabled and not already set by an enclosing call, e.g. the
+// Predict call of an invoke.
+func (c *Client) profiled(ctx context.Context, method, model string, call func(ctx context.Context)) {
+	if !c.opts.profilingLabels {
+		call(ctx)
+		return
+	}
+	if _, ok := pprof.Label(ctx, ProfilingLabelMethod); ok {
+		call(ctx)
+		return
+	}
+	labels := pprof.Labels(ProfilingLabelMethod, method, ProfilingLabelModel, model)
+	pprof.Do(ctx, labels, func(ctx context.Context) {
+		name := "jams." + method
+		if model != "" {
+			name += " " + model
+		}
+		trace.WithRegion(ctx, name, func() {
+			call(ctx)
+		})
+	})
+}
@@ -0,0 +1,201 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrQuotaExceeded matches every *QuotaError with errors.Is.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota limits the predictions made through a client over a time window. Each
+// limit is enforced as a token bucket: up to Requests calls and Rows records
+// may be sent at once, and the budget refills evenly over Window. Zero limits
+// are unlimited.
+type Quota struct {
+	Requests int
+	Rows     int
+	Window   time.Duration
+	// Wait queues predictions exceeding the quota until there is room or their
+	// context is done, instead of failing them with a *QuotaError.
+	Wait bool
+	// PerTeam gives every team set with WithTeam a budget of its own instead
+	// of sharing one between all callers.
+	PerTeam bool
+}
+
+// QuotaError is returned by Predict when a prediction exceeds a quota set with
+// WithQuota or WithTeamQuota.
+type QuotaError struct {
+	Model string
+	Team  string
+	// Limit is the exceeded limit, "requests" or "rows".
+	Limit string
+	Quota Quota
+	// RetryAfter is how long until the prediction fits in the quota. It is
+	// zero when it never will, i.e. it has more rows than the quota allows.
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	limit := e.Quota.Requests
+	if e.Limit == "rows" {
+		limit = e.Quota.Rows
+	}
+	msg := fmt.Sprintf("quota of %d %s per %s exceeded for model %q", limit, e.Limit, e.Quota.Window, e.Model)
+	if e.Team != "" {
+		msg += fmt.Sprintf(" and team %q", e.Team)
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %s", e.RetryAfter)
+	}
+	return msg
+}
+
+// Is reports whether target is ErrQuotaExceeded.
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// WithQuota applies q to the predictions of the named model, or of every model
+// when modelName is empty. Cached predictions do not count. Calls matching
+// several quotas must fit in all of them.
+func WithQuota(modelName string, q Quota) Option {
+	return func(o *options) {
+		o.quotas = append(o.quotas, newQuotaRule(modelName, "", q))
+	}
+}
+
+// WithTeamQuota applies q to the predictions made by the named team, as set
+// with WithTeam, across all models.
+func WithTeamQuota(team string, q Quota) Option {
+	return func(o *options) {
+		o.quotas = append(o.quotas, newQuotaRule("", team, q))
+	}
+}
+
+// quotaRule holds the token buckets of a quota, one per team when the quota is
+// per team.
+type quotaRule struct {
+	model string
+	team  string
+	quota Quota
+
+	mu       sync.Mutex
+	limiters map[string]*quotaLimiters
+}
+
+type quotaLimiters struct {
+	requests *rate.Limiter
+	rows     *rate.Limiter
+}
+
+func newQuotaRule(model, team string, q Quota) *quotaRule {
+	return &quotaRule{model: model, team: team, quota: q, limiters: make(map[string]*quotaLimiters)}
+}
+
+func (r *quotaRule) matches(model, team string) bool {
+	return (r.model == "" || r.model == model) && (r.team == "" || r.team == team)
+}
+
+func (r *quotaRule) limitersFor(team string) *quotaLimiters {
+	if !r.quota.PerTeam {
+		team = ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.limiters[team]
+	if !ok {
+		l = &quotaLimiters{
+			requests: newQuotaLimiter(r.quota.Requests, r.quota.Window),
+			rows:     newQuotaLimiter(r.quota.Rows, r.quota.Window),
+		}
+		r.limiters[team] = l
+	}
+	return l
+}
+
+func newQuotaLimiter(n int, window time.Duration) *rate.Limiter {
+	if n <= 0 || window <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(float64(n)/window.Seconds()), n)
+}
+
+// acquireQuota takes a prediction of the model, with the rows and team found
+// in ctx, out of every matching quota. It waits when all exceeded quotas allow
+// it, and otherwise returns a *QuotaError without using any quota.
+func (c *Client) acquireQuota(ctx context.Context, model string) error {
+	if len(c.opts.quotas) == 0 {
+		return nil
+	}
+	team, _ := ctx.Value(teamKey{}).(string)
+	rows, _ := ctx.Value(rowsKey{}).(int)
+
+	now := time.Now()
+	var (
+		reservations []*rate.Reservation
+		wait         time.Duration
+		exceeded     *QuotaError
+		reject       bool
+	)
+	// cancelling at now returns the tokens even when the reservations were
+	// ready at once, which Cancel, being called later, would keep
+	cancel := func() {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+	for _, rule := range c.opts.quotas {
+		if !rule.matches(model, team) {
+			continue
+		}
+		l := rule.limitersFor(team)
+		for _, limit := range []struct {
+			name    string
+			limiter *rate.Limiter
+			n       int
+		}{
+			{"requests", l.requests, 1},
+			{"rows", l.rows, rows},
+		} {
+			if limit.limiter == nil || limit.n == 0 {
+				continue
+			}
+			err := &QuotaError{Model: model, Team: team, Limit: limit.name, Quota: rule.quota}
+			r := limit.limiter.ReserveN(now, limit.n)
+			if !r.OK() {
+				cancel()
+				return err
+			}
+			reservations = append(reservations, r)
+			delay := r.DelayFrom(now)
+			if delay <= 0 {
+				continue
+			}
+			reject = reject || !rule.quota.Wait
+			if delay > wait {
+				wait, exceeded = delay, err
+				err.RetryAfter = delay
+			}
+		}
+	}
+	if wait == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); reject || (ok && deadline.Before(now.Add(wait))) {
+		cancel()
+		return exceeded
+	}
+	if !sleep(ctx, wait) {
+		cancel()
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// predictConcurrently makes n predictions of input at once, returning their
+// errors.
+func predictConcurrently(ctx context.Context, client *Client, n, rows int) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = client.Predict(ctx, "m", indexInput(rows))
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// quotaErrors returns the number of nil errors and the *QuotaErrors of errs,
+// failing on other errors.
+func quotaErrors(t *testing.T, errs []error) (ok int, exceeded []*QuotaError) {
+	t.Helper()
+	for _, err := range errs {
+		var quotaErr *QuotaError
+		switch {
+		case err == nil:
+			ok++
+		case errors.As(err, &quotaErr) && errors.Is(err, ErrQuotaExceeded):
+			exceeded = append(exceeded, quotaErr)
+		default:
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	return ok, exceeded
+}
+
+func TestQuotaBurst(t *testing.T) {
+	tests := []struct {
+		name      string
+		quota     Quota
+		calls     int
+		rows      int
+		wantOK    int
+		wantLimit string
+	}{
+		{name: "requests", quota: Quota{Requests: 5, Window: time.Minute}, calls: 20, rows: 1, wantOK: 5, wantLimit: "requests"},
+		{name: "rows", quota: Quota{Rows: 10, Window: time.Minute}, calls: 20, rows: 4, wantOK: 2, wantLimit: "rows"},
+		{name: "both", quota: Quota{Requests: 5, Rows: 100, Window: time.Minute}, calls: 20, rows: 30, wantOK: 3, wantLimit: "rows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &fakeTransport{}
+			client := newTestClient(transport, WithQuota("m", tt.quota))
+			ok, exceeded := quotaErrors(t, predictConcurrently(context.Background(), client, tt.calls, tt.rows))
+			if ok != tt.wantOK || transport.calls.Load() != int64(tt.wantOK) {
+				t.Fatalf("%d calls succeeded and %d were sent, want %d", ok, transport.calls.Load(), tt.wantOK)
+			}
+			for _, err := range exceeded {
+				if err.Limit != tt.wantLimit || err.Model != "m" || err.RetryAfter <= 0 || err.RetryAfter > tt.quota.Window {
+					t.Fatalf("got %+v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestQuotaRefill(t *testing.T) {
+	transport := &fakeTransport{}
+	quota := Quota{Requests: 4, Window: 200 * time.Millisecond}
+	client := newTestClient(transport, WithQuota("", quota))
+	ctx := context.Background()
+
+	ok, exceeded := quotaErrors(t, predictConcurrently(ctx, client, 8, 1))
+	if ok != 4 || len(exceeded) != 4 {
+		t.Fatalf("%d calls of the burst succeeded, want 4", ok)
+	}
+	// a call is refilled every 50ms
+	retryAfter := exceeded[0].RetryAfter
+	for _, err := range exceeded {
+		retryAfter = min(retryAfter, err.RetryAfter)
+	}
+	if retryAfter > 50*time.Millisecond {
+		t.Fatalf("retry after %s, want at most 50ms", retryAfter)
+	}
+	time.Sleep(retryAfter)
+	if _, err := client.Predict(ctx, "m", indexInput(1)); err != nil {
+		t.Fatalf("call after %s failed: %v", retryAfter, err)
+	}
+	time.Sleep(quota.Window)
+	ok, _ = quotaErrors(t, predictConcurrently(ctx, client, 8, 1))
+	if ok != 4 {
+		t.Fatalf("%d calls succeeded after a window, want the burst of 4", ok)
+	}
+}
+
+func TestQuotaTooManyRows(t *testing.T) {
+	client := newTestClient(&fakeTransport{}, WithQuota("m", Quota{Rows: 10, Window: time.Minute, Wait: true}))
+	_, err := client.Predict(context.Background(), "m", indexInput(11))
+	var quotaErr *QuotaError
+	if !errors.As(err, &quotaErr) || quotaErr.RetryAfter != 0 {
+		t.Fatalf("got error %v, want a *QuotaError never fitting", err)
+	}
+	// the rejected call took nothing out of the quota
+	if _, err := client.Predict(context.Background(), "m", indexInput(10)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuotaWait(t *testing.T) {
+	transport := &fakeTransport{}
+	quota := Quota{Requests: 2, Window: 100 * time.Millisecond, Wait: true}
+	client := newTestClient(transport, WithQuota("m", quota))
+
+	start := time.Now()
+	ok, _ := quotaErrors(t, predictConcurrently(context.Background(), client, 6, 1))
+	// the 4 calls after the burst wait 50ms each
+	if elapsed := time.Since(start); ok != 6 || elapsed < 150*time.Millisecond {
+		t.Fatalf("%d calls succeeded in %s, want 6 in at least 150ms", ok, elapsed)
+	}
+
+	// calls whose deadline is too close fail without waiting
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	predictConcurrently(context.Background(), client, 2, 1)
+	if _, err := client.Predict(ctx, "m", indexInput(1)); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("got error %v, want ErrQuotaExceeded", err)
+	}
+}
+
+func TestQuotaPerTeam(t *testing.T) {
+	quota := Quota{Requests: 3, Window: time.Minute, PerTeam: true}
+	client := newTestClient(&fakeTransport{}, WithQuota("", quota), WithTeamQuota("c", Quota{Requests: 1, Window: time.Minute}))
+	for _, tt := range []struct {
+		team   string
+		wantOK int
+	}{
+		{"a", 3},
+		{"b", 3},
+		{"c", 1},
+		{"", 3},
+	} {
+		ok, exceeded := quotaErrors(t, predictConcurrently(WithTeam(context.Background(), tt.team), client, 10, 1))
+		if ok != tt.wantOK {
+			t.Fatalf("team %q: %d calls succeeded, want %d", tt.team, ok, tt.wantOK)
+		}
+		for _, err := range exceeded {
+			if err.Team != tt.team {
+				t.Fatalf("team %q: got %+v", tt.team, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,485 @@
+// Package reconcile converges the models loaded into a J.A.M.S server towards a
+// declarative desired set. It is the building block for operators and
+// manifest based tooling.
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// ErrBreakingChange is returned by Plan when a model would be reloaded with
+// breaking changes to its inputs, see ModelSet.BreakingChanges.
+var ErrBreakingChange = errors.New("breaking input schema change")
+
+// ModelAPI is the subset of the client used by the reconciler. *jams.Client
+// implements it.
+type ModelAPI interface {
+	GetModels(ctx context.Context) ([]jams.ModelMetadata, error)
+	AddModel(ctx context.Context, modelName string) error
+	UpdateModel(ctx context.Context, modelName string) error
+	DeleteModel(ctx context.Context, modelName string) error
+}
+
+// Model is the desired state of a single model.
+type Model struct {
+	// Name of the model as served, e.g. "titanic_model".
+	Name string `json:"name" yaml:"name"`
+	// Framework of the model, e.g. "catboost".
+	Framework string `json:"framework" yaml:"framework"`
+	// Artifact is the name of the artefact in the model store. Defaults to
+	// "<framework>-<name>".
+	Artifact string `json:"artifact,omitempty" yaml:"artifact,omitempty"`
+	// Generation is bumped to request a reload of the model from the model
+	// store, similar to metadata.generation on Kubernetes objects.
+	Generation int64 `json:"generation,omitempty" yaml:"generation,omitempty"`
+	// Labels are free form metadata kept by the client.
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	// Inputs declares the input columns of the model and their types. The
+	// server does not report the inputs of models, so reloads are checked
+	// against the inputs declared when the model was last applied, or
+	// observed with Reconciler.Observe.
+	Inputs map[string]types.ColumnType `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+}
+
+// ArtifactName returns the artefact name used to add the model.
+func (m Model) ArtifactName() string {
+	if m.Artifact != "" {
+		return m.Artifact
+	}
+	return m.Framework + "-" + m.Name
+}
+
+// BreakingChangePolicy is how Plan treats breaking changes to the inputs of
+// reloaded models, i.e. removed or retyped columns, which would break the
+// callers of the model.
+type BreakingChangePolicy string
+
+// Breaking change policies.
+const (
+	// BreakingChangesRefuse fails the plan with ErrBreakingChange.
+	BreakingChangesRefuse BreakingChangePolicy = "refuse"
+	// BreakingChangesWarn plans the reload and reports the changes in the
+	// warnings of the plan.
+	BreakingChangesWarn BreakingChangePolicy = "warn"
+)
+
+// ModelSet is the desired set of models for a server.
+type ModelSet struct {
+	Models []Model `json:"models" yaml:"models"`
+	// Prune deletes models loaded into the server which are not in Models.
+	Prune bool `json:"prune,omitempty" yaml:"prune,omitempty"`
+	// BreakingChanges is the policy of breaking input changes, refuse by
+	// default.
+	BreakingChanges BreakingChangePolicy `json:"breaking_changes,omitempty" yaml:"breaking_changes,omitempty"`
+}
+
+// FromMetadata returns the ModelSet describing the given served models, e.g.
+// to capture the catalog of a server.
+func FromMetadata(models []jams.ModelMetadata) ModelSet {
+	set := ModelSet{Models: make([]Model, 0, len(models))}
+	for _, m := range models {
+		model := Model{Name: m.Name, Framework: m.Framework}
+		// models added through the API are served by their artefact name.
+		if strings.HasPrefix(m.Name, m.Framework+"-") {
+			model.Artifact = m.Name
+		}
+		set.Models = append(set.Models, model)
+	}
+	sort.Slice(set.Models, func(i, j int) bool {
+		return set.Models[i].Name < set.Models[j].Name
+	})
+	return set
+}
+
+// Validate checks that every model has a name and framework and that names
+// are unique.
+func (s ModelSet) Validate() error {
+	seen := make(map[string]bool, len(s.Models))
+	for i, m := range s.Models {
+		if m.Name == "" {
+			return fmt.Errorf("model %d: name is required", i)
+		}
+		if m.Framework == "" && m.Artifact == "" {
+			return fmt.Errorf("model %q: framework or artifact is required", m.Name)
+		}
+		if seen[m.Name] {
+			return fmt.Errorf("model %q: duplicate name", m.Name)
+		}
+		seen[m.Name] = true
+		for column, typ := range m.Inputs {
+			switch typ {
+			case "", types.Numeric, types.Integer, types.Categorical:
+			default:
+				return fmt.Errorf("model %q: column %q has unknown type %q", m.Name, column, typ)
+			}
+		}
+	}
+	switch s.BreakingChanges {
+	case "", BreakingChangesRefuse, BreakingChangesWarn:
+	default:
+		return fmt.Errorf("unknown breaking change policy %q", s.BreakingChanges)
+	}
+	return nil
+}
+
+// ActionType is the kind of change applied to a model.
+type ActionType string
+
+// Action types in the order they are applied.
+const (
+	ActionDelete ActionType = "delete"
+	ActionAdd    ActionType = "add"
+	ActionUpdate ActionType = "update"
+)
+
+// Action is a single change required to converge the server.
+type Action struct {
+	Type ActionType `json:"type"`
+	// Model is the desired model name, or the served name for orphans.
+	Model string `json:"model"`
+	// Target is the name sent to the server: the artefact name for adds and
+	// the served name otherwise.
+	Target string `json:"target"`
+	// Reason explains why the action is needed.
+	Reason string `json:"reason"`
+	// SchemaChanges are the changes to the inputs of a reloaded model.
+	SchemaChanges []types.SchemaChange `json:"schema_changes,omitempty"`
+
+	generation int64
+	inputs     map[string]types.ColumnType
+}
+
+// Plan is the ordered list of actions needed to converge the server.
+type Plan struct {
+	Actions []Action `json:"actions"`
+	// Warnings report the breaking input changes planned under
+	// BreakingChangesWarn.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Empty reports whether the server already matches the desired state.
+func (p Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// Phase is the reconciliation phase of a model.
+type Phase string
+
+// Model phases.
+const (
+	PhaseReady   Phase = "Ready"
+	PhaseFailed  Phase = "Failed"
+	PhaseDeleted Phase = "Deleted"
+)
+
+// Status reports the outcome of the last reconciliation of a model.
+type Status struct {
+	Model              string     `json:"model"`
+	Phase              Phase      `json:"phase"`
+	LastAction         ActionType `json:"last_action,omitempty"`
+	Error              string     `json:"error,omitempty"`
+	ObservedGeneration int64      `json:"observed_generation"`
+	LastReconciled     time.Time  `json:"last_reconciled"`
+}
+
+// Reconciler drives a server towards a desired ModelSet. It remembers the
+// generation and inputs last applied to each model so that bumping
+// Generation triggers a reload, checked for breaking input changes. It is
+// safe for concurrent use.
+type Reconciler struct {
+	api ModelAPI
+
+	mu       sync.Mutex
+	observed map[string]int64
+	inputs   map[string]map[string]types.ColumnType
+	statuses map[string]Status
+}
+
+// New returns a Reconciler using the given API.
+func New(api ModelAPI) *Reconciler {
+	return &Reconciler{
+		api:      api,
+		observed: make(map[string]int64),
+		inputs:   make(map[string]map[string]types.ColumnType),
+		statuses: make(map[string]Status),
+	}
+}
+
+// Observe records the generations and inputs of deployed as applied, e.g.
+// from the manifest of the previous release, for reconcilers which did not
+// apply it themselves. Plan then reloads the models whose generation was
+// bumped since, after checking their inputs.
+func (r *Reconciler) Observe(deployed ModelSet) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range deployed.Models {
+		r.observed[m.Name] = m.Generation
+		if m.Inputs != nil {
+			r.inputs[m.Name] = m.Inputs
+		}
+	}
+}
+
+// checkInputs returns the changes from the inputs last applied to m to its
+// desired inputs, and the breaking ones as an error under
+// BreakingChangesRefuse or as a warning of plan otherwise. The caller must
+// hold r.mu.
+func (r *Reconciler) checkInputs(m Model, policy BreakingChangePolicy, plan *Plan) ([]types.SchemaChange, error) {
+	applied, ok := r.inputs[m.Name]
+	if !ok || m.Inputs == nil {
+		return nil, nil
+	}
+	changes := types.Schema{Columns: applied}.Diff(types.Schema{Columns: m.Inputs})
+	var breaking []string
+	for _, c := range changes {
+		if c.Breaking() {
+			breaking = append(breaking, c.String())
+		}
+	}
+	if len(breaking) == 0 {
+		return changes, nil
+	}
+	if policy == BreakingChangesWarn {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("model %s: %s", m.Name, strings.Join(breaking, ", ")))
+		return changes, nil
+	}
+	return changes, fmt.Errorf("%w of model %s: %s", ErrBreakingChange, m.Name, strings.Join(breaking, ", "))
+}
+
+// Plan computes the actions needed to converge the server without applying
+// them.
+func (r *Reconciler) Plan(ctx context.Context, desired ModelSet) (Plan, error) {
+	if err := desired.Validate(); err != nil {
+		return Plan{}, err
+	}
+	current, err := r.api.GetModels(ctx)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to get models: %w", err)
+	}
+
+	served := make(map[string]jams.ModelMetadata, len(current))
+	for _, m := range current {
+		served[m.Name] = m
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var (
+		plan     Plan
+		breaking []error
+	)
+	claimed := make(map[string]bool, len(desired.Models))
+	for _, m := range desired.Models {
+		// models loaded on startup are served by name while models added
+		// through the API are served by their artefact name.
+		existing, ok := served[m.Name]
+		if !ok {
+			existing, ok = served[m.ArtifactName()]
+		}
+		if !ok {
+			plan.Actions = append(plan.Actions, Action{
+				Type:       ActionAdd,
+				Model:      m.Name,
+				Target:     m.ArtifactName(),
+				Reason:     "model is not loaded",
+				generation: m.Generation,
+				inputs:     m.Inputs,
+			})
+			continue
+		}
+		claimed[existing.Name] = true
+
+		if m.Framework != "" && existing.Framework != m.Framework {
+			changes, err := r.checkInputs(m, desired.BreakingChanges, &plan)
+			if err != nil {
+				breaking = append(breaking, err)
+			}
+			plan.Actions = append(plan.Actions,
+				Action{
+					Type:   ActionDelete,
+					Model:  m.Name,
+					Target: existing.Name,
+					Reason: fmt.Sprintf("framework changed from %s to %s", existing.Framework, m.Framework),
+				},
+				Action{
+					Type:          ActionAdd,
+					Model:         m.Name,
+					Target:        m.ArtifactName(),
+					Reason:        fmt.Sprintf("framework changed from %s to %s", existing.Framework, m.Framework),
+					SchemaChanges: changes,
+					generation:    m.Generation,
+					inputs:        m.Inputs,
+				},
+			)
+			continue
+		}
+
+		observed, known := r.observed[m.Name]
+		if known && m.Generation > observed {
+			changes, err := r.checkInputs(m, desired.BreakingChanges, &plan)
+			if err != nil {
+				breaking = append(breaking, err)
+			}
+			plan.Actions = append(plan.Actions, Action{
+				Type:          ActionUpdate,
+				Model:         m.Name,
+				Target:        existing.Name,
+				Reason:        fmt.Sprintf("generation changed from %d to %d", observed, m.Generation),
+				SchemaChanges: changes,
+				generation:    m.Generation,
+				inputs:        m.Inputs,
+			})
+		}
+	}
+	if len(breaking) > 0 {
+		return Plan{}, errors.Join(breaking...)
+	}
+
+	if desired.Prune {
+		for _, m := range current {
+			if claimed[m.Name] {
+				continue
+			}
+			plan.Actions = append(plan.Actions, Action{
+				Type:   ActionDelete,
+				Model:  m.Name,
+				Target: m.Name,
+				Reason: "model is not in the desired set",
+			})
+		}
+	}
+
+	sort.SliceStable(plan.Actions, func(i, j int) bool {
+		return actionOrder(plan.Actions[i].Type) < actionOrder(plan.Actions[j].Type)
+	})
+
+	// models which are already in sync are recorded as observed so later
+	// generation bumps are detected.
+	now := time.Now()
+	pending := make(map[string]bool, len(plan.Actions))
+	for _, a := range plan.Actions {
+		pending[a.Model] = true
+	}
+	for _, m := range desired.Models {
+		if pending[m.Name] {
+			continue
+		}
+		if _, known := r.observed[m.Name]; !known {
+			r.observed[m.Name] = m.Generation
+		}
+		if _, known := r.inputs[m.Name]; !known && m.Inputs != nil {
+			r.inputs[m.Name] = m.Inputs
+		}
+		r.statuses[m.Name] = Status{
+			Model:              m.Name,
+			Phase:              PhaseReady,
+			ObservedGeneration: r.observed[m.Name],
+			LastReconciled:     now,
+		}
+	}
+	return plan, nil
+}
+
+// Apply executes the actions of a plan. It keeps going after a failed action
+// and returns the first error encountered; Statuses reports the per model
+// outcome.
+func (r *Reconciler) Apply(ctx context.Context, plan Plan) error {
+	var firstErr error
+	for _, a := range plan.Actions {
+		var err error
+		switch a.Type {
+		case ActionAdd:
+			err = r.api.AddModel(ctx, a.Target)
+		case ActionUpdate:
+			err = r.api.UpdateModel(ctx, a.Target)
+		case ActionDelete:
+			err = r.api.DeleteModel(ctx, a.Target)
+		default:
+			err = fmt.Errorf("unknown action type %q", a.Type)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to %s model %s: %w", a.Type, a.Model, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		r.record(a, err)
+	}
+	return firstErr
+}
+
+// Reconcile plans and applies the changes needed to converge the server.
+func (r *Reconciler) Reconcile(ctx context.Context, desired ModelSet) (Plan, error) {
+	plan, err := r.Plan(ctx, desired)
+	if err != nil {
+		return Plan{}, err
+	}
+	return plan, r.Apply(ctx, plan)
+}
+
+// Statuses returns the status of every model reconciled so far, sorted by
+// name.
+func (r *Reconciler) Statuses() []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]Status, 0, len(r.statuses))
+	for _, s := range r.statuses {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Model < statuses[j].Model
+	})
+	return statuses
+}
+
+func (r *Reconciler) record(a Action, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.statuses[a.Model]
+	status.Model = a.Model
+	status.LastAction = a.Type
+	status.LastReconciled = time.Now()
+	switch {
+	case err != nil:
+		status.Phase = PhaseFailed
+		status.Error = err.Error()
+	case a.Type == ActionDelete:
+		status.Phase = PhaseDeleted
+		status.Error = ""
+		delete(r.observed, a.Model)
+		delete(r.inputs, a.Model)
+	default:
+		status.Phase = PhaseReady
+		status.Error = ""
+		r.observed[a.Model] = a.generation
+		if a.inputs != nil {
+			r.inputs[a.Model] = a.inputs
+		}
+		status.ObservedGeneration = a.generation
+	}
+	r.statuses[a.Model] = status
+}
+
+func actionOrder(t ActionType) int {
+	switch t {
+	case ActionDelete:
+		return 0
+	case ActionAdd:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,224 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// server is a J.A.M.S HTTP API serving the models of its map, by name to
+// framework. Models added through the API are served by their artefact name.
+type server struct {
+	mu     sync.Mutex
+	models map[string]string
+	// fail answers the mutations of a model with a 500.
+	fail  map[string]bool
+	calls []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path != "/api/models" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == http.MethodGet {
+		var resp struct {
+			Total  int                  `json:"total"`
+			Models []jams.ModelMetadata `json:"models"`
+		}
+		for name, framework := range s.models {
+			resp.Models = append(resp.Models, jams.ModelMetadata{Name: name, Framework: framework})
+		}
+		sort.Slice(resp.Models, func(i, j int) bool { return resp.Models[i].Name < resp.Models[j].Name })
+		resp.Total = len(resp.Models)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	name := r.URL.Query().Get("model_name")
+	if r.Method != http.MethodDelete {
+		var req struct {
+			ModelName string `json:"model_name"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		name = req.ModelName
+	}
+	s.calls = append(s.calls, r.Method+" "+name)
+	if s.fail[name] {
+		http.Error(w, "model store is unavailable", http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		framework, _, _ := strings.Cut(name, "-")
+		s.models[name] = framework
+	case http.MethodDelete:
+		delete(s.models, name)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name    string
+		models  map[string]string
+		fail    []string
+		desired ModelSet
+		// wantPlan holds the type and target of the planned actions.
+		wantPlan   []string
+		wantCalls  []string
+		wantErr    string
+		wantModels map[string]string
+		wantPhases map[string]Phase
+	}{
+		{
+			name:   "empty plan",
+			models: map[string]string{"titanic": "catboost"},
+			desired: ModelSet{Models: []Model{
+				{Name: "titanic", Framework: "catboost"},
+			}},
+			wantModels: map[string]string{"titanic": "catboost"},
+			wantPhases: map[string]Phase{"titanic": PhaseReady},
+		},
+		{
+			name:   "add only",
+			models: map[string]string{"titanic": "catboost"},
+			desired: ModelSet{Models: []Model{
+				{Name: "titanic", Framework: "catboost"},
+				{Name: "housing", Framework: "lightgbm"},
+				{Name: "iris", Framework: "torch", Artifact: "torch-iris-v2"},
+			}},
+			wantPlan:  []string{"add lightgbm-housing", "add torch-iris-v2"},
+			wantCalls: []string{"POST lightgbm-housing", "POST torch-iris-v2"},
+			wantModels: map[string]string{
+				"titanic": "catboost", "lightgbm-housing": "lightgbm", "torch-iris-v2": "torch",
+			},
+			wantPhases: map[string]Phase{"titanic": PhaseReady, "housing": PhaseReady, "iris": PhaseReady},
+		},
+		{
+			name:   "delete only",
+			models: map[string]string{"titanic": "catboost", "housing": "lightgbm", "torch-iris": "torch"},
+			desired: ModelSet{Prune: true, Models: []Model{
+				{Name: "titanic", Framework: "catboost"},
+			}},
+			wantPlan:   []string{"delete housing", "delete torch-iris"},
+			wantCalls:  []string{"DELETE housing", "DELETE torch-iris"},
+			wantModels: map[string]string{"titanic": "catboost"},
+			wantPhases: map[string]Phase{"titanic": PhaseReady, "housing": PhaseDeleted, "torch-iris": PhaseDeleted},
+		},
+		{
+			name:   "partial failure",
+			models: map[string]string{"titanic": "catboost", "old": "torch"},
+			fail:   []string{"lightgbm-housing"},
+			desired: ModelSet{Prune: true, Models: []Model{
+				{Name: "titanic", Framework: "tensorflow"},
+				{Name: "housing", Framework: "lightgbm"},
+				{Name: "iris", Framework: "torch"},
+			}},
+			wantPlan: []string{
+				"delete titanic", "delete old",
+				"add tensorflow-titanic", "add lightgbm-housing", "add torch-iris",
+			},
+			wantCalls: []string{
+				"DELETE titanic", "DELETE old",
+				"POST tensorflow-titanic", "POST lightgbm-housing", "POST torch-iris",
+			},
+			wantErr: "failed to add model housing",
+			wantModels: map[string]string{
+				"tensorflow-titanic": "tensorflow", "torch-iris": "torch",
+			},
+			wantPhases: map[string]Phase{
+				"titanic": PhaseReady, "housing": PhaseFailed, "iris": PhaseReady, "old": PhaseDeleted,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{models: tt.models, fail: make(map[string]bool)}
+			for _, name := range tt.fail {
+				s.fail[name] = true
+			}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+			client, err := jams.NewHTTPClient(srv.URL, jams.WithRetry(1))
+			if err != nil {
+				t.Fatalf("NewHTTPClient: %v", err)
+			}
+			defer client.Close()
+			r := New(client)
+			ctx := context.Background()
+
+			plan, err := r.Plan(ctx, tt.desired)
+			if err != nil {
+				t.Fatalf("Plan: %v", err)
+			}
+			var actions []string
+			for _, a := range plan.Actions {
+				actions = append(actions, string(a.Type)+" "+a.Target)
+			}
+			if !reflect.DeepEqual(actions, tt.wantPlan) {
+				t.Fatalf("plan = %q, want %q", actions, tt.wantPlan)
+			}
+			if plan.Empty() != (len(tt.wantPlan) == 0) {
+				t.Errorf("Empty() = %v", plan.Empty())
+			}
+
+			err = r.Apply(ctx, plan)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if tt.wantErr != "" {
+				var httpErr *jams.HTTPError
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+					t.Fatalf("Apply error = %v, want %q from a 500", err, tt.wantErr)
+				}
+			}
+			if !reflect.DeepEqual(s.calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", s.calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(s.models, tt.wantModels) {
+				t.Errorf("models = %v, want %v", s.models, tt.wantModels)
+			}
+			phases := make(map[string]Phase)
+			for _, status := range r.Statuses() {
+				phases[status.Model] = status.Phase
+				if (status.Phase == PhaseFailed) != (status.Error != "") {
+					t.Errorf("status of %s has phase %s and error %q", status.Model, status.Phase, status.Error)
+				}
+			}
+			if !reflect.DeepEqual(phases, tt.wantPhases) {
+				t.Errorf("phases = %v, want %v", phases, tt.wantPhases)
+			}
+
+			// the next plan retries the failed actions only.
+			s.fail = nil
+			plan, err = r.Plan(ctx, tt.desired)
+			if err != nil {
+				t.Fatalf("Plan: %v", err)
+			}
+			var retried []string
+			for _, a := range plan.Actions {
+				retried = append(retried, string(a.Type)+" "+a.Target)
+			}
+			var want []string
+			for _, name := range tt.fail {
+				want = append(want, "add "+name)
+			}
+			if !reflect.DeepEqual(retried, want) {
+				t.Errorf("next plan = %q, want %q", retried, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package jams_client
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// DefaultRedactionReplacement replaces redacted values.
+const DefaultRedactionReplacement = "[REDACTED]"
+
+// Redaction hides the values of sensitive columns, e.g. personal data, from
+// everything the client records: debug dumps, the inputs passed to
+// prediction sinks and the inputs and raw records of dead letters. The inputs
+// sent to the server are not redacted.
+type Redaction struct {
+	patterns []*regexp.Regexp
+	// Replacement replaces every value of a redacted column,
+	// DefaultRedactionReplacement when empty.
+	Replacement string
+}
+
+// NewRedaction returns a redaction of the columns whose name matches one of
+// the regular expressions, case-insensitively. Patterns match anywhere in the
+// name unless anchored: "ssn" redacts "customer_ssn", "^email$" only "email".
+func NewRedaction(patterns ...string) (*Redaction, error) {
+	r := &Redaction{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redacts reports whether the named column is redacted.
+func (r *Redaction) Redacts(column string) bool {
+	for _, re := range r.patterns {
+		if re.MatchString(column) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the input with the values of redacted columns replaced, or
+// the input itself when no column is redacted.
+func (r *Redaction) Apply(in *types.Input) *types.Input {
+	replacement := r.Replacement
+	if replacement == "" {
+		replacement = DefaultRedactionReplacement
+	}
+	out := in
+	for _, name := range in.Order() {
+		if !r.Redacts(name) {
+			continue
+		}
+		if out == in {
+			out = in.Clone()
+		}
+		column, _ := in.Column(name)
+		values := make([]string, len(column))
+		for i := range values {
+			values[i] = replacement
+		}
+		out.AddStrings(name, values...)
+	}
+	return out
+}
+
+// ApplyRecords returns copies of the records with the values of redacted
+// features replaced, or the records themselves when no feature is redacted.
+func (r *Redaction) ApplyRecords(records []map[string]any) []map[string]any {
+	replacement := r.Replacement
+	if replacement == "" {
+		replacement = DefaultRedactionReplacement
+	}
+	var out []map[string]any
+	for i, record := range records {
+		var redacted map[string]any
+		for name := range record {
+			if !r.Redacts(name) {
+				continue
+			}
+			if redacted == nil {
+				redacted = maps.Clone(record)
+			}
+			redacted[name] = replacement
+		}
+		if redacted == nil {
+			continue
+		}
+		if out == nil {
+			out = slices.Clone(records)
+		}
+		out[i] = redacted
+	}
+	if out == nil {
+		return records
+	}
+	return out
+}
+
+// WithRedaction applies r to everything the client records. Several
+// redactions may be set.
+func WithRedaction(r *Redaction) Option {
+	return func(o *options) {
+		o.redactions = append(o.redactions, r)
+	}
+}
+
+// WithRedactedColumns redacts the named columns, matched case-insensitively.
+// See WithRedaction to redact columns by pattern.
+func WithRedactedColumns(columns ...string) Option {
+	patterns := make([]string, len(columns))
+	for i, column := range columns {
+		patterns[i] = "^" + regexp.QuoteMeta(column) + "$"
+	}
+	// quoted patterns always compile
+	r, _ := NewRedaction(patterns...)
+	return WithRedaction(r)
+}
+
+// RedactInput returns in with the redactions set with WithRedaction applied,
+// as the client records it, e.g. for code writing inputs somewhere of its
+// own.
+func (c *Client) RedactInput(in *types.Input) *types.Input {
+	for _, r := range c.opts.redactions {
+		in = r.Apply(in)
+	}
+	return in
+}
+
+// RedactRecords returns records with the redactions set with WithRedaction
+// applied, like RedactInput.
+func (c *Client) RedactRecords(records []map[string]any) []map[string]any {
+	for _, r := range c.opts.redactions {
+		records = r.ApplyRecords(records)
+	}
+	return records
+}
@@ -0,0 +1,192 @@
+// Package router sends calls to the J.A.M.S server of their tenant, for
+// applications serving teams whose models live on separate clusters.
+//
+// Routes map tenant names to endpoints and credentials, usually loaded from a
+// file:
+//
+//	routes, err := router.Load("tenants.yaml")
+//	r, err := router.New(routes, jams.WithRetry(3))
+//	defer r.Close()
+//
+//	prediction, err := r.Predict(router.WithTenant(ctx, "search"), "ranker", input)
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// ErrUnknownTenant is returned for calls of a tenant without a route when
+// there is no default route.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// Endpoint is the server of a tenant and the credentials used to reach it.
+type Endpoint struct {
+	// URL of the HTTP API, e.g. "http://localhost:3000".
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Target of the gRPC API, e.g. "localhost:4000". Used instead of URL when
+	// set.
+	GRPCTarget string `json:"grpc_target,omitempty" yaml:"grpc_target,omitempty"`
+	// Token is sent as a bearer token. TokenEnv names an environment variable
+	// holding it instead, to keep secrets out of route files.
+	Token    string `json:"token,omitempty" yaml:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty" yaml:"token_env,omitempty"`
+}
+
+// Routes maps tenants to their endpoints. The tenant named by Default serves
+// calls without a tenant or of tenants without an endpoint.
+type Routes struct {
+	Tenants map[string]Endpoint `json:"tenants" yaml:"tenants"`
+	Default string              `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// Load reads routes from a YAML or JSON file.
+func Load(path string) (*Routes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var routes Routes
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &routes)
+	} else {
+		err = yaml.Unmarshal(data, &routes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse routes: %w", err)
+	}
+	return &routes, nil
+}
+
+// Router holds a client per tenant. It is safe for concurrent use.
+type Router struct {
+	clients map[string]*jams.Client
+	// fallback serves unknown tenants, nil when there is no default route.
+	fallback *jams.Client
+}
+
+// New creates a client for every tenant of routes. opts apply to all of them.
+func New(routes *Routes, opts ...jams.Option) (*Router, error) {
+	r := &Router{clients: make(map[string]*jams.Client, len(routes.Tenants))}
+	for _, tenant := range sortedTenants(routes) {
+		client, err := newClient(routes.Tenants[tenant], opts)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("tenant %q: %w", tenant, err)
+		}
+		r.clients[tenant] = client
+	}
+	if routes.Default != "" {
+		fallback, ok := r.clients[routes.Default]
+		if !ok {
+			r.Close()
+			return nil, fmt.Errorf("default tenant %q has no endpoint", routes.Default)
+		}
+		r.fallback = fallback
+	}
+	return r, nil
+}
+
+func sortedTenants(routes *Routes) []string {
+	tenants := make([]string, 0, len(routes.Tenants))
+	for tenant := range routes.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+func newClient(endpoint Endpoint, opts []jams.Option) (*jams.Client, error) {
+	token := endpoint.Token
+	if endpoint.TokenEnv != "" {
+		token = os.Getenv(endpoint.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", endpoint.TokenEnv)
+		}
+	}
+	if token != "" {
+		opts = append(opts[:len(opts):len(opts)], jams.WithBearerToken(token))
+	}
+
+	switch {
+	case endpoint.GRPCTarget != "":
+		return jams.NewGRPCClient(endpoint.GRPCTarget, opts...)
+	case endpoint.URL != "":
+		return jams.NewHTTPClient(endpoint.URL, opts...)
+	}
+	return nil, errors.New("endpoint has neither a url nor a grpc target")
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose calls are routed to the endpoint of
+// tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// Tenant returns the tenant set on ctx with WithTenant.
+func Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// Client returns the client of tenant, or of the default tenant when tenant
+// has no endpoint.
+func (r *Router) Client(tenant string) (*jams.Client, error) {
+	if client, ok := r.clients[tenant]; ok {
+		return client, nil
+	}
+	if r.fallback != nil {
+		return r.fallback, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownTenant, tenant)
+}
+
+// Tenants returns the tenants with an endpoint in sorted order.
+func (r *Router) Tenants() []string {
+	tenants := make([]string, 0, len(r.clients))
+	for tenant := range r.clients {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// Predict makes predictions with the named model of the tenant set on ctx.
+func (r *Router) Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error) {
+	client, err := r.Client(Tenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return client.Predict(ctx, modelName, input)
+}
+
+// GetModels returns the models loaded into the server of the tenant set on
+// ctx.
+func (r *Router) GetModels(ctx context.Context) ([]jams.ModelMetadata, error) {
+	client, err := r.Client(Tenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return client.GetModels(ctx)
+}
+
+// Close closes the clients of all tenants.
+func (r *Router) Close() error {
+	var errs []error
+	for _, client := range r.clients {
+		errs = append(errs, client.Close())
+	}
+	return errors.Join(errs...)
+}
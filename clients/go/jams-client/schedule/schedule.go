@@ -0,0 +1,383 @@
+// Package schedule drains a queue of background scoring jobs, e.g. a nightly
+// re-scoring, through the client used for interactive traffic, at a target
+// rate of records per second that can be raised off-peak.
+//
+//	scheduler, err := schedule.New(client, schedule.Config{
+//		Rate:        200,
+//		OffPeak:     &schedule.OffPeak{Start: 22 * time.Hour, End: 6 * time.Hour, Rate: 2000},
+//		MaxInFlight: 32,
+//		Team:        "rescoring",
+//	})
+//	go scheduler.Run(ctx)
+//	err = scheduler.Submit(ctx, schedule.Job{Model: "churn", Input: input, Done: store})
+//
+// The scheduler holds back while the client has MaxInFlight predictions in
+// flight, interactive ones included, and waits out the quotas of the client
+// instead of failing jobs with a *jams.QuotaError. Jobs have
+// jams.PriorityBatch, so that interactive predictions overtake them when the
+// concurrency of the client is limited with jams.WithMaxConcurrency.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// ErrClosed is returned by Submit once the scheduler is closed.
+var ErrClosed = errors.New("scheduler is closed")
+
+const (
+	defaultQueueSize    = 1024
+	defaultConcurrency  = 4
+	loadPollInterval    = 50 * time.Millisecond
+	minQuotaRetryPeriod = 100 * time.Millisecond
+)
+
+// Client is the client jobs are predicted with. *jams.Client implements it.
+type Client interface {
+	Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error)
+	Stats() jams.Stats
+}
+
+// redactor is implemented by clients redacting the inputs they record, such
+// as *jams.Client.
+type redactor interface {
+	RedactInput(in *types.Input) *types.Input
+}
+
+// Job is a scoring job.
+type Job struct {
+	Model string
+	Input *types.Input
+	// Metadata is set on the prediction of the job with
+	// jams.WithCallMetadata, as the context of Submit is not.
+	Metadata map[string]string
+	// Done, if not nil, receives the outcome of the job. It is called from
+	// the worker which ran the job.
+	Done func(prediction *types.Prediction, err error)
+}
+
+// OffPeak is a daily window during which the scheduler runs at a higher
+// rate. Start and End are offsets from midnight, local time; a window ending
+// before it starts spans midnight.
+type OffPeak struct {
+	Start time.Duration
+	End   time.Duration
+	// Rate and Burst replace those of the Config during the window. Burst
+	// defaults to Rate.
+	Rate  float64
+	Burst int
+}
+
+func (o *OffPeak) contains(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if o.Start <= o.End {
+		return offset >= o.Start && offset < o.End
+	}
+	return offset >= o.Start || offset < o.End
+}
+
+// Config configures a Scheduler.
+type Config struct {
+	// Rate is the number of records scored per second.
+	Rate float64
+	// Burst is the number of records that may be scored at once, Rate by
+	// default. Jobs larger than Burst wait for their records in several
+	// steps.
+	Burst   int
+	OffPeak *OffPeak
+	// MaxInFlight holds back jobs while the client has as many predictions
+	// in flight, when greater than zero.
+	MaxInFlight int64
+	// Concurrency is the number of jobs predicted at once, 4 by default.
+	Concurrency int
+	// QueueSize is the number of jobs queued before Submit blocks, 1024 by
+	// default.
+	QueueSize int
+	// Team attributes the predictions to a team with jams.WithTeam, e.g. to
+	// give them a quota of their own.
+	Team string
+	// DeadLetters, if not nil, receives the input of every failed job,
+	// except the jobs cancelled by the context of Run, before its Done is
+	// called. Their inputs are redacted by the client when it is a
+	// *jams.Client, see jams.WithRedaction.
+	DeadLetters jams.DeadLetterQueue
+	// DeadLettersUnredacted puts the inputs into DeadLetters as given, see
+	// jams.BatchOptions.DeadLettersUnredacted.
+	DeadLettersUnredacted bool
+}
+
+// Scheduler runs the jobs submitted to it. It is safe for concurrent use.
+type Scheduler struct {
+	client Client
+	config Config
+	queue  chan Job
+
+	limiterMu sync.Mutex
+	limiter   *rate.Limiter
+	offPeak   bool
+
+	// mu guards closed against the submits starting, counted by submits.
+	// done is closed by Close; the queue is never closed, as submits may be
+	// sending to it.
+	mu      sync.RWMutex
+	closed  bool
+	submits sync.WaitGroup
+	done    chan struct{}
+}
+
+// New returns a scheduler predicting with client.
+func New(client Client, config Config) (*Scheduler, error) {
+	if config.Rate <= 0 {
+		return nil, errors.New("rate must be greater than zero")
+	}
+	if config.OffPeak != nil && config.OffPeak.Rate <= 0 {
+		return nil, errors.New("off-peak rate must be greater than zero")
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaultConcurrency
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	r, burst := config.limits(false)
+	return &Scheduler{
+		client:  client,
+		config:  config,
+		queue:   make(chan Job, config.QueueSize),
+		limiter: rate.NewLimiter(rate.Limit(r), burst),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+func (c Config) limits(offPeak bool) (float64, int) {
+	r, burst := c.Rate, c.Burst
+	if offPeak {
+		r, burst = c.OffPeak.Rate, c.OffPeak.Burst
+	}
+	if burst <= 0 {
+		burst = max(int(r), 1)
+	}
+	return r, burst
+}
+
+// Submit queues a job, blocking while the queue is full. A Submit blocked
+// when the scheduler is closed returns ErrClosed.
+func (s *Scheduler) Submit(ctx context.Context, job Job) error {
+	if job.Input == nil {
+		return jams.ErrNilInput
+	}
+	s.mu.RLock()
+	if s.closed {
+		s.mu.RUnlock()
+		return ErrClosed
+	}
+	s.submits.Add(1)
+	s.mu.RUnlock()
+	defer s.submits.Done()
+
+	select {
+	case s.queue <- job:
+		return nil
+	case <-s.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting jobs. Run returns once the queued jobs are done.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+}
+
+// Len returns the number of queued jobs.
+func (s *Scheduler) Len() int {
+	return len(s.queue)
+}
+
+// Run runs the queued jobs until the scheduler is closed and drained, or ctx
+// is done. Jobs left in the queue when ctx is done are not run.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.config.Team != "" {
+		ctx = jams.WithTeam(ctx, s.config.Team)
+	}
+	ctx = jams.WithPriority(ctx, jams.PriorityBatch)
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (s *Scheduler) work(ctx context.Context) {
+	for {
+		select {
+		case job := <-s.queue:
+			s.runJob(ctx, job)
+		case <-s.done:
+			s.drain(ctx)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drain runs the jobs left in the queue once the scheduler is closed,
+// including those of the submits in flight when it was.
+func (s *Scheduler) drain(ctx context.Context) {
+	s.submits.Wait()
+	for {
+		select {
+		case job := <-s.queue:
+			s.runJob(ctx, job)
+		default:
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// runJob runs a job and passes its outcome to its Done.
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	prediction, err := s.run(ctx, job)
+	if err != nil {
+		err = s.deadLetter(ctx, job, err)
+	}
+	if job.Done != nil {
+		job.Done(prediction, err)
+	}
+}
+
+// deadLetter puts the input of a failed job into the dead letter queue, if
+// any, returning the error of the job along with the error of the queue.
+func (s *Scheduler) deadLetter(ctx context.Context, job Job, err error) error {
+	if s.config.DeadLetters == nil || ctx.Err() != nil {
+		return err
+	}
+	input := job.Input
+	if r, ok := s.client.(redactor); ok && !s.config.DeadLettersUnredacted {
+		input = r.RedactInput(input)
+	}
+	putErr := s.config.DeadLetters.Put(ctx, jams.DeadLetter{
+		Source: jams.DeadLetterSourceSchedule,
+		Model:  job.Model,
+		Time:   time.Now(),
+		Input:  input,
+		Err:    err,
+	})
+	if putErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to store dead letter: %w", putErr))
+	}
+	return err
+}
+
+// run waits for the rate, load and quotas to allow the job and predicts it.
+func (s *Scheduler) run(ctx context.Context, job Job) (*types.Prediction, error) {
+	if job.Metadata != nil {
+		ctx = jams.WithCallMetadata(ctx, job.Metadata)
+	}
+	if err := s.waitRecords(ctx, job.Input.Len()); err != nil {
+		return nil, err
+	}
+	for {
+		if err := s.waitLoad(ctx); err != nil {
+			return nil, err
+		}
+		prediction, err := s.client.Predict(ctx, job.Model, job.Input)
+		var quotaErr *jams.QuotaError
+		if !errors.As(err, &quotaErr) || quotaErr.RetryAfter == 0 {
+			return prediction, err
+		}
+		if !sleep(ctx, max(quotaErr.RetryAfter, minQuotaRetryPeriod)) {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// waitRecords takes n records out of the rate limiter, switching it to the
+// off-peak rate and back as needed.
+func (s *Scheduler) waitRecords(ctx context.Context, n int) error {
+	for n > 0 {
+		limiter := s.currentLimiter()
+		step := min(n, limiter.Burst())
+		if err := limiter.WaitN(ctx, step); err != nil {
+			return err
+		}
+		n -= step
+	}
+	return nil
+}
+
+func (s *Scheduler) currentLimiter() *rate.Limiter {
+	if s.config.OffPeak == nil {
+		return s.limiter
+	}
+	offPeak := s.config.OffPeak.contains(time.Now())
+	s.limiterMu.Lock()
+	defer s.limiterMu.Unlock()
+	if offPeak != s.offPeak {
+		s.offPeak = offPeak
+		r, burst := s.config.limits(offPeak)
+		s.limiter.SetLimit(rate.Limit(r))
+		s.limiter.SetBurst(burst)
+	}
+	return s.limiter
+}
+
+// waitLoad waits until the client has less than MaxInFlight predictions in
+// flight.
+func (s *Scheduler) waitLoad(ctx context.Context) error {
+	if s.config.MaxInFlight <= 0 {
+		return nil
+	}
+	for inFlight(s.client.Stats()) >= s.config.MaxInFlight {
+		if !sleep(ctx, loadPollInterval) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func inFlight(stats jams.Stats) int64 {
+	var n int64
+	for _, call := range stats.Calls {
+		if call.Method == jams.MethodPredict {
+			n += call.InFlight
+		}
+	}
+	return n
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,111 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// fakeClient predicts an empty prediction for every input.
+type fakeClient struct{}
+
+func (fakeClient) Predict(context.Context, string, *types.Input) (*types.Prediction, error) {
+	return &types.Prediction{}, nil
+}
+
+func (fakeClient) Stats() jams.Stats {
+	return jams.Stats{}
+}
+
+// within fails the test when f does not return within a second.
+func within(t *testing.T, what string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s blocked", what)
+	}
+}
+
+func TestCloseUnblocksSubmit(t *testing.T) {
+	s, err := New(fakeClient{}, Config{Rate: 1000, QueueSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Run stopped before draining the queue.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Run(ctx)
+
+	job := Job{Model: "m", Input: types.NewInput().AddFloats("x", 1)}
+	if err := s.Submit(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	blocked := make(chan error)
+	go func() {
+		blocked <- s.Submit(context.Background(), job)
+	}()
+	// let the submit block on the full queue.
+	time.Sleep(20 * time.Millisecond)
+
+	within(t, "Close", s.Close)
+	within(t, "blocked Submit", func() {
+		if err := <-blocked; !errors.Is(err, ErrClosed) {
+			t.Errorf("blocked Submit returned %v, want ErrClosed", err)
+		}
+	})
+	within(t, "Submit after Close", func() {
+		if err := s.Submit(context.Background(), job); !errors.Is(err, ErrClosed) {
+			t.Errorf("Submit after Close returned %v, want ErrClosed", err)
+		}
+	})
+	within(t, "Len", func() { s.Len() })
+}
+
+func TestCloseRunsAcceptedJobs(t *testing.T) {
+	s, err := New(fakeClient{}, Config{Rate: 1e6, QueueSize: 4, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ran atomic.Int64
+	job := Job{Model: "m", Input: types.NewInput().AddFloats("x", 1), Done: func(*types.Prediction, error) {
+		ran.Add(1)
+	}}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.Run(context.Background())
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int64
+	)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.Submit(context.Background(), job) == nil {
+				accepted.Add(1)
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	s.Close()
+	wg.Wait()
+	within(t, "Run", func() { <-stopped })
+	if ran.Load() != accepted.Load() {
+		t.Fatalf("ran %d jobs of the %d accepted", ran.Load(), accepted.Load())
+	}
+}
@@ -0,0 +1,208 @@
+// Package shard partitions an input deterministically across the workers of a
+// horizontally scaled batch scoring job, so that every record is scored by
+// exactly one worker. Records are assigned either by hash, with every worker
+// reading the whole input:
+//
+//	spec, err := shard.Parse("2/8") // the third of eight workers
+//	records = shard.ByHash(records, spec)
+//	err = client.PredictStream(ctx, "churn", records, opts, store)
+//
+// or by byte range of a file of JSON lines, with every worker reading its
+// range only:
+//
+//	f, err := os.Open("records.jsonl")
+//	info, err := f.Stat()
+//	lines := shard.Lines(f, info.Size(), spec)
+//
+// Workers must agree on the number of shards and read the same input.
+package shard
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"strings"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// Spec identifies a shard: Index is in [0, Count).
+type Spec struct {
+	Index int
+	Count int
+}
+
+// Parse parses a shard given as "index/count", e.g. "0/4".
+func Parse(s string) (Spec, error) {
+	index, count, ok := strings.Cut(s, "/")
+	if !ok {
+		return Spec{}, fmt.Errorf("invalid shard %q, expected index/count", s)
+	}
+	var spec Spec
+	var err error
+	if spec.Index, err = strconv.Atoi(strings.TrimSpace(index)); err != nil {
+		return Spec{}, fmt.Errorf("invalid shard %q: %w", s, err)
+	}
+	if spec.Count, err = strconv.Atoi(strings.TrimSpace(count)); err != nil {
+		return Spec{}, fmt.Errorf("invalid shard %q: %w", s, err)
+	}
+	if err := spec.Validate(); err != nil {
+		return Spec{}, err
+	}
+	return spec, nil
+}
+
+// Validate checks that the index is within the count.
+func (s Spec) Validate() error {
+	if s.Count <= 0 {
+		return errors.New("shard count must be greater than zero")
+	}
+	if s.Index < 0 || s.Index >= s.Count {
+		return fmt.Errorf("shard index %d is out of range [0, %d)", s.Index, s.Count)
+	}
+	return nil
+}
+
+func (s Spec) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Owns reports whether the shard owns the record with the given key, i.e.
+// whether the jump consistent hash of the FNV-1a hash of the key into Count
+// buckets is Index. Adding a shard only moves to it the records it takes
+// over, a 1/Count share, so that the other shards keep most of their records
+// and checkpoints.
+func (s Spec) Owns(key []byte) bool {
+	h := fnv.New64a()
+	h.Write(key)
+	return jump(h.Sum64(), s.Count) == s.Index
+}
+
+// jump returns the bucket of key among n with the jump consistent hash of
+// Lamping and Veach.
+func jump(key uint64, n int) int {
+	b, j := int64(-1), int64(0)
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// ByHash returns a reader of the records of r owned by the shard. Records are
+// keyed by the values of the given fields, or by the whole record when no
+// field is given; records with the same key go to the same shard, e.g. all
+// the records of a customer.
+func ByHash(r jams.RecordReader, spec Spec, fields ...string) jams.RecordReader {
+	return &hashReader{r: r, spec: spec, fields: fields}
+}
+
+type hashReader struct {
+	r      jams.RecordReader
+	spec   Spec
+	fields []string
+}
+
+// Read implements jams.RecordReader.
+func (h *hashReader) Read() (map[string]any, error) {
+	for {
+		record, err := h.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		key, err := h.key(record)
+		if err != nil {
+			return nil, err
+		}
+		if h.spec.Owns(key) {
+			return record, nil
+		}
+	}
+}
+
+// key encodes the fields of record as JSON, whose object keys are sorted.
+func (h *hashReader) key(record map[string]any) ([]byte, error) {
+	if len(h.fields) == 0 {
+		return json.Marshal(record)
+	}
+	values := make([]any, len(h.fields))
+	for i, field := range h.fields {
+		v, ok := record[field]
+		if !ok {
+			return nil, fmt.Errorf("record has no shard key field %q", field)
+		}
+		values[i] = v
+	}
+	return json.Marshal(values)
+}
+
+// Range returns the byte range [start, end) of the shard in an input of size
+// bytes.
+func Range(size int64, spec Spec) (start, end int64) {
+	start = size * int64(spec.Index) / int64(spec.Count)
+	end = size * int64(spec.Index+1) / int64(spec.Count)
+	return start, end
+}
+
+// Lines returns the lines of r, an input of size bytes such as a file, owned
+// by the shard: the lines starting within its byte range. Lines crossing the
+// end of the range are read whole, and the line crossing its start belongs to
+// the previous shard, so that every line is read by exactly one shard.
+func Lines(r io.ReaderAt, size int64, spec Spec) io.Reader {
+	start, end := Range(size, spec)
+	pos := start
+	if start > 0 {
+		// a line starts at start only if the byte before it ends a line
+		pos = start - 1
+	}
+	return &lineReader{
+		br:   bufio.NewReader(io.NewSectionReader(r, pos, size-pos)),
+		pos:  pos,
+		end:  end,
+		skip: start > 0,
+	}
+}
+
+// lineReader reads the lines starting before end.
+type lineReader struct {
+	br *bufio.Reader
+	// pos is the offset in the input following the data read from br.
+	pos int64
+	end int64
+	// skip is set until the line crossing the start of the range is skipped.
+	skip bool
+	// inLine is set while a line longer than the buffer of br is read.
+	inLine  bool
+	pending []byte
+	err     error
+}
+
+func (l *lineReader) Read(p []byte) (int, error) {
+	for len(l.pending) == 0 {
+		if l.err != nil {
+			return 0, l.err
+		}
+		if !l.inLine && !l.skip && l.pos >= l.end {
+			return 0, io.EOF
+		}
+		data, err := l.br.ReadSlice('\n')
+		l.pos += int64(len(data))
+		l.inLine = err == bufio.ErrBufferFull
+		if err != nil && !l.inLine {
+			l.err = err
+		}
+		if l.skip {
+			l.skip = l.inLine
+			continue
+		}
+		l.pending = data
+	}
+	n := copy(p, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
@@ -0,0 +1,140 @@
+package shard
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+// owner returns the index of the shard of count owning key.
+func owner(t *testing.T, key string, count int) int {
+	t.Helper()
+	index := -1
+	for i := 0; i < count; i++ {
+		if (Spec{Index: i, Count: count}).Owns([]byte(key)) {
+			if index >= 0 {
+				t.Fatalf("key %q is owned by shards %d and %d of %d", key, index, i, count)
+			}
+			index = i
+		}
+	}
+	if index < 0 {
+		t.Fatalf("key %q is owned by no shard of %d", key, count)
+	}
+	return index
+}
+
+func TestJump(t *testing.T) {
+	// reference vectors of the jump consistent hash.
+	tests := []struct {
+		key  uint64
+		n    int
+		want int
+	}{
+		{1, 1, 0},
+		{42, 57, 43},
+		{0xDEAD10CC, 1, 0},
+		{0xDEAD10CC, 666, 361},
+		{256, 1024, 520},
+	}
+	for _, tt := range tests {
+		if got := jump(tt.key, tt.n); got != tt.want {
+			t.Errorf("jump(%d, %d) = %d, want %d", tt.key, tt.n, got, tt.want)
+		}
+	}
+}
+
+// TestOwnsGolden pins the shards of fixed keys: workers of different
+// versions must agree on them, and checkpoints are kept per shard.
+func TestOwnsGolden(t *testing.T) {
+	keys := []string{"", "a", "foobar", "customer-1", "customer-2", "customer-3", `[42]`, `{"id":7}`}
+	golden := map[int][]int{
+		1:   {0, 0, 0, 0, 0, 0, 0, 0},
+		2:   {1, 1, 1, 0, 0, 0, 1, 1},
+		3:   {1, 2, 1, 0, 0, 2, 1, 2},
+		8:   {1, 2, 5, 0, 3, 2, 6, 7},
+		100: {90, 31, 33, 64, 81, 60, 69, 49},
+	}
+	for count, want := range golden {
+		for i, key := range keys {
+			if got := owner(t, key, count); got != want[i] {
+				t.Errorf("shard of %q among %d = %d, want %d", key, count, got, want[i])
+			}
+		}
+	}
+}
+
+func TestOwnsStability(t *testing.T) {
+	const keys = 10000
+	for count := 1; count < 16; count++ {
+		moved := 0
+		for k := 0; k < keys; k++ {
+			key := fmt.Sprint("customer-", k)
+			before, after := owner(t, key, count), owner(t, key, count+1)
+			if before == after {
+				continue
+			}
+			if after != count {
+				t.Fatalf("adding shard %d moved %q from shard %d to %d", count, key, before, after)
+			}
+			moved++
+		}
+		// the new shard takes over its share of the keys.
+		share := float64(moved) / keys
+		if want := 1 / float64(count+1); share < 0.9*want || share > 1.1*want {
+			t.Errorf("adding shard %d moved %.3f of the keys, want %.3f", count, share, want)
+		}
+	}
+}
+
+// sliceReader reads records from a slice.
+type sliceReader []map[string]any
+
+func (r *sliceReader) Read() (map[string]any, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	record := (*r)[0]
+	*r = (*r)[1:]
+	return record, nil
+}
+
+func TestByHash(t *testing.T) {
+	var records []map[string]any
+	for i := 0; i < 100; i++ {
+		records = append(records, map[string]any{"id": i % 10, "seq": i})
+	}
+	owners := make(map[int]int)
+	total := 0
+	for index := 0; index < 3; index++ {
+		in := sliceReader(records)
+		r := ByHash(&in, Spec{Index: index, Count: 3}, "id")
+		for {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			total++
+			// records with the same key go to the same shard.
+			id := record["id"].(int)
+			if o, ok := owners[id]; ok && o != index {
+				t.Fatalf("records of id %d are in shards %d and %d", id, o, index)
+			}
+			owners[id] = index
+			if want := owner(t, fmt.Sprintf("[%d]", id), 3); want != index {
+				t.Fatalf("record of id %d is in shard %d, want %d", id, index, want)
+			}
+		}
+	}
+	if total != len(records) {
+		t.Fatalf("shards hold %d records, want %d", total, len(records))
+	}
+
+	in := sliceReader{{"seq": 1}}
+	if _, err := ByHash(&in, Spec{Index: 0, Count: 1}, "id").Read(); err == nil {
+		t.Fatalf("Read of a record without its key succeeded")
+	}
+}
@@ -0,0 +1,56 @@
+package jams_client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// PredictionRecord describes a completed Predict call.
+type PredictionRecord struct {
+	// RequestID is a random identifier generated for the call.
+	RequestID string
+	Model     string
+	// App is the identity set with WithAppIdentity.
+	App AppIdentity
+	// Metadata is the metadata of the call context, see WithCallMetadata.
+	Metadata map[string]string
+	// Time is when the call started.
+	Time    time.Time
+	Latency time.Duration
+	// Input is the input sent to the server, after preprocessing, with the
+	// redactions set with WithRedaction applied.
+	Input *types.Input
+	// Prediction is nil when the call failed.
+	Prediction *types.Prediction
+	Err        error
+}
+
+// PredictionSink receives a record for every Predict call with a valid input,
+// e.g. to build offline evaluation datasets. Record is called synchronously
+// and must not block.
+type PredictionSink interface {
+	Record(r PredictionRecord)
+}
+
+// WithPredictionSink adds a sink receiving every Predict call.
+func WithPredictionSink(s PredictionSink) Option {
+	return func(o *options) {
+		o.sinks = append(o.sinks, s)
+	}
+}
+
+func (c *Client) recordPrediction(r PredictionRecord) {
+	for _, s := range c.opts.sinks {
+		s.Record(r)
+	}
+}
+
+// newRequestID returns a random 128 bit hex identifier.
+func newRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
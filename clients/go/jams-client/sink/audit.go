@@ -0,0 +1,134 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// AuditRecord is a Predict call as written by the audit log, one JSON object
+// per line. Its fields are the columns of the SQL sink.
+type AuditRecord struct {
+	RequestID string    `json:"request_id"`
+	Model     string    `json:"model_name"`
+	Time      time.Time `json:"created_at"`
+	LatencyMS float64   `json:"latency_ms"`
+	// Input is in the wire format of the server.
+	Input json.RawMessage `json:"input"`
+	// Output is nil when the call failed.
+	Output map[string][][]float64 `json:"output,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// AuditLogConfig configures an audit log.
+type AuditLogConfig struct {
+	// BatchSize is the number of records written at once, 100 by default.
+	BatchSize int
+	// FlushInterval is the longest a record is buffered, 5s by default.
+	FlushInterval time.Duration
+	// OnError receives write errors. Records of a failed batch are dropped.
+	OnError func(error)
+}
+
+// AuditLog writes prediction records to w as JSON lines, e.g. to capture
+// production traffic and replay it against a new server with jams-cli
+// replay. Inputs are written with the redactions of the client applied, so
+// redacted columns do not replay.
+type AuditLog struct {
+	w       io.Writer
+	batcher *batcher
+}
+
+// NewAuditLog returns a sink writing to w. It does not close w.
+func NewAuditLog(w io.Writer, config AuditLogConfig) *AuditLog {
+	a := &AuditLog{w: w}
+	a.batcher = newBatcher(config.BatchSize, config.FlushInterval, config.OnError, a.write)
+	return a
+}
+
+// Record implements jams.PredictionSink.
+func (a *AuditLog) Record(r jams.PredictionRecord) {
+	a.batcher.add(r)
+}
+
+// Flush writes the buffered records.
+func (a *AuditLog) Flush(ctx context.Context) error {
+	return a.batcher.flushAll(ctx)
+}
+
+// Close writes the buffered records and stops the sink.
+func (a *AuditLog) Close() error {
+	return a.batcher.close(context.Background())
+}
+
+func (a *AuditLog) write(_ context.Context, records []jams.PredictionRecord) error {
+	var buf []byte
+	for _, r := range records {
+		input, err := r.Input.MarshalJSON()
+		if err != nil {
+			return fmt.Errorf("failed to encode input: %w", err)
+		}
+		record := AuditRecord{
+			RequestID: r.RequestID,
+			Model:     r.Model,
+			Time:      r.Time.UTC(),
+			LatencyMS: float64(r.Latency) / float64(time.Millisecond),
+			Input:     input,
+		}
+		if r.Prediction != nil {
+			record.Output = r.Prediction.Outputs
+		}
+		if r.Err != nil {
+			record.Error = r.Err.Error()
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode record %s: %w", r.RequestID, err)
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	if _, err := a.w.Write(buf); err != nil {
+		return fmt.Errorf("failed to write %d predictions: %w", len(records), err)
+	}
+	return nil
+}
+
+// AuditReader reads the records of an audit log.
+type AuditReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewAuditReader returns a reader of the audit log in r.
+func NewAuditReader(r io.Reader) *AuditReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	return &AuditReader{scanner: scanner}
+}
+
+// Read returns the next record, or io.EOF at the end of the log. Blank lines
+// are skipped.
+func (r *AuditReader) Read() (AuditRecord, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var record AuditRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return AuditRecord{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return record, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return AuditRecord{}, err
+	}
+	return AuditRecord{}, io.EOF
+}
@@ -0,0 +1,306 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// Storage creates the files written by the Parquet sink. Files become visible
+// once closed.
+type Storage interface {
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+}
+
+// LocalStorage stores files below Dir.
+type LocalStorage struct {
+	Dir string
+}
+
+// Create implements Storage. Files are written under a temporary name and
+// renamed on Close.
+func (s LocalStorage) Create(_ context.Context, name string) (io.WriteCloser, error) {
+	target := filepath.Join(s.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(target + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &localFile{File: f, target: target}, nil
+}
+
+type localFile struct {
+	*os.File
+	target string
+}
+
+func (f *localFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), f.target)
+}
+
+// S3Uploader uploads an object. It is typically a thin wrapper around the
+// upload manager of the AWS SDK, which this package does not depend on.
+type S3Uploader interface {
+	Upload(ctx context.Context, bucket, key string, body io.Reader) error
+}
+
+// S3Storage stores files in Bucket below Prefix. Files are buffered in memory
+// and uploaded on Close.
+type S3Storage struct {
+	Uploader S3Uploader
+	Bucket   string
+	Prefix   string
+}
+
+// Create implements Storage.
+func (s S3Storage) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	key := path.Join(s.Prefix, name)
+	return &s3Object{ctx: ctx, storage: s, key: key}, nil
+}
+
+type s3Object struct {
+	bytes.Buffer
+	ctx     context.Context
+	storage S3Storage
+	key     string
+}
+
+func (o *s3Object) Close() error {
+	return o.storage.Uploader.Upload(o.ctx, o.storage.Bucket, o.key, &o.Buffer)
+}
+
+// ParquetConfig configures a Parquet sink.
+type ParquetConfig struct {
+	Storage Storage
+	// InputColumns are the input features written next to the predictions.
+	// Columns missing from an input are written as nulls.
+	InputColumns []string
+	// Partition returns the directory of a record. It defaults to Hive style
+	// "model=<name>/date=<yyyy-mm-dd>" partitions. Records of a partition
+	// must come from models with the same input column types.
+	Partition func(r jams.PredictionRecord) string
+	// BatchSize is the number of Predict calls per flush, 1000 by default.
+	// Every flush writes one file per partition.
+	BatchSize int
+	// FlushInterval is the longest a record is buffered, 1m by default.
+	FlushInterval time.Duration
+	// OnError receives write errors. Records of a failed flush are dropped.
+	OnError func(error)
+}
+
+// Parquet writes successful predictions to partitioned Parquet files with one
+// row per predicted record. Rows hold the request ID, model name, call time,
+// record index, the selected input columns and one DOUBLE column per output
+// value, named "<output>_<k>", e.g. "predictions_0" and "predictions_1" for a
+// binary classifier. Files are uncompressed and PLAIN encoded.
+type Parquet struct {
+	config  ParquetConfig
+	batcher *batcher
+	seq     atomic.Uint64
+}
+
+// NewParquet returns a Parquet sink.
+func NewParquet(config ParquetConfig) (*Parquet, error) {
+	if config.Storage == nil {
+		return nil, errors.New("a storage is required")
+	}
+	if config.Partition == nil {
+		config.Partition = DefaultPartition
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Minute
+	}
+	p := &Parquet{config: config}
+	p.batcher = newBatcher(config.BatchSize, config.FlushInterval, config.OnError, p.write)
+	return p, nil
+}
+
+// DefaultPartition partitions records by model and UTC date.
+func DefaultPartition(r jams.PredictionRecord) string {
+	return fmt.Sprintf("model=%s/date=%s", r.Model, r.Time.UTC().Format("2006-01-02"))
+}
+
+// Record implements jams.PredictionSink. Failed predictions are ignored.
+func (p *Parquet) Record(r jams.PredictionRecord) {
+	if r.Err != nil || r.Prediction == nil {
+		return
+	}
+	p.batcher.add(r)
+}
+
+// Flush writes the buffered records.
+func (p *Parquet) Flush(ctx context.Context) error {
+	return p.batcher.flushAll(ctx)
+}
+
+// Close writes the buffered records and stops the sink.
+func (p *Parquet) Close() error {
+	return p.batcher.close(context.Background())
+}
+
+func (p *Parquet) write(ctx context.Context, records []jams.PredictionRecord) error {
+	partitions := make(map[string][]jams.PredictionRecord)
+	for _, r := range records {
+		key := p.config.Partition(r)
+		partitions[key] = append(partitions[key], r)
+	}
+	keys := make([]string, 0, len(partitions))
+	for key := range partitions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		name := fmt.Sprintf("%s/part-%s-%04d.parquet", key, time.Now().UTC().Format("20060102T150405"), p.seq.Add(1))
+		if err := p.writeFile(ctx, name, partitions[key]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Parquet) writeFile(ctx context.Context, name string, records []jams.PredictionRecord) error {
+	columns := p.columns(records)
+	f, err := p.config.Storage.Create(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := writeParquet(f, columns, parquetRowGroupRows); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// columns returns the Parquet columns of a file holding records, with one row
+// per predicted record.
+func (p *Parquet) columns(records []jams.PredictionRecord) []parquetColumn {
+	requestID := parquetColumn{name: "request_id", typ: parquetByteArray, converted: convertedUTF8}
+	model := parquetColumn{name: "model_name", typ: parquetByteArray, converted: convertedUTF8}
+	created := parquetColumn{name: "created_at", typ: parquetInt64, converted: convertedTimestampMicros}
+	index := parquetColumn{name: "record", typ: parquetInt64, converted: convertedNone}
+
+	inputs := make([]parquetColumn, len(p.config.InputColumns))
+	for i, name := range p.config.InputColumns {
+		typ, converted := columnType(records, name)
+		inputs[i] = parquetColumn{name: name, typ: typ, converted: converted, optional: true}
+	}
+	widths := outputWidths(records)
+	names := make([]string, 0, len(widths))
+	for name := range widths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var outputs []parquetColumn
+	for _, name := range names {
+		digits := len(fmt.Sprint(widths[name] - 1))
+		for k := 0; k < widths[name]; k++ {
+			outputs = append(outputs, parquetColumn{
+				name:      fmt.Sprintf("%s_%0*d", name, digits, k),
+				typ:       parquetDouble,
+				converted: convertedNone,
+				optional:  true,
+			})
+		}
+	}
+
+	for _, r := range records {
+		for i := 0; i < r.Input.Len(); i++ {
+			requestID.values = append(requestID.values, r.RequestID)
+			model.values = append(model.values, r.Model)
+			created.values = append(created.values, r.Time.UnixMicro())
+			index.values = append(index.values, int64(i))
+			for c, name := range p.config.InputColumns {
+				var value any
+				if values, ok := r.Input.Column(name); ok && i < len(values) {
+					value = values[i]
+				}
+				// values of another type than the first one found are dropped.
+				if !matchesType(value, inputs[c].typ) {
+					value = nil
+				}
+				inputs[c].values = append(inputs[c].values, value)
+			}
+			record := r.Prediction.Record(i)
+			o := 0
+			for _, name := range names {
+				for k := 0; k < widths[name]; k++ {
+					var value any
+					if k < len(record[name]) {
+						value = record[name][k]
+					}
+					outputs[o].values = append(outputs[o].values, value)
+					o++
+				}
+			}
+		}
+	}
+
+	columns := []parquetColumn{requestID, model, created, index}
+	columns = append(columns, inputs...)
+	return append(columns, outputs...)
+}
+
+// columnType returns the Parquet type of the first value found in an input
+// column, defaulting to strings.
+func columnType(records []jams.PredictionRecord, column string) (int32, int32) {
+	for _, r := range records {
+		values, _ := r.Input.Column(column)
+		for _, v := range values {
+			switch v.(type) {
+			case int64:
+				return parquetInt64, convertedNone
+			case float64:
+				return parquetDouble, convertedNone
+			case string:
+				return parquetByteArray, convertedUTF8
+			}
+		}
+	}
+	return parquetByteArray, convertedUTF8
+}
+
+func matchesType(v any, typ int32) bool {
+	switch v.(type) {
+	case int64:
+		return typ == parquetInt64
+	case float64:
+		return typ == parquetDouble
+	case string:
+		return typ == parquetByteArray
+	}
+	return false
+}
+
+// outputWidths returns the largest number of values per record of every
+// output.
+func outputWidths(records []jams.PredictionRecord) map[string]int {
+	widths := make(map[string]int)
+	for _, r := range records {
+		for name, rows := range r.Prediction.Outputs {
+			for _, row := range rows {
+				widths[name] = max(widths[name], len(row))
+			}
+		}
+	}
+	return widths
+}
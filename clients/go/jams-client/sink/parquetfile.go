@@ -0,0 +1,317 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// This file implements the small subset of the Parquet format needed by the
+// Parquet sink: row groups of flat REQUIRED or OPTIONAL columns, each column
+// chunk stored as one uncompressed, PLAIN encoded data page.
+
+// Parquet physical types.
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+)
+
+// Parquet converted types.
+const (
+	convertedNone            = -1
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+)
+
+// parquetRowGroupRows is the number of rows per row group of the files of the
+// Parquet sink, bounding the size of their pages.
+const parquetRowGroupRows = 1 << 16
+
+// parquetColumn is a column of a Parquet file. Values are int64, float64 or
+// string according to typ, or nil for nulls in optional columns.
+type parquetColumn struct {
+	name      string
+	typ       int32
+	converted int32
+	optional  bool
+	values    []any
+}
+
+// writeParquet writes columns, which must have the same number of values, as
+// a Parquet file of row groups of up to groupRows rows.
+func writeParquet(w io.Writer, columns []parquetColumn, groupRows int) error {
+	out := bufio.NewWriter(w)
+	offset := int64(0)
+	write := func(data []byte) {
+		out.Write(data)
+		offset += int64(len(data))
+	}
+	write([]byte("PAR1"))
+
+	rows := 0
+	if len(columns) > 0 {
+		rows = len(columns[0].values)
+	}
+	for _, column := range columns {
+		if len(column.values) != rows {
+			return fmt.Errorf("column %q has %d values, expected %d", column.name, len(column.values), rows)
+		}
+	}
+
+	// groups holds the encoded RowGroups of the footer.
+	var groups [][]byte
+	for start, end := 0, 0; end < rows || len(groups) == 0; start = end {
+		end = min(start+groupRows, rows)
+		chunks := make([][]byte, len(columns))
+		var total int64
+		for i, column := range columns {
+			column.values = column.values[start:end]
+			page, err := column.page()
+			if err != nil {
+				return err
+			}
+			var header thriftWriter
+			header.i32(1, 0) // DATA_PAGE
+			header.i32(2, int32(len(page)))
+			header.i32(3, int32(len(page)))
+			header.beginStruct(5)
+			header.i32(1, int32(end-start))
+			header.i32(2, 0) // PLAIN
+			header.i32(3, 3) // RLE
+			header.i32(4, 3) // RLE
+			header.endStruct()
+			header.stop()
+
+			size := int64(len(header.Bytes()) + len(page))
+			// the ColumnChunk is added to the footer once all pages are
+			// written.
+			var meta thriftWriter
+			meta.i64(2, offset)
+			meta.beginStruct(3)
+			meta.i32(1, column.typ)
+			meta.i32List(2, []int32{0, 3})
+			meta.stringList(3, []string{column.name})
+			meta.i32(4, 0) // UNCOMPRESSED
+			meta.i64(5, int64(end-start))
+			meta.i64(6, size)
+			meta.i64(7, size)
+			meta.i64(9, offset)
+			meta.endStruct()
+			meta.stop()
+			chunks[i] = meta.Bytes()
+
+			write(header.Bytes())
+			write(page)
+			total += size
+		}
+
+		var group thriftWriter
+		group.listHeader(1, thriftStruct, len(columns))
+		for _, chunk := range chunks {
+			group.Write(chunk)
+		}
+		group.i64(2, total)
+		group.i64(3, int64(end-start))
+		group.stop()
+		groups = append(groups, group.Bytes())
+	}
+
+	var footer thriftWriter
+	footer.i32(1, 1)
+	footer.listHeader(2, thriftStruct, len(columns)+1)
+	footer.beginStruct(0)
+	footer.str(4, "schema")
+	footer.i32(5, int32(len(columns)))
+	footer.endStruct()
+	for _, column := range columns {
+		footer.beginStruct(0)
+		footer.i32(1, column.typ)
+		repetition := int32(0)
+		if column.optional {
+			repetition = 1
+		}
+		footer.i32(3, repetition)
+		footer.str(4, column.name)
+		if column.converted != convertedNone {
+			footer.i32(6, column.converted)
+		}
+		footer.endStruct()
+	}
+	footer.i64(3, int64(rows))
+	footer.listHeader(4, thriftStruct, len(groups))
+	for _, group := range groups {
+		footer.Write(group)
+	}
+	footer.str(6, "jams-client")
+	footer.stop()
+
+	write(footer.Bytes())
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer.Bytes())))
+	write(length[:])
+	write([]byte("PAR1"))
+	return out.Flush()
+}
+
+// page returns the data of the column's data page: definition levels for
+// optional columns followed by the PLAIN encoded non null values.
+func (c parquetColumn) page() ([]byte, error) {
+	var page bytes.Buffer
+	if c.optional {
+		levels := rleLevels(c.values)
+		binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+	for i, v := range c.values {
+		if v == nil {
+			if !c.optional {
+				return nil, fmt.Errorf("column %q: record %d is null", c.name, i)
+			}
+			continue
+		}
+		var ok bool
+		switch c.typ {
+		case parquetInt64:
+			var n int64
+			if n, ok = v.(int64); ok {
+				binary.Write(&page, binary.LittleEndian, n)
+			}
+		case parquetDouble:
+			var f float64
+			if f, ok = v.(float64); ok {
+				binary.Write(&page, binary.LittleEndian, math.Float64bits(f))
+			}
+		case parquetByteArray:
+			var s string
+			if s, ok = v.(string); ok {
+				binary.Write(&page, binary.LittleEndian, uint32(len(s)))
+				page.WriteString(s)
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("column %q: record %d has unexpected type %T", c.name, i, v)
+		}
+	}
+	return page.Bytes(), nil
+}
+
+// rleLevels encodes the definition levels of values, 0 for nulls and 1
+// otherwise, with the RLE hybrid encoding using RLE runs only.
+func rleLevels(values []any) []byte {
+	var out []byte
+	for i := 0; i < len(values); {
+		level := byte(1)
+		if values[i] == nil {
+			level = 0
+		}
+		run := 1
+		for i+run < len(values) && (values[i+run] == nil) == (level == 0) {
+			run++
+		}
+		out = binary.AppendUvarint(out, uint64(run)<<1)
+		out = append(out, level)
+		i += run
+	}
+	return out
+}
+
+// Thrift compact protocol types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs with the Thrift compact protocol, as used by
+// Parquet metadata.
+type thriftWriter struct {
+	bytes.Buffer
+	last  int16
+	stack []int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.Write(binary.AppendUvarint(nil, uint64((v<<1)^(v>>63))))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) str(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.rawString(s)
+}
+
+func (t *thriftWriter) rawString(s string) {
+	t.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	t.WriteString(s)
+}
+
+// listHeader starts a list field of n elements of the given type.
+func (t *thriftWriter) listHeader(id int16, typ byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.WriteByte(byte(n)<<4 | typ)
+	} else {
+		t.WriteByte(0xf0 | typ)
+		t.Write(binary.AppendUvarint(nil, uint64(n)))
+	}
+}
+
+func (t *thriftWriter) i32List(id int16, values []int32) {
+	t.listHeader(id, thriftI32, len(values))
+	for _, v := range values {
+		t.varint(int64(v))
+	}
+}
+
+func (t *thriftWriter) stringList(id int16, values []string) {
+	t.listHeader(id, thriftBinary, len(values))
+	for _, v := range values {
+		t.rawString(v)
+	}
+}
+
+// beginStruct starts a nested struct, either as field id or, when id is zero,
+// as a list element. It must be closed with endStruct.
+func (t *thriftWriter) beginStruct(id int16) {
+	if id != 0 {
+		t.field(id, thriftStruct)
+	}
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// stop ends the fields of the top level struct.
+func (t *thriftWriter) stop() {
+	t.WriteByte(0)
+}
@@ -0,0 +1,513 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// The reader below decodes Parquet files following the format specification,
+// independently of the writer, so that round trips catch encoding mistakes
+// rather than repeating them. It reads any Thrift compact struct, every data
+// page of a column chunk and both runs of the RLE / bit-packed hybrid
+// encoding.
+
+// thriftReader decodes Thrift compact protocol values. Structs are decoded
+// into maps of field id to value.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		panic(fmt.Sprintf("invalid varint at %d", r.pos))
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case 1, 2: // list element booleans, struct fields hold them in the type
+		return r.byte() == 1
+	case 3:
+		return int8(r.byte())
+	case 4, 5, 6:
+		return r.zigzag()
+	case 7:
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+		r.pos += 8
+		return v
+	case 8:
+		n := int(r.uvarint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case 9, 10:
+		header := r.byte()
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case 11:
+		n := int(r.uvarint())
+		m := make(map[any]any, n)
+		if n > 0 {
+			types := r.byte()
+			for i := 0; i < n; i++ {
+				k := r.value(types >> 4)
+				m[k] = r.value(types & 0x0f)
+			}
+		}
+		return m
+	case 12:
+		return r.structure()
+	}
+	panic(fmt.Sprintf("unknown thrift type %d at %d", typ, r.pos))
+}
+
+func (r *thriftReader) structure() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		last = id
+		switch typ := header & 0x0f; typ {
+		case 1:
+			fields[id] = true
+		case 2:
+			fields[id] = false
+		default:
+			fields[id] = r.value(typ)
+		}
+	}
+}
+
+// parquetTable is the content of a Parquet file.
+type parquetTable struct {
+	// columns holds the type, converted type (-1 when unset), repetition
+	// and name of the columns.
+	columns []string
+	// groups holds the number of rows of every row group.
+	groups []int64
+	values map[string][]any
+}
+
+// readParquet reads a Parquet file of flat columns.
+func readParquet(t *testing.T, data []byte) parquetTable {
+	t.Helper()
+	var table parquetTable
+	defer func() {
+		if err := recover(); err != nil {
+			t.Fatalf("failed to read the parquet file: %v", err)
+		}
+	}()
+
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatalf("missing magic number")
+	}
+	length := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftReader{data: data[len(data)-8-length : len(data)-8]}
+	meta := footer.structure()
+	if footer.pos != length {
+		t.Fatalf("footer is %d bytes, decoded %d", length, footer.pos)
+	}
+
+	schema := meta[2].([]any)
+	root := schema[0].(map[int16]any)
+	if root[5] != int64(len(schema)-1) {
+		t.Fatalf("root has %v children, schema has %d leaves", root[5], len(schema)-1)
+	}
+	optional := make(map[string]bool)
+	for _, element := range schema[1:] {
+		leaf := element.(map[int16]any)
+		converted, ok := leaf[6]
+		if !ok {
+			converted = int64(-1)
+		}
+		name := leaf[4].(string)
+		table.columns = append(table.columns, fmt.Sprintf("%d %d %d %s", leaf[1], converted, leaf[3], name))
+		optional[name] = leaf[3] == int64(1)
+	}
+
+	table.values = make(map[string][]any)
+	var rows int64
+	for _, element := range meta[4].([]any) {
+		group := element.(map[int16]any)
+		table.groups = append(table.groups, group[3].(int64))
+		rows += group[3].(int64)
+		chunks := group[1].([]any)
+		if len(chunks) != len(schema)-1 {
+			t.Fatalf("row group has %d column chunks, expected %d", len(chunks), len(schema)-1)
+		}
+		var size int64
+		for _, chunk := range chunks {
+			chunkMeta := chunk.(map[int16]any)[3].(map[int16]any)
+			name := chunkMeta[3].([]any)[0].(string)
+			if chunkMeta[4] != int64(0) {
+				t.Fatalf("column %s: codec %v", name, chunkMeta[4])
+			}
+			values := readChunk(t, data, chunkMeta, optional[name])
+			if int64(len(values)) != group[3] {
+				t.Fatalf("column %s: %d values in a row group of %d rows", name, len(values), group[3])
+			}
+			table.values[name] = append(table.values[name], values...)
+			size += chunkMeta[7].(int64)
+		}
+		if group[2] != size {
+			t.Fatalf("row group size is %v, column chunks take %d bytes", group[2], size)
+		}
+	}
+	if meta[3] != rows {
+		t.Fatalf("file has %v rows, row groups have %d", meta[3], rows)
+	}
+	return table
+}
+
+// readChunk returns the values of a column chunk, nil for nulls.
+func readChunk(t *testing.T, data []byte, meta map[int16]any, optional bool) []any {
+	t.Helper()
+	typ, count := meta[1].(int64), meta[5].(int64)
+	offset, end := meta[9].(int64), meta[9].(int64)+meta[7].(int64)
+	var values []any
+	for offset < end {
+		header := &thriftReader{data: data, pos: int(offset)}
+		page := header.structure()
+		if page[1] != int64(0) {
+			t.Fatalf("page type %v", page[1])
+		}
+		if page[2] != page[3] {
+			t.Fatalf("uncompressed page size %v, compressed %v", page[2], page[3])
+		}
+		dataPage := page[5].(map[int16]any)
+		if dataPage[2] != int64(0) {
+			t.Fatalf("page encoding %v", dataPage[2])
+		}
+		body := data[header.pos : header.pos+int(page[3].(int64))]
+		offset = int64(header.pos) + page[3].(int64)
+
+		n := int(dataPage[1].(int64))
+		defined := make([]bool, n)
+		for i := range defined {
+			defined[i] = true
+		}
+		if optional {
+			size := int(binary.LittleEndian.Uint32(body))
+			defined = readLevels(t, body[4:4+size], n)
+			body = body[4+size:]
+		}
+		for _, ok := range defined {
+			if !ok {
+				values = append(values, nil)
+				continue
+			}
+			switch typ {
+			case parquetInt64:
+				values = append(values, int64(binary.LittleEndian.Uint64(body)))
+				body = body[8:]
+			case parquetDouble:
+				values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(body)))
+				body = body[8:]
+			case parquetByteArray:
+				size := binary.LittleEndian.Uint32(body)
+				values = append(values, string(body[4:4+size]))
+				body = body[4+size:]
+			default:
+				t.Fatalf("unexpected physical type %d", typ)
+			}
+		}
+		if len(body) != 0 {
+			t.Fatalf("%d bytes left in a page", len(body))
+		}
+	}
+	if offset != end || meta[6] != meta[7] {
+		t.Fatalf("column chunk size is %v, pages take %d bytes", meta[7], offset-meta[9].(int64))
+	}
+	if int64(len(values)) != count {
+		t.Fatalf("column chunk has %d values, pages hold %d", count, len(values))
+	}
+	return values
+}
+
+// readLevels decodes n definition levels of bit width 1 encoded with the RLE
+// / bit-packed hybrid encoding.
+func readLevels(t *testing.T, data []byte, n int) []bool {
+	t.Helper()
+	r := &thriftReader{data: data}
+	var levels []bool
+	for r.pos < len(data) {
+		header := r.uvarint()
+		if header&1 == 1 {
+			for i := 0; i < int(header>>1); i++ {
+				b := r.byte()
+				for bit := 0; bit < 8; bit++ {
+					levels = append(levels, b>>bit&1 == 1)
+				}
+			}
+		} else {
+			level := r.byte()
+			if level > 1 {
+				t.Fatalf("definition level %d", level)
+			}
+			for i := 0; i < int(header>>1); i++ {
+				levels = append(levels, level == 1)
+			}
+		}
+	}
+	if len(levels) < n {
+		t.Fatalf("decoded %d definition levels, expected %d", len(levels), n)
+	}
+	return levels[:n]
+}
+
+func TestWriteParquet(t *testing.T) {
+	rows := func(n int, value func(i int) any) []any {
+		values := make([]any, n)
+		for i := range values {
+			values[i] = value(i)
+		}
+		return values
+	}
+	nullEvery := func(k int, value func(i int) any) func(i int) any {
+		return func(i int) any {
+			if i%k == 0 {
+				return nil
+			}
+			return value(i)
+		}
+	}
+	wide := make([]parquetColumn, 20)
+	for c := range wide {
+		wide[c] = parquetColumn{name: fmt.Sprint("c", c), typ: parquetDouble, converted: convertedNone, optional: c%2 == 0,
+			values: rows(5, func(i int) any { return float64(c*i) / 3 })}
+	}
+
+	tests := []struct {
+		name      string
+		columns   []parquetColumn
+		groupRows int
+		groups    []int64
+	}{
+		{
+			name: "types and nulls",
+			columns: []parquetColumn{
+				{name: "id", typ: parquetInt64, converted: convertedNone,
+					values: rows(10, func(i int) any { return int64(i) - 5 })},
+				{name: "created_at", typ: parquetInt64, converted: convertedTimestampMicros,
+					values: rows(10, func(i int) any { return int64(1700000000000000 + i) })},
+				{name: "score", typ: parquetDouble, converted: convertedNone, optional: true,
+					values: rows(10, nullEvery(3, func(i int) any { return float64(i) * 0.25 }))},
+				{name: "label", typ: parquetByteArray, converted: convertedUTF8, optional: true,
+					values: rows(10, nullEvery(4, func(i int) any { return strings.Repeat("é", i%3) }))},
+				{name: "count", typ: parquetInt64, converted: convertedNone, optional: true,
+					values: rows(10, nullEvery(2, func(i int) any { return int64(math.MaxInt64 - i) }))},
+				{name: "empty", typ: parquetByteArray, converted: convertedUTF8, optional: true,
+					values: rows(10, func(int) any { return nil })},
+				{name: "full", typ: parquetDouble, converted: convertedNone, optional: true,
+					values: rows(10, func(i int) any { return math.Inf(1 - 2*(i%2)) })},
+			},
+			groupRows: 1000,
+			groups:    []int64{10},
+		},
+		{
+			name: "row groups",
+			columns: []parquetColumn{
+				{name: "id", typ: parquetInt64, converted: convertedNone,
+					values: rows(10, func(i int) any { return int64(i) })},
+				{name: "score", typ: parquetDouble, converted: convertedNone, optional: true,
+					values: rows(10, nullEvery(3, func(i int) any { return float64(i) }))},
+				{name: "label", typ: parquetByteArray, converted: convertedUTF8, optional: true,
+					values: rows(10, nullEvery(2, func(i int) any { return fmt.Sprint(i) }))},
+			},
+			groupRows: 3,
+			groups:    []int64{3, 3, 3, 1},
+		},
+		{
+			name: "long runs",
+			columns: []parquetColumn{
+				{name: "score", typ: parquetDouble, converted: convertedNone, optional: true,
+					values: rows(1000, func(i int) any {
+						if i < 300 || i >= 900 {
+							return nil
+						}
+						return float64(i)
+					})},
+			},
+			groupRows: 500,
+			groups:    []int64{500, 500},
+		},
+		{
+			name:      "wide",
+			columns:   wide,
+			groupRows: 2,
+			groups:    []int64{2, 2, 1},
+		},
+		{
+			name: "no rows",
+			columns: []parquetColumn{
+				{name: "id", typ: parquetInt64, converted: convertedNone},
+				{name: "label", typ: parquetByteArray, converted: convertedUTF8, optional: true},
+			},
+			groupRows: 10,
+			groups:    []int64{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeParquet(&buf, tt.columns, tt.groupRows); err != nil {
+				t.Fatalf("writeParquet: %v", err)
+			}
+			table := readParquet(t, buf.Bytes())
+
+			if !reflect.DeepEqual(table.groups, tt.groups) {
+				t.Errorf("row groups = %v, want %v", table.groups, tt.groups)
+			}
+			for i, column := range tt.columns {
+				repetition := 0
+				if column.optional {
+					repetition = 1
+				}
+				want := fmt.Sprintf("%d %d %d %s", column.typ, column.converted, repetition, column.name)
+				if i >= len(table.columns) || table.columns[i] != want {
+					t.Fatalf("columns = %q, want %q at %d", table.columns, want, i)
+				}
+				if got := table.values[column.name]; !reflect.DeepEqual(got, column.values) {
+					t.Errorf("column %s = %v, want %v", column.name, got, column.values)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteParquetErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []parquetColumn
+	}{
+		{
+			name: "lengths",
+			columns: []parquetColumn{
+				{name: "a", typ: parquetInt64, values: []any{int64(1), int64(2)}},
+				{name: "b", typ: parquetInt64, values: []any{int64(1)}},
+			},
+		},
+		{
+			name:    "required null",
+			columns: []parquetColumn{{name: "a", typ: parquetInt64, values: []any{int64(1), nil}}},
+		},
+		{
+			name:    "type",
+			columns: []parquetColumn{{name: "a", typ: parquetDouble, optional: true, values: []any{int64(1)}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := writeParquet(&bytes.Buffer{}, tt.columns, 10); err == nil {
+				t.Fatalf("writeParquet succeeded")
+			}
+		})
+	}
+}
+
+func TestParquetSink(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewParquet(ParquetConfig{
+		Storage:      LocalStorage{Dir: dir},
+		InputColumns: []string{"age", "name", "missing"},
+		OnError:      func(err error) { t.Errorf("OnError: %v", err) },
+	})
+	if err != nil {
+		t.Fatalf("NewParquet: %v", err)
+	}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sink.Record(jams.PredictionRecord{
+		RequestID: "r1",
+		Model:     "titanic",
+		Time:      at,
+		Input:     types.NewInput().AddInts("age", 30, 40).AddStrings("name", "a", "b"),
+		Prediction: &types.Prediction{Outputs: map[string][][]float64{
+			"predictions": {{0.25, 0.75}, {0.5, 0.5}},
+		}},
+	})
+	sink.Record(jams.PredictionRecord{
+		RequestID: "r2",
+		Model:     "titanic",
+		Time:      at.Add(time.Second),
+		Input:     types.NewInput().AddStrings("name", "c"),
+		Prediction: &types.Prediction{Outputs: map[string][][]float64{
+			"predictions": {{1}},
+		}},
+	})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "model=titanic", "date=2024-05-01", "*.parquet"))
+	if len(files) != 1 {
+		t.Fatalf("files = %v, want one", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := readParquet(t, data)
+
+	micros := at.UnixMicro()
+	want := map[string][]any{
+		"request_id":    {"r1", "r1", "r2"},
+		"model_name":    {"titanic", "titanic", "titanic"},
+		"created_at":    {micros, micros, micros + 1e6},
+		"record":        {int64(0), int64(1), int64(0)},
+		"age":           {int64(30), int64(40), nil},
+		"name":          {"a", "b", "c"},
+		"missing":       {nil, nil, nil},
+		"predictions_0": {0.25, 0.5, 1.0},
+		"predictions_1": {0.75, 0.5, nil},
+	}
+	if !reflect.DeepEqual(table.values, want) {
+		t.Errorf("values = %v, want %v", table.values, want)
+	}
+	wantColumns := []string{
+		"6 0 0 request_id", "6 0 0 model_name", "2 10 0 created_at", "2 -1 0 record",
+		"2 -1 1 age", "6 0 1 name", "6 0 1 missing", "5 -1 1 predictions_0", "5 -1 1 predictions_1",
+	}
+	if !reflect.DeepEqual(table.columns, wantColumns) {
+		t.Errorf("columns = %q, want %q", table.columns, wantColumns)
+	}
+}
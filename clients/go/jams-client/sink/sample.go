@@ -0,0 +1,179 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// SamplerConfig configures a Sampler.
+type SamplerConfig struct {
+	// Rate is the fraction of the Predict calls of each model passed on,
+	// from 0 to 1.
+	Rate float64
+	// ModelRates overrides Rate for the named models.
+	ModelRates map[string]float64
+	// Reservoir, when positive, passes on a uniform sample of at most
+	// Reservoir calls of each model per Interval instead of a fraction, so
+	// that rarely called models are as represented as busy ones. Rate and
+	// ModelRates are ignored.
+	Reservoir int
+	// Interval is the period of a reservoir, whose sample is passed on at its
+	// end, 1h by default.
+	Interval time.Duration
+	// Failed also samples failed calls, which are dropped by default.
+	Failed bool
+	// Redaction is applied to the inputs of sampled calls, on top of the
+	// redactions of the client, e.g. to keep more columns out of datasets
+	// than out of debug dumps.
+	Redaction *jams.Redaction
+}
+
+// Sampler passes a sample of the Predict calls it records on to another sink,
+// e.g. to build labelled evaluation datasets without storing every call:
+//
+//	sampler, err := sink.NewSampler(parquetSink, sink.SamplerConfig{Rate: 0.01})
+//	defer sampler.Close()
+//	client, err := jams.NewHTTPClient("http://localhost:3000", jams.WithPredictionSink(sampler))
+type Sampler struct {
+	next   jams.PredictionSink
+	config SamplerConfig
+
+	mu         sync.Mutex
+	reservoirs map[string]*reservoir
+	done       chan struct{}
+	stopped    chan struct{}
+	stop       sync.Once
+}
+
+// reservoir is a uniform sample of the calls of a model, kept with Vitter's
+// algorithm R.
+type reservoir struct {
+	seen    int
+	records []jams.PredictionRecord
+}
+
+// NewSampler returns a Sampler passing sampled calls on to next.
+func NewSampler(next jams.PredictionSink, config SamplerConfig) (*Sampler, error) {
+	if next == nil {
+		return nil, errors.New("a sink is required")
+	}
+	if config.Rate < 0 || config.Rate > 1 {
+		return nil, fmt.Errorf("sample rate %v is not between 0 and 1", config.Rate)
+	}
+	for model, rate := range config.ModelRates {
+		if rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("sample rate %v of model %q is not between 0 and 1", rate, model)
+		}
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	s := &Sampler{
+		next:       next,
+		config:     config,
+		reservoirs: make(map[string]*reservoir),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	if config.Reservoir > 0 {
+		go s.run()
+	} else {
+		close(s.stopped)
+	}
+	return s, nil
+}
+
+// Record implements jams.PredictionSink.
+func (s *Sampler) Record(r jams.PredictionRecord) {
+	if r.Err != nil && !s.config.Failed {
+		return
+	}
+	if s.config.Reservoir > 0 {
+		s.keep(r)
+		return
+	}
+	rate, ok := s.config.ModelRates[r.Model]
+	if !ok {
+		rate = s.config.Rate
+	}
+	if rate > 0 && rand.Float64() < rate {
+		s.next.Record(s.redact(r))
+	}
+}
+
+// keep adds r to the reservoir of its model.
+func (s *Sampler) keep(r jams.PredictionRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.reservoirs[r.Model]
+	if !ok {
+		res = &reservoir{}
+		s.reservoirs[r.Model] = res
+	}
+	res.seen++
+	if len(res.records) < s.config.Reservoir {
+		res.records = append(res.records, s.redact(r))
+	} else if i := rand.Intn(res.seen); i < s.config.Reservoir {
+		res.records[i] = s.redact(r)
+	}
+}
+
+func (s *Sampler) redact(r jams.PredictionRecord) jams.PredictionRecord {
+	if s.config.Redaction != nil && r.Input != nil {
+		r.Input = s.config.Redaction.Apply(r.Input)
+	}
+	return r
+}
+
+func (s *Sampler) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Flush passes the reservoirs on, sorted by model then call time, and starts
+// new ones. It does nothing without Reservoir.
+func (s *Sampler) Flush() {
+	s.mu.Lock()
+	reservoirs := s.reservoirs
+	s.reservoirs = make(map[string]*reservoir)
+	s.mu.Unlock()
+
+	var records []jams.PredictionRecord
+	for _, res := range reservoirs {
+		records = append(records, res.records...)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Model != records[j].Model {
+			return records[i].Model < records[j].Model
+		}
+		return records[i].Time.Before(records[j].Time)
+	})
+	for _, r := range records {
+		s.next.Record(r)
+	}
+}
+
+// Close passes the reservoirs on and stops the sampler. It does not close the
+// sink calls are passed on to.
+func (s *Sampler) Close() error {
+	s.stop.Do(func() { close(s.done) })
+	<-s.stopped
+	s.Flush()
+	return nil
+}
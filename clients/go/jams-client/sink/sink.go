@@ -0,0 +1,132 @@
+// Package sink provides jams.PredictionSink implementations which persist
+// predictions for offline evaluation.
+//
+//	sqlSink, err := sink.NewSQL(db, sink.SQLConfig{Dialect: sink.Postgres})
+//	defer sqlSink.Close()
+//	client, err := jams.NewHTTPClient("http://localhost:3000", jams.WithPredictionSink(sqlSink))
+//
+// Sinks buffer records and write them in batches from a background goroutine,
+// so Predict is never slowed down by the destination. Write errors are passed
+// to the OnError callback of the sink.
+package sink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// ErrBufferFull is passed to OnError when records are dropped because the
+// destination does not keep up.
+var ErrBufferFull = errors.New("sink buffer is full, dropping records")
+
+// batcher buffers records and hands them to flush in batches, either once
+// batchSize records are buffered or every interval.
+type batcher struct {
+	flush     func(ctx context.Context, records []jams.PredictionRecord) error
+	onError   func(error)
+	batchSize int
+	maxBuffer int
+
+	mu      sync.Mutex
+	buffer  []jams.PredictionRecord
+	dropped bool
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	stop    sync.Once
+	flushMu sync.Mutex
+}
+
+func newBatcher(batchSize int, interval time.Duration, onError func(error), flush func(ctx context.Context, records []jams.PredictionRecord) error) *batcher {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	if onError == nil {
+		onError = func(error) {}
+	}
+	b := &batcher{
+		flush:     flush,
+		onError:   onError,
+		batchSize: batchSize,
+		maxBuffer: 10 * batchSize,
+		full:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go b.run(interval)
+	return b
+}
+
+func (b *batcher) add(r jams.PredictionRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.buffer) >= b.maxBuffer {
+		if !b.dropped {
+			b.dropped = true
+			go b.onError(ErrBufferFull)
+		}
+		return
+	}
+	b.dropped = false
+	b.buffer = append(b.buffer, r)
+	if len(b.buffer) >= b.batchSize {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *batcher) run(interval time.Duration) {
+	defer close(b.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.full:
+		case <-b.done:
+			return
+		}
+		if err := b.flushAll(context.Background()); err != nil {
+			b.onError(err)
+		}
+	}
+}
+
+// flushAll writes every buffered record in batches of batchSize. Records of a
+// failed batch are dropped.
+func (b *batcher) flushAll(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	records := b.buffer
+	b.buffer = nil
+	b.mu.Unlock()
+
+	var errs []error
+	for len(records) > 0 {
+		n := min(len(records), b.batchSize)
+		if err := b.flush(ctx, records[:n]); err != nil {
+			errs = append(errs, err)
+		}
+		records = records[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// close stops the background goroutine and writes the remaining records.
+func (b *batcher) close(ctx context.Context) error {
+	b.stop.Do(func() { close(b.done) })
+	<-b.stopped
+	return b.flushAll(ctx)
+}
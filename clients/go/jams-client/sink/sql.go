@@ -0,0 +1,156 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+)
+
+// Dialect selects the SQL flavour of the target database.
+type Dialect string
+
+// Supported dialects.
+const (
+	Postgres Dialect = "postgres"
+	MySQL    Dialect = "mysql"
+)
+
+// DefaultTable is the table written to when SQLConfig.Table is empty.
+const DefaultTable = "predictions"
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// sqlColumns are the columns written for every record.
+var sqlColumns = []string{"request_id", "model_name", "created_at", "latency_ms", "input", "output", "error"}
+
+// SQLConfig configures a SQL sink.
+type SQLConfig struct {
+	Dialect Dialect
+	// Table defaults to DefaultTable and may be schema qualified.
+	Table string
+	// BatchSize is the number of rows per INSERT, 100 by default.
+	BatchSize int
+	// FlushInterval is the longest a record is buffered, 5s by default.
+	FlushInterval time.Duration
+	// OnError receives write errors. Records of a failed batch are dropped.
+	OnError func(error)
+}
+
+// SQL writes prediction records to a Postgres or MySQL table using batched
+// inserts. The database driver is chosen by the caller when opening db. The
+// table can be created with the statement returned by Schema.
+type SQL struct {
+	db      *sql.DB
+	config  SQLConfig
+	batcher *batcher
+}
+
+// NewSQL returns a sink writing to db.
+func NewSQL(db *sql.DB, config SQLConfig) (*SQL, error) {
+	switch config.Dialect {
+	case Postgres, MySQL:
+	default:
+		return nil, fmt.Errorf("unsupported dialect %q", config.Dialect)
+	}
+	if config.Table == "" {
+		config.Table = DefaultTable
+	}
+	if !tableName.MatchString(config.Table) {
+		return nil, fmt.Errorf("invalid table name %q", config.Table)
+	}
+	s := &SQL{db: db, config: config}
+	s.batcher = newBatcher(config.BatchSize, config.FlushInterval, config.OnError, s.insert)
+	return s, nil
+}
+
+// Schema returns a CREATE TABLE statement for the sink's table.
+func (s *SQL) Schema() string {
+	timestamp, float := "TIMESTAMPTZ", "DOUBLE PRECISION"
+	if s.config.Dialect == MySQL {
+		timestamp, float = "DATETIME(6)", "DOUBLE"
+	}
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	request_id VARCHAR(32) NOT NULL,
+	model_name VARCHAR(255) NOT NULL,
+	created_at %s NOT NULL,
+	latency_ms %s NOT NULL,
+	input TEXT NOT NULL,
+	output TEXT,
+	error TEXT
+)`, s.config.Table, timestamp, float)
+}
+
+// Record implements jams.PredictionSink.
+func (s *SQL) Record(r jams.PredictionRecord) {
+	s.batcher.add(r)
+}
+
+// Flush writes the buffered records.
+func (s *SQL) Flush(ctx context.Context) error {
+	return s.batcher.flushAll(ctx)
+}
+
+// Close writes the buffered records and stops the sink. It does not close db.
+func (s *SQL) Close() error {
+	return s.batcher.close(context.Background())
+}
+
+func (s *SQL) insert(ctx context.Context, records []jams.PredictionRecord) error {
+	var query strings.Builder
+	fmt.Fprintf(&query, "INSERT INTO %s (%s) VALUES ", s.config.Table, strings.Join(sqlColumns, ", "))
+	args := make([]any, 0, len(records)*len(sqlColumns))
+	for i, r := range records {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for j := range sqlColumns {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			if s.config.Dialect == Postgres {
+				fmt.Fprintf(&query, "$%d", len(args)+j+1)
+			} else {
+				query.WriteString("?")
+			}
+		}
+		query.WriteString(")")
+
+		row, err := sqlRow(r)
+		if err != nil {
+			return err
+		}
+		args = append(args, row...)
+	}
+
+	if _, err := s.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("failed to insert %d predictions: %w", len(records), err)
+	}
+	return nil
+}
+
+func sqlRow(r jams.PredictionRecord) ([]any, error) {
+	input, err := r.Input.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode input: %w", err)
+	}
+	var output, errMsg sql.NullString
+	if r.Prediction != nil {
+		data, err := json.Marshal(r.Prediction.Outputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode prediction: %w", err)
+		}
+		output = sql.NullString{String: string(data), Valid: true}
+	}
+	if r.Err != nil {
+		errMsg = sql.NullString{String: r.Err.Error(), Valid: true}
+	}
+	latency := float64(r.Latency) / float64(time.Millisecond)
+	return []any{r.RequestID, r.Model, r.Time.UTC(), latency, string(input), output, errMsg}, nil
+}
@@ -0,0 +1,141 @@
+package jams_client
+
+import (
+	"context"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Stage is a stage of a Predict call, see WithStageTimings.
+type Stage string
+
+const (
+	// StagePrepare builds the input sent: preprocessing, column checks and
+	// validation.
+	StagePrepare Stage = "prepare"
+	// StageMarshal encodes the input, and the request body over HTTP.
+	StageMarshal Stage = "marshal"
+	// StageQueue waits for quotas and a slot under WithMaxConcurrency.
+	StageQueue Stage = "queue"
+	// StageNetwork gets a connection and sends the request, including the
+	// protobuf encoding over gRPC.
+	StageNetwork Stage = "network"
+	// StageServer waits from the request sent to the first byte of the
+	// response: the time spent by the server plus a network round trip.
+	StageServer Stage = "server"
+	// StageReceive reads and decodes the response.
+	StageReceive Stage = "receive"
+	// StageParse parses the output of the model into a types.Prediction.
+	StageParse Stage = "parse"
+	// StagePostprocess applies the postprocessor and uncertainty of the
+	// model.
+	StagePostprocess Stage = "postprocess"
+)
+
+// stages are the stages of a Predict call, in order.
+var stages = []Stage{
+	StagePrepare, StageMarshal, StageQueue, StageNetwork, StageServer, StageReceive, StageParse, StagePostprocess,
+}
+
+// StageStats summarises the time spent in a stage by the Predict calls of a
+// model.
+type StageStats struct {
+	Stage   Stage        `json:"stage"`
+	Latency LatencyStats `json:"latency"`
+}
+
+// WithStageTimings breaks the latency of successful Predict calls down into
+// stages, reported per model in the Stages of its CallStats, to find out
+// where the milliseconds go. The transport stages of calls retried by
+// WithRetry add up the time of every attempt, and calls served from the
+// prediction cache have none.
+func WithStageTimings() Option {
+	return func(o *options) {
+		o.stageTimings = true
+	}
+}
+
+// stageTimer gathers the time spent in the stages of a single Predict call.
+// Transports find it in the call context.
+type stageTimer struct {
+	mu        sync.Mutex
+	durations map[Stage]time.Duration
+	// current is the transport stage in progress, if any, and mark when it
+	// started.
+	current Stage
+	mark    time.Time
+}
+
+type stageTimerKey struct{}
+
+func stageTimerFrom(ctx context.Context) *stageTimer {
+	timer, _ := ctx.Value(stageTimerKey{}).(*stageTimer)
+	return timer
+}
+
+// withStageTimer returns a context timing the stages of a Predict call, or
+// ctx itself when stage timings are off.
+func (c *Client) withStageTimer(ctx context.Context) (context.Context, *stageTimer) {
+	if !c.opts.stageTimings {
+		return ctx, nil
+	}
+	timer := &stageTimer{durations: make(map[Stage]time.Duration, len(stages))}
+	return context.WithValue(ctx, stageTimerKey{}, timer), timer
+}
+
+// since adds the time elapsed since start to stage. It is a no-op on a nil
+// timer.
+func (t *stageTimer) since(stage Stage, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.add(stage, time.Since(start))
+}
+
+func (t *stageTimer) add(stage Stage, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.durations[stage] += d
+}
+
+// enter ends the transport stage in progress, if any, at now and starts
+// next, or none when next is empty.
+func (t *stageTimer) enter(next Stage, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current != "" {
+		t.durations[t.current] += now.Sub(t.mark)
+	}
+	t.current, t.mark = next, now
+}
+
+func (c *Client) reportStages(model string, timer *stageTimer) {
+	if timer == nil {
+		return
+	}
+	timer.mu.Lock()
+	defer timer.mu.Unlock()
+	c.stats.recordStages(MethodPredict, model, timer.durations)
+}
+
+// clientTrace times the network, server and receive stages of an HTTP
+// request, until done is called.
+func (t *stageTimer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.enter(StageNetwork, time.Now())
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.enter(StageServer, time.Now())
+		},
+		GotFirstResponseByte: func() {
+			t.enter(StageReceive, time.Now())
+		},
+	}
+}
+
+// done ends the transport stage in progress.
+func (t *stageTimer) done() {
+	t.enter("", time.Now())
+}
@@ -0,0 +1,288 @@
+package jams_client
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histogram buckets. Calls
+// slower than the last bound land in an overflow bucket.
+var latencyBuckets = []time.Duration{
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Stats is a point in time snapshot of the calls made by a Client. It is meant
+// to be exposed on debug endpoints, e.g. by encoding it as JSON.
+type Stats struct {
+	// Since is when the client started collecting stats.
+	Since time.Time `json:"since"`
+	// Calls holds one entry per method and model, sorted by method then model.
+	Calls []CallStats `json:"calls"`
+}
+
+// CallStats holds the counters for a single method and model pair. Model is
+// empty for calls which do not target a model, e.g. HealthCheck.
+type CallStats struct {
+	Method   string `json:"method"`
+	Model    string `json:"model,omitempty"`
+	Requests uint64 `json:"requests"`
+	Failures uint64 `json:"failures"`
+	Retries  uint64 `json:"retries"`
+	// Cancelled counts the calls whose context was done before they
+	// returned, including calls cancelled while queued, which are not
+	// counted as requests.
+	Cancelled    uint64       `json:"cancelled"`
+	InFlight     int64        `json:"in_flight"`
+	SuccessRatio float64      `json:"success_ratio"`
+	Latency      LatencyStats `json:"latency"`
+	// Stages breaks the latency of successful Predict calls down into
+	// stages, in order, with WithStageTimings.
+	Stages []StageStats `json:"stages,omitempty"`
+}
+
+// LatencyStats summarises the latency histogram of a call. Percentiles are
+// estimated from the histogram buckets.
+type LatencyStats struct {
+	Mean    time.Duration   `json:"mean"`
+	P50     time.Duration   `json:"p50"`
+	P90     time.Duration   `json:"p90"`
+	P99     time.Duration   `json:"p99"`
+	Max     time.Duration   `json:"max"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// LatencyBucket is a single histogram bucket. The overflow bucket has a zero
+// UpperBound.
+type LatencyBucket struct {
+	UpperBound time.Duration `json:"upper_bound"`
+	Count      uint64        `json:"count"`
+}
+
+type callKey struct {
+	method string
+	model  string
+}
+
+type callCounters struct {
+	inFlight  int64
+	requests  uint64
+	failures  uint64
+	retries   uint64
+	cancelled uint64
+	total     time.Duration
+	max       time.Duration
+	buckets   []uint64
+	// stages hold the time spent in each stage by the calls, with requests
+	// counting those timed.
+	stages map[Stage]*callCounters
+}
+
+type statsRecorder struct {
+	mu    sync.Mutex
+	since time.Time
+	calls map[callKey]*callCounters
+	// window holds the Predict counters of each model since windowStart,
+	// when WithModelStatsReporter is used.
+	window      map[string]*callCounters
+	windowStart time.Time
+}
+
+func newStatsRecorder() *statsRecorder {
+	return &statsRecorder{
+		since: time.Now(),
+		calls: make(map[callKey]*callCounters),
+	}
+}
+
+// counters returns the counters for the given call, creating them if needed.
+// The caller must hold r.mu.
+func (r *statsRecorder) counters(method, model string) *callCounters {
+	key := callKey{method: method, model: model}
+	counters, ok := r.calls[key]
+	if !ok {
+		counters = &callCounters{buckets: make([]uint64, len(latencyBuckets)+1)}
+		r.calls[key] = counters
+	}
+	return counters
+}
+
+// begin marks a call as in flight. Every begin must be followed by record.
+func (r *statsRecorder) begin(method, model string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counters(method, model).inFlight++
+}
+
+// retry counts a retried attempt of an in flight call.
+func (r *statsRecorder) retry(method, model string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counters(method, model).retries++
+}
+
+// cancel counts a cancelled call.
+func (r *statsRecorder) cancel(method, model string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counters(method, model).cancelled++
+}
+
+func (r *statsRecorder) record(method, model string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counters := r.counters(method, model)
+	counters.inFlight--
+	counters.requests++
+	if err != nil {
+		counters.failures++
+	}
+	counters.observe(latency)
+
+	if r.window != nil && method == MethodPredict && model != "" {
+		window, ok := r.window[model]
+		if !ok {
+			window = &callCounters{buckets: make([]uint64, len(latencyBuckets)+1)}
+			r.window[model] = window
+		}
+		window.requests++
+		if err != nil {
+			window.failures++
+		}
+		window.observe(latency)
+	}
+}
+
+// recordStages records the stage timings of a call recorded by record.
+func (r *statsRecorder) recordStages(method, model string, durations map[Stage]time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counters := r.counters(method, model)
+	if counters.stages == nil {
+		counters.stages = make(map[Stage]*callCounters, len(stages))
+	}
+	for _, stage := range stages {
+		stageCounters, ok := counters.stages[stage]
+		if !ok {
+			stageCounters = &callCounters{buckets: make([]uint64, len(latencyBuckets)+1)}
+			counters.stages[stage] = stageCounters
+		}
+		stageCounters.requests++
+		stageCounters.observe(durations[stage])
+	}
+}
+
+// observe adds latency to the histogram.
+func (c *callCounters) observe(latency time.Duration) {
+	c.total += latency
+	if latency > c.max {
+		c.max = latency
+	}
+	c.buckets[sort.Search(len(latencyBuckets), func(i int) bool {
+		return latency <= latencyBuckets[i]
+	})]++
+}
+
+func (r *statsRecorder) snapshot() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := Stats{Since: r.since, Calls: make([]CallStats, 0, len(r.calls))}
+	for key, counters := range r.calls {
+		call := CallStats{
+			Method:    key.method,
+			Model:     key.model,
+			Requests:  counters.requests,
+			Failures:  counters.failures,
+			Retries:   counters.retries,
+			Cancelled: counters.cancelled,
+			InFlight:  counters.inFlight,
+		}
+		if counters.requests > 0 {
+			call.SuccessRatio = float64(counters.requests-counters.failures) / float64(counters.requests)
+			call.Latency = counters.latency()
+		}
+		for _, stage := range stages {
+			if stageCounters, ok := counters.stages[stage]; ok {
+				call.Stages = append(call.Stages, StageStats{Stage: stage, Latency: stageCounters.latency()})
+			}
+		}
+		stats.Calls = append(stats.Calls, call)
+	}
+	sort.Slice(stats.Calls, func(i, j int) bool {
+		if stats.Calls[i].Method != stats.Calls[j].Method {
+			return stats.Calls[i].Method < stats.Calls[j].Method
+		}
+		return stats.Calls[i].Model < stats.Calls[j].Model
+	})
+	return stats
+}
+
+func (c *callCounters) latency() LatencyStats {
+	buckets := make([]LatencyBucket, len(c.buckets))
+	for i, count := range c.buckets {
+		if i < len(latencyBuckets) {
+			buckets[i].UpperBound = latencyBuckets[i]
+		}
+		buckets[i].Count = count
+	}
+	return LatencyStats{
+		Mean:    c.total / time.Duration(c.requests),
+		P50:     c.percentile(0.50),
+		P90:     c.percentile(0.90),
+		P99:     c.percentile(0.99),
+		Max:     c.max,
+		Buckets: buckets,
+	}
+}
+
+// percentile estimates the q-th percentile by linear interpolation within the
+// bucket that contains it.
+func (c *callCounters) percentile(q float64) time.Duration {
+	rank := q * float64(c.requests)
+	var cumulative uint64
+	for i, count := range c.buckets {
+		if count == 0 || float64(cumulative+count) < rank {
+			cumulative += count
+			continue
+		}
+		if i == len(latencyBuckets) {
+			return c.max
+		}
+		var lower time.Duration
+		if i > 0 {
+			lower = latencyBuckets[i-1]
+		}
+		upper := latencyBuckets[i]
+		if upper > c.max {
+			upper = c.max
+		}
+		fraction := (rank - float64(cumulative)) / float64(count)
+		return lower + time.Duration(fraction*float64(upper-lower))
+	}
+	return c.max
+}
+
+// Stats returns a snapshot of the per method and per model counters and
+// latency histograms collected by the client.
+func (c *Client) Stats() Stats {
+	return c.stats.snapshot()
+}
@@ -0,0 +1,262 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// RecordReader reads the records of PredictStream, e.g. from a file. Read
+// returns io.EOF after the last record.
+type RecordReader interface {
+	Read() (map[string]any, error)
+}
+
+// Checkpoint stores the progress of PredictStream, so that an interrupted
+// stream resumes where it left off. See the checkpoint package for a
+// file-based implementation.
+type Checkpoint interface {
+	// Load returns the number of records of the stream already handled, zero
+	// for a new stream.
+	Load() (int, error)
+	// Save records that the first offset records of the stream were handled.
+	Save(offset int) error
+}
+
+// StreamOptions configures PredictStream.
+type StreamOptions struct {
+	// ChunkSize is the number of records sent per call, DefaultBatchChunkSize
+	// when zero.
+	ChunkSize int
+	// Concurrency is the number of chunks predicted at once,
+	// DefaultBatchConcurrency when zero.
+	Concurrency int
+	// Unordered hands chunks to the handler as soon as they are predicted
+	// rather than in the order of the records, so that a slow chunk does not
+	// hold back the chunks after it and the slots they take, for callers
+	// keying results by the Start and End of chunks and preferring
+	// throughput over ordering.
+	Unordered bool
+	// DeadLetters, if not nil, receives the input of every failed chunk, or
+	// its raw records when they cannot be made into an input, e.g. when one
+	// misses a feature, and the stream carries on with the next chunks.
+	// Without it, the stream stops at the first failed chunk.
+	DeadLetters DeadLetterQueue
+	// DeadLettersUnredacted puts the inputs into DeadLetters as given, see
+	// BatchOptions.DeadLettersUnredacted.
+	DeadLettersUnredacted bool
+	// Checkpoint, if not nil, makes the stream skip the records handled by a
+	// previous run and saves the offset of the stream after every chunk
+	// handled or put into DeadLetters. Only the chunk being handled when the
+	// process stopped is handled again, or when Unordered, the chunks handled
+	// after the first chunk not handled yet. The Start and End of chunks
+	// count the records skipped.
+	Checkpoint Checkpoint
+}
+
+// PredictStream makes predictions for the records read from r, in chunks
+// predicted concurrently, and passes each chunk with its records to handle,
+// in the order of the records unless StreamOptions.Unordered is set.
+// A chunk is only read once a slot is free, a slot being held until its
+// chunk is handled, so that at most Concurrency chunks are held in memory and
+// reading slows down to the pace of the server, the network or handle,
+// however many records r has. Chunks carry the indices of their records in
+// the stream either way. Chunks have PriorityBatch unless ctx has a priority
+// set with WithPriority.
+//
+// PredictStream returns the first error of r or handle, or the *ChunkError of
+// the first failed chunk unless StreamOptions.DeadLetters is set.
+func (c *Client) PredictStream(ctx context.Context, modelName string, r RecordReader, opts StreamOptions, handle func(chunk BatchChunk, records []map[string]any) error) error {
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = DefaultBatchChunkSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	if _, ok := ctx.Value(priorityKey{}).(Priority); !ok {
+		ctx = WithPriority(ctx, PriorityBatch)
+	}
+	var offset int
+	if opts.Checkpoint != nil {
+		var err error
+		if offset, err = opts.Checkpoint.Load(); err != nil {
+			return fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+		if err := skipRecords(r, offset); err != nil {
+			return fmt.Errorf("failed to skip the %d records of the checkpoint: %w", offset, err)
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		chunk   BatchChunk
+		records []map[string]any
+		input   *types.Input
+		err     error
+	}
+	results := make(chan result, concurrency)
+	var (
+		// running counts the chunks being predicted, inFlight those read and
+		// not handled yet.
+		running  int
+		inFlight int
+		// pending holds the chunks predicted before the chunks preceding them,
+		// when ordered.
+		pending = make(map[int]result)
+		// next is the index of the first chunk not handled, and handled the
+		// End of the chunks handled after it.
+		next    int
+		handled = make(map[int]int)
+	)
+	// handleResult handles a chunk and saves the checkpoint once the chunks
+	// before it are handled too.
+	handleResult := func(res result) error {
+		if err := c.handleChunk(ctx, modelName, opts, res.chunk, res.records, res.input, res.err, handle); err != nil {
+			return err
+		}
+		inFlight--
+		handled[res.chunk.Index] = res.chunk.End
+		end := -1
+		for e, ok := handled[next]; ok; e, ok = handled[next] {
+			delete(handled, next)
+			next++
+			end = e
+		}
+		if end < 0 || opts.Checkpoint == nil {
+			return nil
+		}
+		if err := opts.Checkpoint.Save(end); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+		return nil
+	}
+	// receive waits for a chunk to be predicted and handles it, or when
+	// ordered, the chunks it completes the sequence of.
+	receive := func() error {
+		res := <-results
+		running--
+		if opts.Unordered {
+			return handleResult(res)
+		}
+		pending[res.chunk.Index] = res
+		for res, ok := pending[next]; ok; res, ok = pending[next] {
+			delete(pending, next)
+			if err := handleResult(res); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// stop waits for the chunks being predicted, which are cancelled, so that
+	// no goroutine outlives the call.
+	stop := func(err error) error {
+		cancel()
+		for ; running > 0; running-- {
+			<-results
+		}
+		return err
+	}
+
+	// chunks start after the records skipped, while their indices start at
+	// zero with every run
+	for index, start := 0, offset; ; index++ {
+		for inFlight >= concurrency {
+			if err := receive(); err != nil {
+				return stop(err)
+			}
+		}
+		records, err := readChunk(r, size)
+		if err != nil && err != io.EOF {
+			return stop(fmt.Errorf("failed to read record %d: %w", start+len(records), err))
+		}
+		if len(records) > 0 {
+			running++
+			inFlight++
+			chunk := BatchChunk{Index: index, Start: start, End: start + len(records)}
+			go func() {
+				res := result{chunk: chunk, records: records}
+				res.input, res.err = types.NewInputFromRecords(records)
+				if res.err == nil {
+					res.chunk.Prediction, res.err = c.Predict(ctx, modelName, res.input)
+				}
+				results <- res
+			}()
+			start += len(records)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	for inFlight > 0 {
+		if err := receive(); err != nil {
+			return stop(err)
+		}
+	}
+	return nil
+}
+
+// handleChunk passes a predicted chunk to handle, or puts a failed chunk into
+// the dead letter queue of opts, if any.
+func (c *Client) handleChunk(ctx context.Context, modelName string, opts StreamOptions, chunk BatchChunk, records []map[string]any, input *types.Input, err error, handle func(BatchChunk, []map[string]any) error) error {
+	if err == nil {
+		return handle(chunk, records)
+	}
+	chunkErr := &ChunkError{Index: chunk.Index, Start: chunk.Start, End: chunk.End, Err: err}
+	if opts.DeadLetters == nil || ctx.Err() != nil {
+		return chunkErr
+	}
+	letter := DeadLetter{
+		Source:  DeadLetterSourceBatch,
+		Model:   modelName,
+		Time:    time.Now(),
+		Records: &RecordRange{Start: chunk.Start, End: chunk.End},
+		Err:     err,
+	}
+	// records which could not be made into an input, e.g. missing a
+	// feature, are set aside as they are
+	if input != nil {
+		letter.Input = c.deadLetterInput(input, opts.DeadLettersUnredacted)
+	} else {
+		letter.RawRecords = c.deadLetterRecords(records, opts.DeadLettersUnredacted)
+	}
+	putErr := opts.DeadLetters.Put(ctx, letter)
+	if putErr != nil {
+		chunkErr.Err = errors.Join(err, fmt.Errorf("failed to store dead letter: %w", putErr))
+		return chunkErr
+	}
+	return nil
+}
+
+// skipRecords reads and drops n records from r.
+func skipRecords(r RecordReader, n int) error {
+	for i := 0; i < n; i++ {
+		if _, err := r.Read(); err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("the stream has %d records only", i)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// readChunk reads up to size records from r. It returns io.EOF along with
+// the last records.
+func readChunk(r RecordReader, size int) ([]map[string]any, error) {
+	records := make([]map[string]any, 0, size)
+	for len(records) < size {
+		record, err := r.Read()
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,307 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/checkpoint"
+)
+
+// sliceReader reads the records of a slice.
+type sliceReader struct {
+	records []map[string]any
+	read    int
+}
+
+func (r *sliceReader) Read() (map[string]any, error) {
+	if r.read == len(r.records) {
+		return nil, io.EOF
+	}
+	r.read++
+	return r.records[r.read-1], nil
+}
+
+// indexRecords returns n records whose field "i" is their index.
+func indexRecords(n int) *sliceReader {
+	records := make([]map[string]any, n)
+	for i := range records {
+		records[i] = map[string]any{"i": float64(i)}
+	}
+	return &sliceReader{records: records}
+}
+
+func TestPredictStreamOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		records     int
+		chunkSize   int
+		concurrency int
+		unordered   bool
+	}{
+		{name: "ordered sequential", records: 30, chunkSize: 10, concurrency: 1},
+		{name: "ordered concurrent", records: 95, chunkSize: 10, concurrency: 4},
+		{name: "unordered concurrent", records: 95, chunkSize: 10, concurrency: 4, unordered: true},
+		{name: "unordered all at once", records: 64, chunkSize: 4, concurrency: 16, unordered: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(&fakeTransport{predictFunc: reverseDelay(tt.records)})
+			opts := StreamOptions{ChunkSize: tt.chunkSize, Concurrency: tt.concurrency, Unordered: tt.unordered}
+			var indices []int
+			err := client.PredictStream(context.Background(), "m", indexRecords(tt.records), opts, func(chunk BatchChunk, records []map[string]any) error {
+				checkChunk(t, chunk)
+				if chunk.Start != chunk.Index*tt.chunkSize || len(records) != chunk.End-chunk.Start {
+					t.Fatalf("chunk %d covers records %d to %d with %d records", chunk.Index, chunk.Start, chunk.End, len(records))
+				}
+				for k, record := range records {
+					if record["i"] != float64(chunk.Start+k) {
+						t.Fatalf("chunk %d: record %d is %v", chunk.Index, chunk.Start+k, record)
+					}
+				}
+				indices = append(indices, chunk.Index)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := (tt.records + tt.chunkSize - 1) / tt.chunkSize
+			if len(indices) != want {
+				t.Fatalf("handled %d chunks, want %d", len(indices), want)
+			}
+			sorted := slices.IsSorted(indices)
+			if !tt.unordered && !sorted {
+				t.Fatalf("chunks handled out of order: %v", indices)
+			}
+			if tt.unordered {
+				if tt.concurrency > 1 && sorted {
+					t.Fatalf("unordered chunks handled in order despite completing out of order: %v", indices)
+				}
+				slices.Sort(indices)
+				for i, index := range indices {
+					if index != i {
+						t.Fatalf("chunk indices %v are not those of the input", indices)
+					}
+				}
+			}
+		})
+	}
+}
+
+// aheadReader reads records, checking that no chunk is read before a slot is
+// free, i.e. that at most concurrency chunks are read and not handled yet.
+type aheadReader struct {
+	*sliceReader
+	t           *testing.T
+	size        int
+	concurrency int
+	// handled is the number of chunks handled, maxAhead the largest number
+	// of chunks read and not handled.
+	handled  int
+	maxAhead int
+}
+
+func (r *aheadReader) Read() (map[string]any, error) {
+	ahead := r.read/r.size - r.handled + 1
+	if ahead > r.concurrency {
+		r.t.Fatalf("reading record %d with %d chunks handled, %d chunks ahead", r.read, r.handled, ahead)
+	}
+	r.maxAhead = max(r.maxAhead, ahead)
+	return r.sliceReader.Read()
+}
+
+func TestPredictStreamReadAhead(t *testing.T) {
+	for _, unordered := range []bool{false, true} {
+		t.Run(fmt.Sprintf("unordered=%v", unordered), func(t *testing.T) {
+			const records, size, concurrency = 200, 5, 3
+			client := newTestClient(&fakeTransport{predictFunc: reverseDelay(records)})
+			r := &aheadReader{sliceReader: indexRecords(records), t: t, size: size, concurrency: concurrency}
+			opts := StreamOptions{ChunkSize: size, Concurrency: concurrency, Unordered: unordered}
+			err := client.PredictStream(context.Background(), "m", r, opts, func(BatchChunk, []map[string]any) error {
+				r.handled++
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.handled != records/size {
+				t.Fatalf("handled %d chunks, want %d", r.handled, records/size)
+			}
+			if r.maxAhead != concurrency {
+				t.Fatalf("read at most %d chunks ahead, want %d", r.maxAhead, concurrency)
+			}
+		})
+	}
+}
+
+func TestPredictStreamChunkError(t *testing.T) {
+	errInvalid := errors.New("invalid input")
+	errStop := errors.New("stop")
+	tests := []struct {
+		name        string
+		deadLetters bool
+		// failHandle makes the handler fail on the chunk starting at 10
+		// rather than the prediction of the chunk starting at 20.
+		failHandle bool
+		wantErr    error
+		wantChunks []int
+	}{
+		{name: "stops", wantErr: &ChunkError{Index: 2, Start: 20, End: 30, Err: errInvalid}, wantChunks: []int{0, 1}},
+		{name: "dead letters", deadLetters: true, wantChunks: []int{0, 1, 3, 4, 5, 6, 7, 8, 9}},
+		{name: "handler error", failHandle: true, wantErr: errStop, wantChunks: []int{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(&fakeTransport{predictFunc: func(ctx context.Context, modelName, input string) (string, error) {
+				if !tt.failHandle && firstIndex(input) == 20 {
+					return "", errInvalid
+				}
+				return reverseDelay(100)(ctx, modelName, input)
+			}})
+			opts := StreamOptions{ChunkSize: 10, Concurrency: 4}
+			q := &memoryQueue{}
+			if tt.deadLetters {
+				opts.DeadLetters = q
+			}
+			var chunks []int
+			err := client.PredictStream(context.Background(), "m", indexRecords(100), opts, func(chunk BatchChunk, _ []map[string]any) error {
+				chunks = append(chunks, chunk.Index)
+				if tt.failHandle && chunk.Start == 10 {
+					return errStop
+				}
+				return nil
+			})
+
+			var chunkErr *ChunkError
+			switch want, ok := tt.wantErr.(*ChunkError); {
+			case ok:
+				if !errors.As(err, &chunkErr) || *chunkErr != *want {
+					t.Fatalf("got error %v, want %v", err, want)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(chunks, tt.wantChunks) {
+				t.Fatalf("handled chunks %v, want %v", chunks, tt.wantChunks)
+			}
+			if !tt.deadLetters {
+				return
+			}
+			if len(q.letters) != 1 {
+				t.Fatalf("got %d dead letters, want 1", len(q.letters))
+			}
+			if l := q.letters[0]; *l.Records != (RecordRange{Start: 20, End: 30}) || !errors.Is(l.Err, errInvalid) || l.Input.Len() != 10 {
+				t.Fatalf("got dead letter %+v", l)
+			}
+		})
+	}
+}
+
+func TestPredictStreamResume(t *testing.T) {
+	errStop := errors.New("stop")
+	for _, unordered := range []bool{false, true} {
+		t.Run(fmt.Sprintf("unordered=%v", unordered), func(t *testing.T) {
+			const records = 95
+			client := newTestClient(&fakeTransport{predictFunc: reverseDelay(records)})
+			cp := checkpoint.File{Path: filepath.Join(t.TempDir(), "stream.ckpt")}
+			opts := StreamOptions{ChunkSize: 10, Concurrency: 4, Unordered: unordered, Checkpoint: cp}
+
+			// the first run is interrupted by the handler failing on the
+			// chunk of records 40 to 50
+			first := make(map[int]bool)
+			err := client.PredictStream(context.Background(), "m", indexRecords(records), opts, func(chunk BatchChunk, _ []map[string]any) error {
+				if chunk.Start == 40 {
+					return errStop
+				}
+				for i := chunk.Start; i < chunk.End; i++ {
+					first[i] = true
+				}
+				return nil
+			})
+			if !errors.Is(err, errStop) {
+				t.Fatalf("got error %v, want %v", err, errStop)
+			}
+			offset, err := cp.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !unordered && offset != 40 {
+				t.Fatalf("checkpoint at record %d, want 40", offset)
+			}
+			for i := 0; i < offset; i++ {
+				if !first[i] {
+					t.Fatalf("checkpoint at record %d, record %d not handled", offset, i)
+				}
+			}
+
+			var second []int
+			err = client.PredictStream(context.Background(), "m", indexRecords(records), opts, func(chunk BatchChunk, records []map[string]any) error {
+				checkChunk(t, chunk)
+				for k, record := range records {
+					if record["i"] != float64(chunk.Start+k) {
+						t.Fatalf("chunk %d: record %d is %v", chunk.Index, chunk.Start+k, record)
+					}
+					second = append(second, chunk.Start+k)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(second)
+			want := make([]int, 0, records-offset)
+			for i := offset; i < records; i++ {
+				want = append(want, i)
+			}
+			if !slices.Equal(second, want) {
+				t.Fatalf("resumed at record %d, handled records %v", offset, second)
+			}
+			if offset, err := cp.Load(); err != nil || offset != records {
+				t.Fatalf("checkpoint at record %d (%v) after the stream, want %d", offset, err, records)
+			}
+		})
+	}
+}
+
+func TestPredictStreamMalformedRecord(t *testing.T) {
+	client := newTestClient(&fakeTransport{}, WithRedactedColumns("email"))
+	r := indexRecords(50)
+	for i := range r.records {
+		r.records[i]["email"] = "a@example.com"
+	}
+	// record 23 misses a feature
+	r.records[23] = map[string]any{"i": 23.0}
+	q := &memoryQueue{}
+	var chunks []int
+	err := client.PredictStream(context.Background(), "m", r, StreamOptions{ChunkSize: 10, Concurrency: 2, DeadLetters: q}, func(chunk BatchChunk, _ []map[string]any) error {
+		checkChunk(t, chunk)
+		chunks = append(chunks, chunk.Index)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 3, 4}; !slices.Equal(chunks, want) {
+		t.Fatalf("handled chunks %v, want %v", chunks, want)
+	}
+	if len(q.letters) != 1 {
+		t.Fatalf("got %d dead letters, want 1", len(q.letters))
+	}
+	l := q.letters[0]
+	if l.Input != nil || len(l.RawRecords) != 10 || *l.Records != (RecordRange{Start: 20, End: 30}) || l.Err == nil {
+		t.Fatalf("got dead letter %+v", l)
+	}
+	if email := l.RawRecords[0]["email"]; email != DefaultRedactionReplacement {
+		t.Fatalf("dead letter has email %v, want it redacted", email)
+	}
+	if _, ok := l.RawRecords[3]["email"]; ok {
+		t.Fatalf("dead letter has record %v, want it as read", l.RawRecords[3])
+	}
+	if r.records[0]["email"] != "a@example.com" {
+		t.Fatal("redaction modified the records")
+	}
+}
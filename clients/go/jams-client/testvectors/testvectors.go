@@ -0,0 +1,79 @@
+// Package testvectors ships canonical inputs and the outputs the sample models
+// of the J.A.M.S model store predict for them, as contract suites, one per
+// framework. Run them against a server loaded with the sample models to check
+// a deployment end to end, or feed their inputs through your own
+// serialisation code to verify it against known good outputs:
+//
+//	suite, err := testvectors.Suite("lightgbm")
+//	for _, result := range suite.Run(ctx, client) {
+//		fmt.Println(result.Test, result.Passed())
+//	}
+//
+// Expected outputs are derived from the model files themselves, so only the
+// frameworks whose sample models could be evaluated without their runtime
+// have vectors, see Frameworks.
+package testvectors
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/contract"
+)
+
+//go:embed testdata/*.yaml
+var files embed.FS
+
+// FS returns the vector files, testdata/<framework>.yaml, in the format of
+// the contract package, e.g. to read them with another YAML decoder.
+func FS() fs.FS {
+	return files
+}
+
+// Frameworks returns the frameworks with vectors, sorted.
+func Frameworks() []string {
+	entries, _ := files.ReadDir("testdata")
+	frameworks := make([]string, 0, len(entries))
+	for _, e := range entries {
+		frameworks = append(frameworks, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(frameworks)
+	return frameworks
+}
+
+// Suite returns the vectors of framework.
+func Suite(framework string) (*contract.Suite, error) {
+	f, err := files.Open(path.Join("testdata", framework+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("no vectors for framework %q, have %s", framework, strings.Join(Frameworks(), ", "))
+	}
+	defer f.Close()
+	suite, err := contract.Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s vectors: %w", framework, err)
+	}
+	return suite, nil
+}
+
+// All returns the vectors of every framework as a single suite. The
+// tolerances of each framework are kept by its tests.
+func All() (*contract.Suite, error) {
+	var all contract.Suite
+	for _, framework := range Frameworks() {
+		suite, err := Suite(framework)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range suite.Tests {
+			tolerance := suite.TestTolerance(t)
+			t.Tolerance, t.RelativeTolerance = &tolerance.Abs, &tolerance.Rel
+			t.ULPs, t.Float32 = &tolerance.ULPs, &tolerance.Float32
+			all.Tests = append(all.Tests, t)
+		}
+	}
+	return &all, nil
+}
@@ -0,0 +1,105 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrCallTimeout is the cause of calls exceeding the Overall timeout of
+// WithTimeouts, and is wrapped by their error.
+var ErrCallTimeout = errors.New("call timed out")
+
+// ErrAttemptTimeout is the cause of attempts exceeding the Attempt timeout of
+// WithTimeouts, and is wrapped by the error of calls whose last attempt timed
+// out.
+var ErrAttemptTimeout = errors.New("attempt timed out")
+
+// Timeouts bounds the phases of a call, see WithTimeouts. A zero field leaves
+// its phase bounded by the context of the call only.
+type Timeouts struct {
+	// Dial bounds the establishment of a connection, so that a slow connect
+	// fails, and is retried under WithRetry, without using up the attempt.
+	Dial time.Duration
+	// Attempt bounds each attempt of a call. An attempt timing out is
+	// retried under WithRetry, as long as the call has time left.
+	Attempt time.Duration
+	// Overall bounds the call from its first attempt, retries and backoff
+	// included.
+	Overall time.Duration
+}
+
+// WithTimeouts bounds dialing, each attempt and calls as a whole
+// separately, rather than under the single deadline of the context of the
+// call, which retries and slow connects would otherwise share. The deadline
+// of the context still applies, whichever expires first, and retries whose
+// backoff would end past the deadline of the call are not made: the call
+// fails with the error of its last attempt right away.
+//
+//	client, err := jams.NewHTTPClient(url,
+//		jams.WithRetry(3),
+//		jams.WithTimeouts(jams.Timeouts{Dial: time.Second, Attempt: 2 * time.Second, Overall: 5 * time.Second}),
+//	)
+//
+// Over HTTP, Dial has no effect with an *http.Client whose Transport is not
+// an *http.Transport; over gRPC, it sets the minimum connect timeout of the
+// channels.
+func WithTimeouts(t Timeouts) Option {
+	return func(o *options) {
+		o.timeouts = t
+	}
+}
+
+// callContext returns the context of a call under the Overall timeout.
+func (c *Client) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.opts.timeouts.Overall <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, c.opts.timeouts.Overall, ErrCallTimeout)
+}
+
+// attemptContext returns the context of an attempt of a call made with ctx
+// under the Attempt timeout.
+func (c *Client) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.opts.timeouts.Attempt <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, c.opts.timeouts.Attempt, ErrAttemptTimeout)
+}
+
+// timeoutError wraps err, returned by an attempt made with attemptCtx, with
+// the timeout which interrupted it, if any.
+func timeoutError(attemptCtx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	// the cause of the call context, if it ended first, is inherited.
+	cause := context.Cause(attemptCtx)
+	if (cause == ErrCallTimeout || cause == ErrAttemptTimeout) && !errors.Is(err, cause) {
+		return fmt.Errorf("%w: %w", cause, err)
+	}
+	return err
+}
+
+// beyondDeadline reports whether waiting for delay would end past the
+// deadline of ctx.
+func beyondDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Now().Add(delay).After(deadline)
+}
+
+// withDialTimeout bounds the dials of transport by timeout.
+func withDialTimeout(transport *http.Transport, timeout time.Duration) {
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return dial(ctx, network, addr)
+	}
+}
@@ -0,0 +1,455 @@
+// Package traffic generates synthetic prediction traffic, for soak testing
+// servers and client settings with inputs resembling production ones. The
+// distribution of every feature is learnt from a sample of records, the
+// quantiles of numeric features and the frequencies of the values of the
+// others, and records are drawn from it independently feature by feature:
+//
+//	profile, err := traffic.Learn(records)
+//	generator, err := traffic.New(client, traffic.Config{
+//		Model:    "churn",
+//		Profile:  profile,
+//		QPS:      200,
+//		Duration: time.Hour,
+//	})
+//	report, err := generator.Run(ctx)
+//
+// The generator keeps to its rate however slow the server is: requests due
+// while MaxInFlight requests are in flight are dropped and counted in the
+// report rather than delayed. Latencies are in the stats of the client.
+package traffic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	jams "github.com/gagansingh894/jams-rs/clients/go/jams-client"
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+const (
+	// maxSamples is the number of values of a numeric feature kept by Learn
+	// to compute its quantiles; larger samples are subsampled.
+	maxSamples = 10000
+	// profileQuantiles is the number of intervals between the quantiles of
+	// a numeric feature in a Profile.
+	profileQuantiles   = 100
+	defaultMaxInFlight = 64
+)
+
+// Profile is the distribution of the features of records. It is encoded as
+// JSON to be reused without the sample it was learnt from.
+type Profile struct {
+	// Records is the number of records learnt from.
+	Records int     `json:"records"`
+	Fields  []Field `json:"fields"`
+}
+
+// Field is the distribution of a feature. Exactly one of Numeric and
+// Categorical is set, unless the feature was always missing.
+type Field struct {
+	Name string `json:"name"`
+	// Missing is the fraction of the records without a value.
+	Missing     float64      `json:"missing,omitempty"`
+	Numeric     *Numeric     `json:"numeric,omitempty"`
+	Categorical *Categorical `json:"categorical,omitempty"`
+}
+
+// Numeric is the distribution of a numeric feature, given by its quantiles at
+// evenly spaced probabilities from 0 to 1: values are drawn by interpolating
+// between them.
+type Numeric struct {
+	// Integer is set when every value was an integer; values drawn are
+	// rounded.
+	Integer   bool      `json:"integer,omitempty"`
+	Quantiles []float64 `json:"quantiles"`
+}
+
+// Categorical is the distribution of a string feature.
+type Categorical struct {
+	Values []string `json:"values"`
+	// Weights are the frequencies of the values.
+	Weights []float64 `json:"weights"`
+}
+
+// Learn reads the records of r until io.EOF and returns the distribution of
+// their features. Features must be numbers, strings or null.
+func Learn(r jams.RecordReader) (*Profile, error) {
+	type stats struct {
+		present int
+		integer bool
+		numbers []float64
+		seen    int
+		strings map[string]int
+	}
+	fields := make(map[string]*stats)
+	// subsampling is deterministic, so that a sample always gives the same
+	// profile
+	rng := rand.New(rand.NewSource(1))
+	records := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read record %d: %w", records, err)
+		}
+		for name, value := range record {
+			s, ok := fields[name]
+			if !ok {
+				s = &stats{integer: true, strings: make(map[string]int)}
+				fields[name] = s
+			}
+			if value == nil {
+				continue
+			}
+			s.present++
+			if str, ok := value.(string); ok {
+				s.strings[str]++
+				continue
+			}
+			v, integer, err := number(value)
+			if err != nil {
+				return nil, fmt.Errorf("record %d, feature %q: %w", records, name, err)
+			}
+			s.integer = s.integer && integer
+			// reservoir sampling
+			s.seen++
+			if len(s.numbers) < maxSamples {
+				s.numbers = append(s.numbers, v)
+			} else if i := rng.Intn(s.seen); i < maxSamples {
+				s.numbers[i] = v
+			}
+		}
+		records++
+	}
+	if records == 0 {
+		return nil, errors.New("no records to learn from")
+	}
+
+	profile := &Profile{Records: records}
+	for name, s := range fields {
+		if len(s.strings) > 0 && len(s.numbers) > 0 {
+			return nil, fmt.Errorf("feature %q mixes strings and numbers", name)
+		}
+		field := Field{Name: name, Missing: float64(records-s.present) / float64(records)}
+		switch {
+		case len(s.numbers) > 0:
+			field.Numeric = &Numeric{Integer: s.integer, Quantiles: quantiles(s.numbers)}
+		case len(s.strings) > 0:
+			field.Categorical = categorical(s.strings)
+		}
+		profile.Fields = append(profile.Fields, field)
+	}
+	sort.Slice(profile.Fields, func(i, j int) bool { return profile.Fields[i].Name < profile.Fields[j].Name })
+	return profile, nil
+}
+
+// number converts a decoded JSON or Go number to float64, reporting whether
+// it is an integer.
+func number(value any) (float64, bool, error) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false, err
+		}
+		_, err = v.Int64()
+		return f, err == nil, nil
+	case float64:
+		return v, v == math.Trunc(v), nil
+	case float32:
+		return float64(v), float64(v) == math.Trunc(float64(v)), nil
+	case int:
+		return float64(v), true, nil
+	case int32:
+		return float64(v), true, nil
+	case int64:
+		return float64(v), true, nil
+	default:
+		return 0, false, fmt.Errorf("unsupported value %v of type %T", value, value)
+	}
+}
+
+func quantiles(values []float64) []float64 {
+	sort.Float64s(values)
+	q := make([]float64, profileQuantiles+1)
+	for i := range q {
+		pos := float64(i) / profileQuantiles * float64(len(values)-1)
+		lower := int(pos)
+		upper := min(lower+1, len(values)-1)
+		q[i] = values[lower] + (values[upper]-values[lower])*(pos-float64(lower))
+	}
+	return q
+}
+
+func categorical(counts map[string]int) *Categorical {
+	c := &Categorical{Values: make([]string, 0, len(counts))}
+	for v := range counts {
+		c.Values = append(c.Values, v)
+	}
+	// most frequent first, for readable profiles
+	sort.Slice(c.Values, func(i, j int) bool {
+		if counts[c.Values[i]] != counts[c.Values[j]] {
+			return counts[c.Values[i]] > counts[c.Values[j]]
+		}
+		return c.Values[i] < c.Values[j]
+	})
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	c.Weights = make([]float64, len(c.Values))
+	for i, v := range c.Values {
+		c.Weights[i] = float64(counts[v]) / float64(total)
+	}
+	return c
+}
+
+// Validate checks that the profile can generate records.
+func (p *Profile) Validate() error {
+	if len(p.Fields) == 0 {
+		return errors.New("profile has no fields")
+	}
+	for _, f := range p.Fields {
+		switch {
+		case f.Numeric != nil && f.Categorical != nil:
+			return fmt.Errorf("field %q is both numeric and categorical", f.Name)
+		case f.Numeric != nil && len(f.Numeric.Quantiles) == 0:
+			return fmt.Errorf("field %q has no quantiles", f.Name)
+		case f.Categorical != nil && (len(f.Categorical.Values) == 0 || len(f.Categorical.Values) != len(f.Categorical.Weights)):
+			return fmt.Errorf("field %q must have as many weights as values", f.Name)
+		case f.Numeric == nil && f.Categorical == nil && f.Missing < 1:
+			return fmt.Errorf("field %q has no distribution", f.Name)
+		}
+	}
+	return nil
+}
+
+// Record draws a record from the profile. Values are missing, i.e. nil, at
+// the rate of the sample only if missing is set: the server rejects missing
+// values, which must then be imputed, e.g. by a jams.Preprocessor. Fields
+// always missing are nil either way.
+func (p *Profile) Record(rng *rand.Rand, missing bool) map[string]any {
+	record := make(map[string]any, len(p.Fields))
+	for _, f := range p.Fields {
+		record[f.Name] = f.draw(rng, missing)
+	}
+	return record
+}
+
+// Input draws an input of rows records from the profile, see Record.
+func (p *Profile) Input(rng *rand.Rand, rows int, missing bool) (*types.Input, error) {
+	records := make([]map[string]any, rows)
+	for i := range records {
+		records[i] = p.Record(rng, missing)
+	}
+	return types.NewInputFromRecords(records)
+}
+
+func (f Field) draw(rng *rand.Rand, missing bool) any {
+	if missing && f.Missing > 0 && rng.Float64() < f.Missing {
+		return nil
+	}
+	switch {
+	case f.Numeric != nil:
+		q := f.Numeric.Quantiles
+		pos := rng.Float64() * float64(len(q)-1)
+		lower := int(pos)
+		upper := min(lower+1, len(q)-1)
+		v := q[lower] + (q[upper]-q[lower])*(pos-float64(lower))
+		if f.Numeric.Integer {
+			return int64(math.Round(v))
+		}
+		return v
+	case f.Categorical != nil:
+		u := rng.Float64()
+		for i, w := range f.Categorical.Weights {
+			if u < w {
+				return f.Categorical.Values[i]
+			}
+			u -= w
+		}
+		// rounding of the weights
+		return f.Categorical.Values[len(f.Categorical.Values)-1]
+	}
+	return nil
+}
+
+// Predictor makes predictions. It is implemented by *jams.Client.
+type Predictor interface {
+	Predict(ctx context.Context, modelName string, input *types.Input) (*types.Prediction, error)
+}
+
+// Config configures a Generator.
+type Config struct {
+	Model   string
+	Profile *Profile
+	// QPS is the number of requests sent per second.
+	QPS float64
+	// Rows is the number of records per request, 1 when zero.
+	Rows int
+	// Duration is how long Run sends requests for, until its context is done
+	// when zero.
+	Duration time.Duration
+	// MaxInFlight is the number of requests in flight above which requests
+	// are dropped, 64 when zero.
+	MaxInFlight int
+	// Missing makes requests hold missing values at the rate of the sample,
+	// for clients imputing them with a jams.Preprocessor.
+	Missing bool
+	// Seed seeds the records drawn, so that runs with the same seed send the
+	// same inputs.
+	Seed int64
+	// Interval, if not zero, is the period at which OnReport is called with
+	// the report so far.
+	Interval time.Duration
+	OnReport func(Report)
+}
+
+// Report counts the requests of a run.
+type Report struct {
+	Elapsed time.Duration `json:"elapsed"`
+	// Sent counts the requests sent, Failed those which failed and Dropped
+	// those not sent because MaxInFlight requests were in flight.
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	Dropped int `json:"dropped"`
+	// QPS is the rate at which requests were sent.
+	QPS float64 `json:"qps"`
+	// LastError is the error of the last failed request.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Generator sends requests at a constant rate.
+type Generator struct {
+	client Predictor
+	cfg    Config
+
+	mu     sync.Mutex
+	report Report
+}
+
+// New returns a generator of the traffic described by cfg.
+func New(client Predictor, cfg Config) (*Generator, error) {
+	if cfg.Model == "" {
+		return nil, errors.New("traffic: a model is required")
+	}
+	if cfg.Profile == nil {
+		return nil, errors.New("traffic: a profile is required")
+	}
+	if err := cfg.Profile.Validate(); err != nil {
+		return nil, fmt.Errorf("traffic: %w", err)
+	}
+	if !cfg.Missing {
+		for _, f := range cfg.Profile.Fields {
+			if f.Numeric == nil && f.Categorical == nil {
+				return nil, fmt.Errorf("traffic: field %q is always missing, impute it and set Missing", f.Name)
+			}
+		}
+	}
+	if cfg.QPS <= 0 {
+		return nil, errors.New("traffic: QPS must be greater than zero")
+	}
+	if cfg.Rows <= 0 {
+		cfg.Rows = 1
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = defaultMaxInFlight
+	}
+	return &Generator{client: client, cfg: cfg}, nil
+}
+
+// Run sends requests until the Duration of the config has elapsed or ctx is
+// done, then waits for the requests in flight and returns the report. The
+// requests in flight when the Duration elapses are let finish, those in
+// flight when ctx is done are cancelled. Run returns an error only if the
+// records drawn cannot be made into an input.
+func (g *Generator) Run(ctx context.Context) (Report, error) {
+	requestCtx := ctx
+	if g.cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.cfg.Duration)
+		defer cancel()
+	}
+	start := time.Now()
+	if g.cfg.Interval > 0 && g.cfg.OnReport != nil {
+		ticker := time.NewTicker(g.cfg.Interval)
+		defer ticker.Stop()
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					g.cfg.OnReport(g.snapshot(start))
+				}
+			}
+		}()
+	}
+
+	rng := rand.New(rand.NewSource(g.cfg.Seed))
+	limiter := rate.NewLimiter(rate.Limit(g.cfg.QPS), 1)
+	slots := make(chan struct{}, g.cfg.MaxInFlight)
+	var wg sync.WaitGroup
+	var err error
+	for {
+		if limiter.Wait(ctx) != nil {
+			break
+		}
+		input, inputErr := g.cfg.Profile.Input(rng, g.cfg.Rows, g.cfg.Missing)
+		if inputErr != nil {
+			err = fmt.Errorf("traffic: %w", inputErr)
+			break
+		}
+		select {
+		case slots <- struct{}{}:
+		default:
+			g.mu.Lock()
+			g.report.Dropped++
+			g.mu.Unlock()
+			continue
+		}
+		g.mu.Lock()
+		g.report.Sent++
+		g.mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := g.client.Predict(requestCtx, g.cfg.Model, input)
+			<-slots
+			if err == nil {
+				return
+			}
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			g.report.Failed++
+			g.report.LastError = err.Error()
+		}()
+	}
+	wg.Wait()
+	return g.snapshot(start), err
+}
+
+func (g *Generator) snapshot(start time.Time) Report {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.report
+	r.Elapsed = time.Since(start)
+	if r.Elapsed > 0 {
+		r.QPS = float64(r.Sent) / r.Elapsed.Seconds()
+	}
+	return r
+}
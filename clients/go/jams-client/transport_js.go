@@ -0,0 +1,8 @@
+package jams_client
+
+// fetchTransport is set on js/wasm, where net/http sends requests with the
+// Fetch API of the browser or runtime. Fetch dials and decompresses on its
+// own: net/http falls back to sockets, which browsers do not have, when a
+// transport has a custom dialer, so the options controlling connections are
+// ignored, and responses arrive decompressed whatever their Content-Encoding.
+const fetchTransport = true
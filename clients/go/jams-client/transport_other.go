@@ -0,0 +1,6 @@
+//go:build !js
+
+package jams_client
+
+// fetchTransport is set on js/wasm, see transport_js.go.
+const fetchTransport = false
@@ -0,0 +1,89 @@
+package jams_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// fakeTransport is a transport whose predictions are made by predictFunc, or
+// by echo when nil, for testing the client without a server.
+type fakeTransport struct {
+	predictFunc func(ctx context.Context, modelName, input string) (string, error)
+	// retryableErr is the error retryable reports as retryable, if any.
+	retryableErr error
+	calls        atomic.Int64
+}
+
+func (t *fakeTransport) healthCheck(context.Context) error { return nil }
+
+func (t *fakeTransport) predict(ctx context.Context, modelName, input string) (string, error) {
+	t.calls.Add(1)
+	if t.predictFunc == nil {
+		return echo(input)
+	}
+	return t.predictFunc(ctx, modelName, input)
+}
+
+func (t *fakeTransport) getModels(context.Context) ([]ModelMetadata, error) { return nil, nil }
+func (t *fakeTransport) addModel(context.Context, string) error             { return nil }
+func (t *fakeTransport) updateModel(context.Context, string) error          { return nil }
+func (t *fakeTransport) deleteModel(context.Context, string) error          { return nil }
+func (t *fakeTransport) name() string                                       { return "fake" }
+func (t *fakeTransport) features() []Feature                                { return nil }
+func (t *fakeTransport) close() error                                       { return nil }
+
+func (t *fakeTransport) retryable(err error) (bool, time.Duration) {
+	return t.retryableErr != nil && errors.Is(err, t.retryableErr), 0
+}
+
+// newTestClient returns a client of t with opts.
+func newTestClient(t *fakeTransport, opts ...Option) *Client {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return newClient(t, o)
+}
+
+// echo predicts the values of the column "i" of input, so that every
+// prediction identifies its record.
+func echo(input string) (string, error) {
+	indices, err := indexColumn(input)
+	if err != nil {
+		return "", err
+	}
+	rows := make([][]float64, len(indices))
+	for k, v := range indices {
+		rows[k] = []float64{v}
+	}
+	return predictionsOutput(rows), nil
+}
+
+// indexColumn returns the values of the column "i" of input, ignoring the
+// other columns.
+func indexColumn(input string) ([]float64, error) {
+	var columns struct {
+		I []float64 `json:"i"`
+	}
+	err := json.Unmarshal([]byte(input), &columns)
+	return columns.I, err
+}
+
+// predictionsOutput returns the output of a server predicting rows.
+func predictionsOutput(rows [][]float64) string {
+	data, _ := json.Marshal(map[string][][]float64{"predictions": rows})
+	return string(data)
+}
+
+// firstIndex returns the first value of the column "i" of input.
+func firstIndex(input string) int {
+	indices, err := indexColumn(input)
+	if err != nil || len(indices) == 0 {
+		panic(fmt.Sprintf("unexpected input %q", input))
+	}
+	return int(indices[0])
+}
@@ -1 +1,37 @@
 package jams_client
+
+import "time"
+
+// Method names used to label calls in stats and errors.
+const (
+	MethodHealthCheck = "HealthCheck"
+	MethodPredict     = "Predict"
+	MethodGetModels   = "GetModels"
+	MethodAddModel    = "AddModel"
+	MethodUpdateModel = "UpdateModel"
+	MethodDeleteModel = "DeleteModel"
+)
+
+// ModelMetadata describes a model loaded into the server.
+type ModelMetadata struct {
+	// Name of the model.
+	Name string `json:"name"`
+	// Framework used by the model, e.g. catboost, lightgbm, tensorflow or torch.
+	Framework string `json:"framework"`
+	// Path is the location from where the model was loaded into memory.
+	Path string `json:"path"`
+	// LastUpdated is the timestamp when the model was last updated.
+	LastUpdated string `json:"last_updated"`
+}
+
+// lastUpdatedLayout is the RFC 2822 layout used by the server for LastUpdated.
+const lastUpdatedLayout = "Mon, 2 Jan 2006 15:04:05 -0700"
+
+// UpdatedAt parses LastUpdated.
+func (m ModelMetadata) UpdatedAt() (time.Time, error) {
+	t, err := time.Parse(lastUpdatedLayout, m.LastUpdated)
+	if err != nil {
+		return time.Parse(time.RFC3339, m.LastUpdated)
+	}
+	return t, nil
+}
@@ -0,0 +1,207 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColumnType is the type a model expects for a column.
+type ColumnType string
+
+// Column types.
+const (
+	// Numeric columns are sent as floats.
+	Numeric ColumnType = "numeric"
+	// Integer columns are sent as integers. Floats with a fraction fail.
+	Integer ColumnType = "integer"
+	// Categorical columns are sent as strings, which CatBoost treats as
+	// categorical features. Numbers are formatted without a trailing
+	// fraction, e.g. 1.0 as "1".
+	Categorical ColumnType = "categorical"
+)
+
+// Schema converts inputs to the types a model expects, preventing e.g.
+// numeric codes of categorical features from being sent as numbers, or
+// columns mixing integers and floats, whose type the server infers from their
+// first value.
+//
+// Columns without a declared type follow the rules of the framework:
+//
+//   - catboost: strings are categorical, numbers numeric.
+//   - lightgbm, xgboost, tensorflow, torch and pytorch: every column is
+//     numeric, numeric strings are parsed and floats rounded to float32,
+//     the precision these frameworks compute with.
+//   - other frameworks: columns mixing integers and floats are numeric.
+//
+// Schema implements jams.Preprocessor.
+type Schema struct {
+	Framework string                `json:"framework" yaml:"framework"`
+	Columns   map[string]ColumnType `json:"columns,omitempty" yaml:"columns,omitempty"`
+}
+
+// Apply returns a copy of the input with its values converted.
+func (s Schema) Apply(in *Input) (*Input, error) {
+	framework := strings.ToLower(s.Framework)
+	out := in.Clone()
+	for _, name := range in.order {
+		typ, declared := s.Columns[name]
+		if !declared {
+			typ = inferType(framework, in.columns[name])
+		}
+		if typ == "" {
+			continue
+		}
+		float32Precision := framework != "catboost" && framework != ""
+		column := out.columns[name]
+		for i, v := range column {
+			converted, err := coerce(v, typ, float32Precision)
+			if err != nil {
+				return nil, fmt.Errorf("column %q, record %d: %w", name, i, err)
+			}
+			column[i] = converted
+		}
+	}
+	return out, nil
+}
+
+// inferType returns the type of an undeclared column, or "" to leave it
+// unchanged.
+func inferType(framework string, values []any) ColumnType {
+	switch framework {
+	case "lightgbm", "xgboost", "tensorflow", "torch", "pytorch":
+		return Numeric
+	}
+	var ints, floats, strs int
+	for _, v := range values {
+		switch v.(type) {
+		case int64:
+			ints++
+		case float64:
+			floats++
+		case string:
+			strs++
+		}
+	}
+	if strs == 0 && ints > 0 && floats > 0 {
+		return Numeric
+	}
+	return ""
+}
+
+func coerce(v any, typ ColumnType, float32Precision bool) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch typ {
+	case Categorical:
+		switch v := v.(type) {
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+		return v, nil
+	case Numeric:
+		var f float64
+		switch v := v.(type) {
+		case int64:
+			f = float64(v)
+		case float64:
+			f = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not numeric", v)
+			}
+			f = parsed
+		default:
+			return v, nil
+		}
+		if float32Precision {
+			// the shortest decimal of the float32, which the server parses
+			// back to the same float32
+			f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+		}
+		return f, nil
+	case Integer:
+		switch v := v.(type) {
+		case float64:
+			if v != math.Trunc(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("%v is not an integer", v)
+			}
+			return int64(v), nil
+		case string:
+			parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an integer", v)
+			}
+			return parsed, nil
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("unknown column type %q", typ)
+}
+
+// SchemaChangeKind is the kind of change to a column between two schemas.
+type SchemaChangeKind string
+
+// Schema change kinds.
+const (
+	ColumnAdded   SchemaChangeKind = "added"
+	ColumnRemoved SchemaChangeKind = "removed"
+	ColumnRetyped SchemaChangeKind = "retyped"
+)
+
+// SchemaChange is a change to a column between two schemas.
+type SchemaChange struct {
+	Column string           `json:"column"`
+	Kind   SchemaChangeKind `json:"kind"`
+	// From and To are the types of the column before and after the change,
+	// empty for added and removed columns respectively.
+	From ColumnType `json:"from,omitempty"`
+	To   ColumnType `json:"to,omitempty"`
+}
+
+// Breaking reports whether inputs built for the old schema may break the
+// new one: removed and retyped columns are breaking, added columns are not.
+func (c SchemaChange) Breaking() bool {
+	return c.Kind != ColumnAdded
+}
+
+func (c SchemaChange) String() string {
+	switch c.Kind {
+	case ColumnAdded:
+		return fmt.Sprintf("column %q added as %s", c.Column, c.To)
+	case ColumnRemoved:
+		return fmt.Sprintf("column %q removed", c.Column)
+	default:
+		return fmt.Sprintf("column %q retyped from %s to %s", c.Column, c.From, c.To)
+	}
+}
+
+// Diff returns the changes to the declared columns from s to next, sorted by
+// column. Columns declared without a type in either schema are not retyped.
+func (s Schema) Diff(next Schema) []SchemaChange {
+	var changes []SchemaChange
+	for name, typ := range s.Columns {
+		nextType, ok := next.Columns[name]
+		switch {
+		case !ok:
+			changes = append(changes, SchemaChange{Column: name, Kind: ColumnRemoved, From: typ})
+		case typ != "" && nextType != "" && typ != nextType:
+			changes = append(changes, SchemaChange{Column: name, Kind: ColumnRetyped, From: typ, To: nextType})
+		}
+	}
+	for name, typ := range next.Columns {
+		if _, ok := s.Columns[name]; !ok {
+			changes = append(changes, SchemaChange{Column: name, Kind: ColumnAdded, To: typ})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Column < changes[j].Column
+	})
+	return changes
+}
@@ -0,0 +1,218 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// outputScratch collects the values and row boundaries of an output while it
+// is decoded. Scratch buffers are pooled, so that decoding large predictions
+// only allocates their result.
+type outputScratch struct {
+	values []float64
+	// ends holds the index in values following the last value of each row.
+	ends []int
+}
+
+var scratchPool = sync.Pool{
+	New: func() any { return new(outputScratch) },
+}
+
+// maxPooledValues bounds the scratch buffers kept in the pool, so that a
+// single huge prediction does not pin its memory.
+const maxPooledValues = 1 << 20
+
+// decodeOutputs decodes the outputs of a prediction, an object mapping names
+// to arrays of rows of numbers, straight from the string returned by the
+// server, without converting it to a []byte or going through reflection. The
+// rows of an output share a single backing array. ok is false when the output
+// is not in that form or uses JSON the decoder does not handle, e.g. escaped
+// names, in which case encoding/json decides how to parse it.
+func decodeOutputs(s string) (outputs map[string][][]float64, ok bool) {
+	d := decoder{s: s}
+	if !d.consume('{') {
+		return nil, false
+	}
+	outputs = make(map[string][][]float64)
+	if d.consume('}') {
+		return outputs, d.end()
+	}
+	scratch := scratchPool.Get().(*outputScratch)
+	defer func() {
+		if cap(scratch.values) <= maxPooledValues {
+			scratchPool.Put(scratch)
+		}
+	}()
+	for {
+		name, ok := d.name()
+		if !ok || !d.consume(':') {
+			return nil, false
+		}
+		rows, ok := d.rows(scratch)
+		if !ok {
+			return nil, false
+		}
+		// the name would otherwise keep the whole output alive
+		outputs[strings.Clone(name)] = rows
+		if d.consume(',') {
+			continue
+		}
+		if d.consume('}') {
+			return outputs, d.end()
+		}
+		return nil, false
+	}
+}
+
+type decoder struct {
+	s string
+	i int
+}
+
+func (d *decoder) skipSpace() {
+	for d.i < len(d.s) {
+		switch d.s[d.i] {
+		case ' ', '\t', '\n', '\r':
+			d.i++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips whitespace and c, reporting whether c was next.
+func (d *decoder) consume(c byte) bool {
+	d.skipSpace()
+	if d.i < len(d.s) && d.s[d.i] == c {
+		d.i++
+		return true
+	}
+	return false
+}
+
+// end reports whether only whitespace is left.
+func (d *decoder) end() bool {
+	d.skipSpace()
+	return d.i == len(d.s)
+}
+
+// name decodes an ASCII string without escapes.
+func (d *decoder) name() (string, bool) {
+	if !d.consume('"') {
+		return "", false
+	}
+	start := d.i
+	for ; d.i < len(d.s); d.i++ {
+		switch c := d.s[d.i]; {
+		case c == '"':
+			d.i++
+			return d.s[start : d.i-1], true
+		case c == '\\' || c < 0x20 || c >= 0x80:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func (d *decoder) rows(scratch *outputScratch) ([][]float64, bool) {
+	if !d.consume('[') {
+		return nil, false
+	}
+	scratch.values, scratch.ends = scratch.values[:0], scratch.ends[:0]
+	if !d.consume(']') {
+		for {
+			if !d.row(scratch) {
+				return nil, false
+			}
+			scratch.ends = append(scratch.ends, len(scratch.values))
+			if d.consume(',') {
+				continue
+			}
+			if d.consume(']') {
+				break
+			}
+			return nil, false
+		}
+	}
+
+	values := make([]float64, len(scratch.values))
+	copy(values, scratch.values)
+	rows := make([][]float64, len(scratch.ends))
+	start := 0
+	for i, end := range scratch.ends {
+		rows[i] = values[start:end:end]
+		start = end
+	}
+	return rows, true
+}
+
+func (d *decoder) row(scratch *outputScratch) bool {
+	if !d.consume('[') {
+		return false
+	}
+	if d.consume(']') {
+		return true
+	}
+	for {
+		v, ok := d.number()
+		if !ok {
+			return false
+		}
+		scratch.values = append(scratch.values, v)
+		if d.consume(',') {
+			continue
+		}
+		return d.consume(']')
+	}
+}
+
+// number decodes a number following the JSON grammar.
+func (d *decoder) number() (float64, bool) {
+	d.skipSpace()
+	start := d.i
+	if d.peek() == '-' {
+		d.i++
+	}
+	switch c := d.peek(); {
+	case c == '0':
+		d.i++
+	case c >= '1' && c <= '9':
+		d.digits()
+	default:
+		return 0, false
+	}
+	if d.peek() == '.' {
+		d.i++
+		if !d.digits() {
+			return 0, false
+		}
+	}
+	if c := d.peek(); c == 'e' || c == 'E' {
+		d.i++
+		if c := d.peek(); c == '+' || c == '-' {
+			d.i++
+		}
+		if !d.digits() {
+			return 0, false
+		}
+	}
+	v, err := strconv.ParseFloat(d.s[start:d.i], 64)
+	return v, err == nil
+}
+
+// digits skips one or more digits, reporting whether there was any.
+func (d *decoder) digits() bool {
+	start := d.i
+	for d.i < len(d.s) && d.s[d.i] >= '0' && d.s[d.i] <= '9' {
+		d.i++
+	}
+	return d.i > start
+}
+
+func (d *decoder) peek() byte {
+	if d.i < len(d.s) {
+		return d.s[d.i]
+	}
+	return 0
+}
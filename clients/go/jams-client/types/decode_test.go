@@ -0,0 +1,115 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// decodeTests are outputs of the server, and whether decodeOutputs decodes
+// them itself rather than leaving them to encoding/json.
+var decodeTests = []struct {
+	name   string
+	output string
+	fast   bool
+}{
+	{"empty", `{}`, true},
+	{"no rows", `{"predictions": []}`, true},
+	{"empty row", `{"predictions": [[]]}`, true},
+	{"rows", `{"predictions": [[0.1, 0.9], [0.7, 0.3]]}`, true},
+	{"outputs", `{"a": [[1]], "b": [[2, 3]]}`, true},
+	{"duplicate names", `{"a": [[1]], "a": [[2]]}`, true},
+	{"whitespace", " \t\n{ \"a\" :\r[ [ 1 , 2 ] ] } \n", true},
+	{"integers", `{"a": [[0, -0, 12, -345]]}`, true},
+	{"negative zero", `{"a": [[-0, -0.0, -0e1]]}`, true},
+	{"exponents", `{"a": [[1e3, 1E3, 1e+3, 1e-3, -2.5E-7, 0e0]]}`, true},
+	{"extremes", `{"a": [[1.7976931348623157e308, 5e-324, 1e-400]]}`, true},
+
+	{"escaped name", `{"\u0070redictions": [[1]]}`, false},
+	{"escaped quote", `{"a\"b": [[1]]}`, false},
+	{"surrogate pair", `{"\ud83d\ude00": [[1]]}`, false},
+	{"lone surrogate", `{"\ud83d": [[1]]}`, false},
+	{"unicode name", `{"prédictions": [[1]]}`, false},
+	{"null output", `{"a": null}`, false},
+	{"null row", `{"a": [null, [1]]}`, false},
+	{"null value", `{"a": [[1, null]]}`, false},
+	{"null", `null`, false},
+
+	{"overflow", `{"a": [[1e400]]}`, false},
+	{"leading zero", `{"a": [[01]]}`, false},
+	{"leading plus", `{"a": [[+1]]}`, false},
+	{"bare point", `{"a": [[1.]]}`, false},
+	{"bare exponent", `{"a": [[1e]]}`, false},
+	{"trailing comma", `{"a": [[1,]]}`, false},
+	{"string value", `{"a": [["1"]]}`, false},
+	{"flat output", `{"a": [1, 2]}`, false},
+	{"trailing data", `{"a": [[1]]} x`, false},
+	{"unterminated", `{"a": [[1]]`, false},
+}
+
+func TestDecodeOutputs(t *testing.T) {
+	for _, tt := range decodeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, fast := decodeOutputs(tt.output)
+			if fast != tt.fast {
+				t.Fatalf("decodeOutputs(%q) ok = %v, want %v", tt.output, fast, tt.fast)
+			}
+			checkDecode(t, tt.output)
+		})
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, tt := range decodeTests {
+		f.Add(tt.output)
+	}
+	f.Fuzz(checkDecode)
+}
+
+// checkDecode checks that decodeOutputs decodes output as encoding/json does,
+// when it decodes it, and that ParsePrediction agrees with encoding/json.
+func checkDecode(t *testing.T, output string) {
+	var want map[string][][]float64
+	wantErr := json.Unmarshal([]byte(output), &want)
+
+	if got, ok := decodeOutputs(output); ok {
+		if wantErr != nil {
+			t.Fatalf("decodeOutputs(%q) decoded output encoding/json rejects: %v", output, wantErr)
+		}
+		sameOutputs(t, output, got, want)
+	}
+
+	prediction, err := ParsePrediction(output)
+	if (err != nil) != (wantErr != nil) {
+		t.Fatalf("ParsePrediction(%q) error = %v, encoding/json error = %v", output, err, wantErr)
+	}
+	if err == nil {
+		sameOutputs(t, output, prediction.Outputs, want)
+	}
+}
+
+// sameOutputs compares outputs bit for bit, telling -0 from 0, and empty
+// from missing rows.
+func sameOutputs(t *testing.T, output string, got, want map[string][][]float64) {
+	t.Helper()
+	if (got == nil) != (want == nil) || len(got) != len(want) {
+		t.Fatalf("%q: decoded %v, want %v", output, got, want)
+	}
+	for name, wantRows := range want {
+		rows, ok := got[name]
+		if !ok || (rows == nil) != (wantRows == nil) || len(rows) != len(wantRows) {
+			t.Fatalf("%q: output %q is %v, want %v", output, name, rows, wantRows)
+		}
+		for i, wantRow := range wantRows {
+			row := rows[i]
+			if (row == nil) != (wantRow == nil) || len(row) != len(wantRow) {
+				t.Fatalf("%q: row %d of %q is %v, want %v", output, i, name, row, wantRow)
+			}
+			for j := range wantRow {
+				if math.Float64bits(row[j]) != math.Float64bits(wantRow[j]) {
+					t.Fatalf("%q: value %d of row %d of %q is %v, want %v", output, j, i, name, row[j], wantRow[j])
+				}
+			}
+		}
+	}
+}
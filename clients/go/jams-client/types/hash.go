@@ -0,0 +1,65 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+)
+
+// HashInput returns a stable hash of the model name and the canonical form of
+// the input, for use as cache, idempotency or deduplication key. Inputs hash
+// equally when they have the same columns and values regardless of the order
+// the columns were added in. Integers and floats of the same value hash
+// differently, as the server treats them as different types, while -0.0 is
+// normalised to 0.0, also in sequences.
+func HashInput(modelName string, in *Input) (string, error) {
+	canonical := in.Clone()
+	canonical.order = canonical.Columns()
+	for name, values := range canonical.columns {
+		for i, v := range values {
+			v, err := canonicalValue(v)
+			if err != nil {
+				return "", fmt.Errorf("column %q has %w", name, err)
+			}
+			values[i] = v
+		}
+	}
+	// columns are now in sorted order and MarshalJSON writes floats with a
+	// fraction.
+	payload, err := canonical.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(modelName))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalValue returns v with -0.0 normalised to 0.0, copying sequences
+// rather than modifying them, or an error for NaN and infinite floats.
+func canonicalValue(v any) (any, error) {
+	switch v := v.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("unsupported value %v", v)
+		}
+		if v == 0 {
+			return 0.0, nil
+		}
+	case []any:
+		sequence := make([]any, len(v))
+		for i, e := range v {
+			e, err := canonicalValue(e)
+			if err != nil {
+				return nil, err
+			}
+			sequence[i] = e
+		}
+		return sequence, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,87 @@
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHashInput(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	tests := []struct {
+		name  string
+		a, b  *Input
+		equal bool
+	}{
+		{
+			name:  "column order",
+			a:     NewInput().AddFloats("x", 1).AddStrings("s", "a"),
+			b:     NewInput().AddStrings("s", "a").AddFloats("x", 1),
+			equal: true,
+		},
+		{
+			name:  "negative zero",
+			a:     NewInput().AddFloats("x", negZero),
+			b:     NewInput().AddFloats("x", 0),
+			equal: true,
+		},
+		{
+			name:  "negative zero in sequence",
+			a:     NewInput().AddFloatSequences("x", []float64{1, negZero}, []float64{negZero}),
+			b:     NewInput().AddFloatSequences("x", []float64{1, 0}, []float64{0}),
+			equal: true,
+		},
+		{
+			name: "integers and floats",
+			a:    NewInput().AddInts("x", 1),
+			b:    NewInput().AddFloats("x", 1),
+		},
+		{
+			name: "integers and floats in sequence",
+			a:    NewInput().AddIntSequences("x", []int{1}),
+			b:    NewInput().AddFloatSequences("x", []float64{1}),
+		},
+		{
+			name: "sequence values",
+			a:    NewInput().AddFloatSequences("x", []float64{1, 2}),
+			b:    NewInput().AddFloatSequences("x", []float64{2, 1}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := HashInput("m", tt.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := HashInput("m", tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (a == b) != tt.equal {
+				t.Fatalf("hashes %s and %s, want equal %v", a, b, tt.equal)
+			}
+		})
+	}
+
+	// the input is left as is
+	in := NewInput().AddFloatSequences("x", []float64{negZero})
+	if _, err := HashInput("m", in); err != nil {
+		t.Fatal(err)
+	}
+	values, _ := in.Column("x")
+	if v := values[0].([]any)[0].(float64); !math.Signbit(v) {
+		t.Fatalf("HashInput modified the input to %v", v)
+	}
+}
+
+func TestHashInputUnsupported(t *testing.T) {
+	for name, in := range map[string]*Input{
+		"NaN":                  NewInput().AddFloats("x", math.NaN()),
+		"infinity":             NewInput().AddFloats("x", math.Inf(1)),
+		"NaN in sequence":      NewInput().AddFloatSequences("x", []float64{1, math.NaN()}),
+		"infinity in sequence": NewInput().AddFloatSequences("x", []float64{math.Inf(-1)}),
+	} {
+		if hash, err := HashInput("m", in); err == nil {
+			t.Fatalf("%s: hashed to %s", name, hash)
+		}
+	}
+}
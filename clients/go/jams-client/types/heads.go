@@ -0,0 +1,79 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Head is a named output of a multi-head model, made of Width columns.
+type Head struct {
+	Name  string `json:"name" yaml:"name"`
+	Width int    `json:"width" yaml:"width"`
+}
+
+// Heads splits the columns of one output into named outputs, one per head in
+// column order. The server returns a single tensor per model, so multi-head
+// models, e.g. TorchScript models returning a tuple, are exported with their
+// heads concatenated and split again on the client. A zero Width on the last
+// head takes the remaining columns.
+//
+// Heads implements jams.Postprocessor, so Predict can split the predictions of
+// a model automatically:
+//
+//	jams.WithPostprocessor("torch-penguin_multihead", types.Heads{Heads: []types.Head{
+//		{Name: "species", Width: 3},
+//		{Name: "body_mass", Width: 1},
+//	}})
+type Heads struct {
+	// Output is the output holding the concatenated heads, DefaultOutput when
+	// empty. It is replaced by the heads.
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+	Heads  []Head `json:"heads" yaml:"heads"`
+}
+
+// Apply returns a copy of p with the output split into its heads.
+func (h Heads) Apply(p *Prediction) (*Prediction, error) {
+	if len(h.Heads) == 0 {
+		return nil, errors.New("at least one head is required")
+	}
+	output := h.Output
+	if output == "" {
+		output = DefaultOutput
+	}
+	rows, ok := p.Output(output)
+	if !ok {
+		return nil, fmt.Errorf("prediction has no output %q", output)
+	}
+
+	split := *p
+	split.Outputs = make(map[string][][]float64, len(p.Outputs)+len(h.Heads)-1)
+	for name, values := range p.Outputs {
+		if name != output {
+			split.Outputs[name] = values
+		}
+	}
+	for _, head := range h.Heads {
+		split.Outputs[head.Name] = make([][]float64, len(rows))
+	}
+	for i, row := range rows {
+		start := 0
+		for n, head := range h.Heads {
+			end := start + head.Width
+			if head.Width == 0 && n == len(h.Heads)-1 {
+				end = len(row)
+			}
+			if head.Width < 0 || (head.Width == 0 && n < len(h.Heads)-1) {
+				return nil, fmt.Errorf("head %q has an invalid width of %d", head.Name, head.Width)
+			}
+			if end > len(row) {
+				return nil, fmt.Errorf("row %d of output %q has %d values, too few for the heads", i, output, len(row))
+			}
+			split.Outputs[head.Name][i] = row[start:end]
+			start = end
+		}
+		if start != len(row) {
+			return nil, fmt.Errorf("row %d of output %q has %d values, the heads cover %d", i, output, len(row), start)
+		}
+	}
+	return &split, nil
+}
@@ -0,0 +1,489 @@
+// Package types contains the model input and prediction types exchanged with a
+// J.A.M.S server.
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Input is the columnar model input expected by J.A.M.S. Each column is a
+// feature name mapped to one value per record. Values are int64, float64 or
+// string, or sequences of them in ragged columns; see AddFloatSequences.
+// Parsed inputs may also hold nil for missing values, which must be imputed
+// before they are sent.
+//
+// Columns are sent in the order they were added unless changed with SetOrder,
+// which keeps payloads deterministic and easy to diff.
+//
+//	input := types.NewInput().
+//		AddStrings("sex", "male", "female").
+//		AddFloats("age", 22.0, 23.8)
+type Input struct {
+	columns map[string][]any
+	// order holds the column names in the order they are marshalled.
+	order []string
+}
+
+// NewInput returns an empty Input.
+func NewInput() *Input {
+	return &Input{columns: make(map[string][]any)}
+}
+
+// AddInts adds an integer feature column.
+func (in *Input) AddInts(name string, values ...int) *Input {
+	column := make([]any, len(values))
+	for i, v := range values {
+		column[i] = int64(v)
+	}
+	in.set(name, column)
+	return in
+}
+
+// AddFloats adds a floating point feature column.
+func (in *Input) AddFloats(name string, values ...float64) *Input {
+	column := make([]any, len(values))
+	for i, v := range values {
+		column[i] = v
+	}
+	in.set(name, column)
+	return in
+}
+
+// AddStrings adds a string feature column.
+func (in *Input) AddStrings(name string, values ...string) *Input {
+	column := make([]any, len(values))
+	for i, v := range values {
+		column[i] = v
+	}
+	in.set(name, column)
+	return in
+}
+
+// set adds or replaces a column, keeping the position of replaced columns.
+func (in *Input) set(name string, column []any) {
+	if _, ok := in.columns[name]; !ok {
+		in.order = append(in.order, name)
+	}
+	in.columns[name] = column
+}
+
+// Columns returns the feature names in sorted order. See Order for the order
+// they are sent in.
+func (in *Input) Columns() []string {
+	names := make([]string, 0, len(in.columns))
+	for name := range in.columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Order returns the feature names in the order they are marshalled.
+func (in *Input) Order() []string {
+	return append([]string(nil), in.order...)
+}
+
+// SetOrder moves the named columns to the front, in the given order, so that
+// they are marshalled first. The other columns keep their relative order. It
+// returns an error when a named column is missing.
+func (in *Input) SetOrder(names ...string) error {
+	order := make([]string, 0, len(in.order))
+	named := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := in.columns[name]; !ok {
+			return fmt.Errorf("input has no column %q", name)
+		}
+		if !named[name] {
+			named[name] = true
+			order = append(order, name)
+		}
+	}
+	for _, name := range in.order {
+		if !named[name] {
+			order = append(order, name)
+		}
+	}
+	in.order = order
+	return nil
+}
+
+// CheckColumns returns an error naming the columns of the input which are not
+// among the given names.
+func (in *Input) CheckColumns(names ...string) error {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	var unknown []string
+	for _, name := range in.order {
+		if !known[name] {
+			unknown = append(unknown, strconv.Quote(name))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("input has unknown columns %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Column returns the values of the named feature column.
+func (in *Input) Column(name string) ([]any, bool) {
+	values, ok := in.columns[name]
+	return values, ok
+}
+
+// Delete removes the named feature column.
+func (in *Input) Delete(name string) *Input {
+	if _, ok := in.columns[name]; !ok {
+		return in
+	}
+	delete(in.columns, name)
+	for i, n := range in.order {
+		if n == name {
+			in.order = append(in.order[:i:i], in.order[i+1:]...)
+			break
+		}
+	}
+	return in
+}
+
+// Clone returns a copy of the input which can be changed without affecting
+// the original.
+func (in *Input) Clone() *Input {
+	clone := &Input{
+		columns: make(map[string][]any, len(in.columns)),
+		order:   append([]string(nil), in.order...),
+	}
+	for name, values := range in.columns {
+		clone.columns[name] = append([]any(nil), values...)
+	}
+	return clone
+}
+
+// Slice returns a copy of the records from start up to, but excluding, end.
+func (in *Input) Slice(start, end int) *Input {
+	slice := &Input{
+		columns: make(map[string][]any, len(in.columns)),
+		order:   append([]string(nil), in.order...),
+	}
+	for name, values := range in.columns {
+		slice.columns[name] = append([]any(nil), values[start:end]...)
+	}
+	return slice
+}
+
+// Len returns the number of records in the input.
+func (in *Input) Len() int {
+	for _, values := range in.columns {
+		return len(values)
+	}
+	return 0
+}
+
+// Validate checks that the input has at least one column, that every column
+// holds the same number of records, that no value is missing and that the
+// single values of a column, or the sequences of a ragged column, hold a
+// single type, as the server infers the type of a column from its first value.
+func (in *Input) Validate() error {
+	if len(in.columns) == 0 {
+		return errors.New("input has no columns")
+	}
+	records := -1
+	for _, name := range in.Columns() {
+		n := len(in.columns[name])
+		if records == -1 {
+			records = n
+		}
+		if n != records {
+			return fmt.Errorf("column %q has %d values, expected %d", name, n, records)
+		}
+		for i, v := range in.columns[name] {
+			if v == nil {
+				return fmt.Errorf("column %q has a missing value for record %d", name, i)
+			}
+		}
+		if err := validateScalars(in.columns[name]); err != nil {
+			return fmt.Errorf("column %q: %w", name, err)
+		}
+		if err := validateSequences(in.columns[name]); err != nil {
+			return fmt.Errorf("column %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateScalars checks that the single values of a column all have the
+// same type.
+func validateScalars(values []any) error {
+	var valueType string
+	for i, v := range values {
+		if _, ok := v.([]any); ok || v == nil {
+			continue
+		}
+		t := fmt.Sprintf("%T", v)
+		if valueType == "" {
+			valueType = t
+		}
+		if t != valueType {
+			return fmt.Errorf("record %d has a %s, expected %s", i, t, valueType)
+		}
+	}
+	return nil
+}
+
+// promoteFloats converts the integers of a column holding floats too, also
+// in sequences, to floats. JSON records such as {"fare": 7} and
+// {"fare": 7.25} give such columns, which the server would read as integers.
+func promoteFloats(values []any) {
+	var ints, floats bool
+	for _, v := range values {
+		elements, ok := v.([]any)
+		if !ok {
+			elements = []any{v}
+		}
+		for _, e := range elements {
+			switch e.(type) {
+			case int64:
+				ints = true
+			case float64:
+				floats = true
+			}
+		}
+	}
+	if !ints || !floats {
+		return
+	}
+	for i, v := range values {
+		switch v := v.(type) {
+		case int64:
+			values[i] = float64(v)
+		case []any:
+			for j, e := range v {
+				if n, ok := e.(int64); ok {
+					v[j] = float64(n)
+				}
+			}
+		}
+	}
+}
+
+// MarshalJSON encodes the input in the wire format understood by the server.
+// Columns are written in Order. Floats are always written with a fraction or
+// exponent, as the server infers the type of a column from its JSON numbers.
+func (in *Input) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range in.order {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		values := in.columns[name]
+		column := make([]any, len(values))
+		for i, v := range values {
+			column[i] = wireValue(v)
+		}
+		data, err := json.Marshal(column)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", name, err)
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// wireValue returns v with its floats, also those in sequences, as jsonFloat.
+func wireValue(v any) any {
+	switch v := v.(type) {
+	case float64:
+		return jsonFloat(v)
+	case []any:
+		sequence := make([]any, len(v))
+		for i, e := range v {
+			sequence[i] = wireValue(e)
+		}
+		return sequence
+	}
+	return v
+}
+
+// jsonFloat is a float64 which keeps its fraction when encoded, e.g. 1.0 is
+// written as "1.0" rather than "1".
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(float64(f))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.ContainsAny(data, ".eE") {
+		data = append(data, ".0"...)
+	}
+	return data, nil
+}
+
+// ParseInput parses a JSON object mapping feature names to lists of values,
+// i.e. the wire format produced by MarshalJSON. Numbers without a fraction or
+// exponent are parsed as int64, other numbers as float64, and integers as
+// float64 too in columns holding floats. The columns keep their order in data.
+func ParseInput(data []byte) (*Input, error) {
+	names, raw, err := parseObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	in := NewInput()
+	for _, name := range names {
+		values := raw[name]
+		column := make([]any, len(values))
+		for i, value := range values {
+			v, err := parseValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", name, err)
+			}
+			column[i] = v
+		}
+		promoteFloats(column)
+		in.set(name, column)
+	}
+	return in, nil
+}
+
+// parseObject parses a JSON object of lists, returning its keys in order.
+func parseObject(data []byte) ([]string, map[string][]json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	} else if token != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected a JSON object, got %v", token)
+	}
+	var names []string
+	raw := make(map[string][]json.RawMessage)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		name := token.(string)
+		var values []json.RawMessage
+		if err := decoder.Decode(&values); err != nil {
+			return nil, nil, fmt.Errorf("column %q: %w", name, err)
+		}
+		if _, ok := raw[name]; !ok {
+			names = append(names, name)
+		}
+		raw[name] = values
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, nil, errors.New("unexpected data after the JSON object")
+	}
+	return names, raw, nil
+}
+
+// NewInputFromRecords builds a columnar input from records mapping feature
+// names to single values. Every record must have the same features. Integers
+// are converted to float64 in columns holding floats, e.g. the fares of the
+// JSON records {"fare": 7} and {"fare": 7.25}. Columns are ordered by name.
+func NewInputFromRecords(records []map[string]any) (*Input, error) {
+	in := NewInput()
+	if len(records) == 0 {
+		return in, nil
+	}
+	names := make([]string, 0, len(records[0]))
+	for name := range records[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		in.set(name, make([]any, len(records)))
+	}
+	for i, record := range records {
+		if len(record) != len(in.columns) {
+			return nil, fmt.Errorf("record %d has %d features, expected %d", i, len(record), len(in.columns))
+		}
+		for name, value := range record {
+			column, ok := in.columns[name]
+			if !ok {
+				return nil, fmt.Errorf("record %d has unexpected feature %q", i, name)
+			}
+			v, err := normalizeValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("record %d, feature %q: %w", i, name, err)
+			}
+			column[i] = v
+		}
+	}
+	for _, column := range in.columns {
+		promoteFloats(column)
+	}
+	return in, nil
+}
+
+func parseValue(value json.RawMessage) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return normalizeValue(v)
+}
+
+// normalizeValue converts a Go or decoded JSON value to one of the value types
+// held by Input.
+func normalizeValue(v any) (any, error) {
+	switch v := v.(type) {
+	case []any:
+		return normalizeSequence(v)
+	case []int:
+		return sequence(v), nil
+	case []int64:
+		return sequence(v), nil
+	case []float64:
+		return sequence(v), nil
+	case []string:
+		return sequence(v), nil
+	}
+	return normalizeScalar(v)
+}
+
+// normalizeScalar converts a Go or decoded JSON value to int64, float64,
+// string or nil.
+func normalizeScalar(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, string, int64, float64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		if !strings.ContainsAny(v.String(), ".eE") {
+			if i, err := v.Int64(); err == nil {
+				return i, nil
+			}
+		}
+		return v.Float64()
+	default:
+		return nil, fmt.Errorf("unsupported value %v of type %T", v, v)
+	}
+}
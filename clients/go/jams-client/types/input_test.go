@@ -0,0 +1,88 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// decodeRecords decodes JSON lines as the records of jams-cli.
+func decodeRecords(t *testing.T, lines ...string) []map[string]any {
+	t.Helper()
+	records := make([]map[string]any, len(lines))
+	for i, line := range lines {
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&records[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return records
+}
+
+func TestNewInputFromRecordsPromotesFloats(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []string
+		want    string
+	}{
+		{name: "integers", records: []string{`{"n": 7}`, `{"n": 8}`}, want: `{"n":[7,8]}`},
+		{name: "floats", records: []string{`{"fare": 7.5}`, `{"fare": 7.25}`}, want: `{"fare":[7.5,7.25]}`},
+		{name: "integer first", records: []string{`{"fare": 7}`, `{"fare": 7.25}`}, want: `{"fare":[7.0,7.25]}`},
+		{name: "float first", records: []string{`{"fare": 7.25}`, `{"fare": 7}`}, want: `{"fare":[7.25,7.0]}`},
+		{name: "sequences", records: []string{`{"s": [1, 2]}`, `{"s": [2.5]}`}, want: `{"s":[[1.0,2.0],[2.5]]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := NewInputFromRecords(decodeRecords(t, tt.records...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := in.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			data, err := in.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("got %s, want %s", data, tt.want)
+			}
+
+			parsed, err := ParseInput(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again, _ := parsed.MarshalJSON(); string(again) != tt.want {
+				t.Fatalf("parsed back to %s, want %s", again, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInputPromotesFloats(t *testing.T) {
+	in, err := ParseInput([]byte(`{"fare": [7, 7.25]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := in.MarshalJSON(); string(data) != `{"fare":[7.0,7.25]}` {
+		t.Fatalf("got %s", data)
+	}
+}
+
+func TestValidateMixedTypes(t *testing.T) {
+	for _, records := range [][]string{
+		{`{"x": 7}`, `{"x": "a"}`},
+		{`{"x": "a"}`, `{"x": 7.5}`},
+		{`{"x": [1]}`, `{"x": ["a"]}`},
+		{`{"x": [1]}`, `{"x": 1}`},
+	} {
+		in, err := NewInputFromRecords(decodeRecords(t, records...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := in.Validate(); err == nil {
+			t.Fatalf("records %v validated", records)
+		}
+	}
+}
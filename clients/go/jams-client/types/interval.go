@@ -0,0 +1,124 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Uncertainty describes how the columns of an output express the uncertainty
+// of a prediction.
+type Uncertainty struct {
+	// Output defaults to DefaultOutput.
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+	// Quantiles are the levels predicted by the columns of a quantile
+	// regression model, in column order, e.g. [0.05, 0.5, 0.95]. When empty,
+	// the columns are the predictions of the members of an ensemble.
+	Quantiles []float64 `json:"quantiles,omitempty" yaml:"quantiles,omitempty"`
+}
+
+// Interval is the central prediction interval of a record.
+type Interval struct {
+	Lower float64 `json:"lower"`
+	// Point is the median of a quantile model, or the middle of the interval
+	// when the median cannot be interpolated, or the mean of an ensemble.
+	Point float64 `json:"point"`
+	Upper float64 `json:"upper"`
+}
+
+// Interval returns the central 1-alpha prediction interval of the record at
+// index i, e.g. the 90% interval for alpha 0.1, according to
+// p.Uncertainty. Quantile models must predict the quantiles alpha/2 and
+// 1-alpha/2 or quantiles on both sides of them.
+func (p *Prediction) Interval(i int, alpha float64) (Interval, error) {
+	if p.Uncertainty == nil {
+		return Interval{}, errors.New("prediction has no uncertainty description")
+	}
+	if alpha <= 0 || alpha >= 1 {
+		return Interval{}, fmt.Errorf("alpha must be between 0 and 1, got %v", alpha)
+	}
+	output := p.Uncertainty.Output
+	if output == "" {
+		output = DefaultOutput
+	}
+	rows := p.Outputs[output]
+	if i < 0 || i >= len(rows) {
+		return Interval{}, fmt.Errorf("output %q has no record %d", output, i)
+	}
+	row := rows[i]
+	if len(row) == 0 {
+		return Interval{}, fmt.Errorf("output %q has no values for record %d", output, i)
+	}
+
+	if levels := p.Uncertainty.Quantiles; len(levels) > 0 {
+		if len(levels) != len(row) {
+			return Interval{}, fmt.Errorf("output %q has %d values for record %d, expected one per quantile", output, len(row), i)
+		}
+		lower, err := interpolate(levels, row, alpha/2)
+		if err != nil {
+			return Interval{}, err
+		}
+		upper, err := interpolate(levels, row, 1-alpha/2)
+		if err != nil {
+			return Interval{}, err
+		}
+		point, err := interpolate(levels, row, 0.5)
+		if err != nil {
+			point = (lower + upper) / 2
+		}
+		return Interval{Lower: lower, Point: point, Upper: upper}, nil
+	}
+
+	members := append([]float64(nil), row...)
+	sort.Float64s(members)
+	var sum float64
+	for _, v := range members {
+		sum += v
+	}
+	return Interval{
+		Lower: quantile(members, alpha/2),
+		Point: sum / float64(len(members)),
+		Upper: quantile(members, 1-alpha/2),
+	}, nil
+}
+
+// Intervals returns the central 1-alpha prediction interval of every record.
+func (p *Prediction) Intervals(alpha float64) ([]Interval, error) {
+	output := DefaultOutput
+	if p.Uncertainty != nil && p.Uncertainty.Output != "" {
+		output = p.Uncertainty.Output
+	}
+	intervals := make([]Interval, len(p.Outputs[output]))
+	for i := range intervals {
+		interval, err := p.Interval(i, alpha)
+		if err != nil {
+			return nil, err
+		}
+		intervals[i] = interval
+	}
+	return intervals, nil
+}
+
+// interpolate returns the value at level given the values at levels,
+// interpolating linearly between the closest levels on either side.
+func interpolate(levels, values []float64, level float64) (float64, error) {
+	const epsilon = 1e-9
+	below, above := -1, -1
+	for j, l := range levels {
+		if math.Abs(l-level) < epsilon {
+			return values[j], nil
+		}
+		if l < level && (below == -1 || l > levels[below]) {
+			below = j
+		}
+		if l > level && (above == -1 || l < levels[above]) {
+			above = j
+		}
+	}
+	if below == -1 || above == -1 {
+		return 0, fmt.Errorf("quantile %v is outside the predicted quantiles %v", level, levels)
+	}
+	fraction := (level - levels[below]) / (levels[above] - levels[below])
+	return values[below] + (values[above]-values[below])*fraction, nil
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"math"
+	"sort"
+)
+
+// OutlierRule flags the records whose value in one output column is out of
+// bounds or too far from the mean. Records without the column are ignored,
+// NaN and infinite values are always flagged.
+type OutlierRule struct {
+	// Output defaults to DefaultOutput.
+	Output string
+	Column int
+	// Min and Max bound the valid values when not nil.
+	Min *float64
+	Max *float64
+	// ZScore flags values more than ZScore standard deviations away from the
+	// mean when greater than zero. The mean and standard deviation are those
+	// of the prediction itself unless Std is set, e.g. to the values seen in
+	// validation.
+	ZScore float64
+	Mean   float64
+	Std    float64
+}
+
+// Bound returns a pointer to v, for the bounds of an OutlierRule.
+func Bound(v float64) *float64 {
+	return &v
+}
+
+// Outliers returns the sorted indices of the records flagged by any of the
+// rules.
+func (p *Prediction) Outliers(rules ...OutlierRule) []int {
+	flagged := make(map[int]bool)
+	for _, rule := range rules {
+		output := rule.Output
+		if output == "" {
+			output = DefaultOutput
+		}
+		rows := p.Outputs[output]
+
+		mean, std := rule.Mean, rule.Std
+		if rule.ZScore > 0 && std == 0 {
+			var summary ColumnSummary
+			summarise(&summary, columnValues(rows, rule.Column))
+			mean, std = summary.Mean, summary.Std
+		}
+		for i, row := range rows {
+			if rule.Column >= len(row) {
+				continue
+			}
+			if v := row[rule.Column]; rule.flags(v, mean, std) {
+				flagged[i] = true
+			}
+		}
+	}
+
+	indices := make([]int, 0, len(flagged))
+	for i := range flagged {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+func (r OutlierRule) flags(v, mean, std float64) bool {
+	switch {
+	case math.IsNaN(v) || math.IsInf(v, 0):
+		return true
+	case r.Min != nil && v < *r.Min:
+		return true
+	case r.Max != nil && v > *r.Max:
+		return true
+	case r.ZScore > 0 && std > 0:
+		return math.Abs(v-mean)/std > r.ZScore
+	}
+	return false
+}
+
+// columnValues returns the finite values of a column.
+func columnValues(rows [][]float64, column int) []float64 {
+	values := make([]float64, 0, len(rows))
+	for _, row := range rows {
+		if column < len(row) && !math.IsNaN(row[column]) && !math.IsInf(row[column], 0) {
+			values = append(values, row[column])
+		}
+	}
+	return values
+}
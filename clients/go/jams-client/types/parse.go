@@ -0,0 +1,188 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseMode controls how ParsePredictionWith treats outputs which do not
+// have the expected shape.
+type ParseMode int
+
+// Parse modes.
+const (
+	// ParseDefault is the behaviour of ParsePrediction: non-numeric values
+	// fail, ragged rows and extra outputs are accepted.
+	ParseDefault ParseMode = iota
+	// ParseStrict fails on ragged rows, outputs of different lengths,
+	// unexpected outputs and non-numeric values, for pipelines which must not
+	// continue with malformed predictions.
+	ParseStrict
+	// ParseLenient coerces what it can and records a warning in
+	// Prediction.Warnings for everything else: numeric strings and booleans
+	// become numbers, nulls and other values NaN, and single values rows
+	// of one value. Ragged rows and unexpected outputs are kept.
+	ParseLenient
+)
+
+// ParseOptions configures ParsePredictionWith.
+type ParseOptions struct {
+	Mode ParseMode
+	// Outputs are the output names expected by strict and lenient mode.
+	// Defaults to DefaultOutput.
+	Outputs []string
+}
+
+// ParsePredictionWith parses the JSON output string returned by the server in
+// the given mode.
+func ParsePredictionWith(output string, opts ParseOptions) (*Prediction, error) {
+	if opts.Mode == ParseDefault {
+		return ParsePrediction(output)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+	var raw map[string]any
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse prediction output: %w", err)
+	}
+
+	p := &parser{strict: opts.Mode == ParseStrict}
+	expected := opts.Outputs
+	if len(expected) == 0 {
+		expected = []string{DefaultOutput}
+	}
+	known := make(map[string]bool, len(expected))
+	for _, name := range expected {
+		known[name] = true
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	prediction := &Prediction{Outputs: make(map[string][][]float64, len(raw))}
+	for _, name := range names {
+		if !known[name] {
+			if err := p.fail("unexpected output %q", name); err != nil {
+				return nil, err
+			}
+		}
+		rows, err := p.rows(name, raw[name])
+		if err != nil {
+			return nil, err
+		}
+		prediction.Outputs[name] = rows
+	}
+	for _, name := range names {
+		if n, m := len(prediction.Outputs[name]), len(prediction.Outputs[names[0]]); n != m {
+			if err := p.fail("output %q has %d rows, output %q %d", name, n, names[0], m); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, name := range expected {
+		if _, ok := prediction.Outputs[name]; !ok {
+			if err := p.fail("missing output %q", name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	prediction.Warnings = p.warnings
+	return prediction, nil
+}
+
+// parser fails in strict mode and collects warnings in lenient mode.
+type parser struct {
+	strict   bool
+	warnings []string
+}
+
+func (p *parser) fail(format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if p.strict {
+		return fmt.Errorf("malformed prediction output: %s", msg)
+	}
+	p.warnings = append(p.warnings, msg)
+	return nil
+}
+
+func (p *parser) rows(name string, value any) ([][]float64, error) {
+	values, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("malformed prediction output: output %q is %s rather than a list of rows", name, jsonType(value))
+	}
+	rows := make([][]float64, len(values))
+	width := -1
+	for i, value := range values {
+		at := fmt.Sprintf("output %q, row %d", name, i)
+		var row []float64
+		switch value := value.(type) {
+		case []any:
+			row = make([]float64, len(value))
+			for j, v := range value {
+				f, err := p.number(v, at)
+				if err != nil {
+					return nil, err
+				}
+				row[j] = f
+			}
+		default:
+			if err := p.fail("%s is a single value", at); err != nil {
+				return nil, err
+			}
+			f, err := p.number(value, at)
+			if err != nil {
+				return nil, err
+			}
+			row = []float64{f}
+		}
+		if width == -1 {
+			width = len(row)
+		} else if len(row) != width {
+			if err := p.fail("%s has %d values, expected %d", at, len(row), width); err != nil {
+				return nil, err
+			}
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// number converts a row value, at describing its position.
+func (p *parser) number(v any, at string) (float64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return v.Float64()
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, p.fail("%s has numeric string %q", at, v)
+		}
+	case bool:
+		if v {
+			return 1, p.fail("%s has boolean true", at)
+		}
+		return 0, p.fail("%s has boolean false", at)
+	}
+	return math.NaN(), p.fail("%s has non-numeric value %s", at, jsonType(v))
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprint(v)
+}
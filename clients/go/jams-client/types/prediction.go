@@ -0,0 +1,71 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// DefaultOutput is the output name under which the server returns predictions.
+const DefaultOutput = "predictions"
+
+// Prediction is the parsed output of a Predict call. Each output holds one row
+// per input record; regression models return a single value per row while
+// classification models return one value per class.
+type Prediction struct {
+	Outputs map[string][][]float64
+	// Warnings describes the values coerced or kept by ParseLenient.
+	Warnings []string
+	// Uncertainty describes the outputs of models predicting intervals, for
+	// Interval and Intervals.
+	Uncertainty *Uncertainty
+}
+
+// ParsePrediction parses the JSON output string returned by the server.
+func ParsePrediction(output string) (*Prediction, error) {
+	if outputs, ok := decodeOutputs(output); ok {
+		return &Prediction{Outputs: outputs}, nil
+	}
+	outputs := make(map[string][][]float64)
+	if err := json.Unmarshal([]byte(output), &outputs); err != nil {
+		return nil, fmt.Errorf("failed to parse prediction output: %w", err)
+	}
+	return &Prediction{Outputs: outputs}, nil
+}
+
+// Values returns the rows of the default output.
+func (p *Prediction) Values() [][]float64 {
+	return p.Outputs[DefaultOutput]
+}
+
+// Output returns the rows of the named output.
+func (p *Prediction) Output(name string) ([][]float64, bool) {
+	rows, ok := p.Outputs[name]
+	return rows, ok
+}
+
+// Names returns the output names in sorted order.
+func (p *Prediction) Names() []string {
+	names := make([]string, 0, len(p.Outputs))
+	for name := range p.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Len returns the number of records in the default output.
+func (p *Prediction) Len() int {
+	return len(p.Values())
+}
+
+// Record returns the values of every output for the record at index i.
+func (p *Prediction) Record(i int) map[string][]float64 {
+	record := make(map[string][]float64, len(p.Outputs))
+	for name, rows := range p.Outputs {
+		if i < len(rows) {
+			record[name] = rows[i]
+		}
+	}
+	return record
+}
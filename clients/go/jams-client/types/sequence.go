@@ -0,0 +1,174 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AddIntSequences adds a ragged integer feature column, holding a sequence of
+// any length per record.
+func (in *Input) AddIntSequences(name string, values ...[]int) *Input {
+	column := make([]any, len(values))
+	for i, v := range values {
+		column[i] = sequence(v)
+	}
+	in.set(name, column)
+	return in
+}
+
+// AddFloatSequences adds a ragged floating point feature column, holding a
+// sequence of any length per record, e.g. the event history of a user.
+//
+// Ragged columns are sent as nested JSON arrays. Servers reading only flat
+// columns need them padded to a fixed length with Flatten first.
+func (in *Input) AddFloatSequences(name string, values ...[]float64) *Input {
+	column := make([]any, len(values))
+	for i, v := range values {
+		column[i] = sequence(v)
+	}
+	in.set(name, column)
+	return in
+}
+
+// AddStringSequences adds a ragged string feature column, holding a sequence
+// of any length per record.
+func (in *Input) AddStringSequences(name string, values ...[]string) *Input {
+	column := make([]any, len(values))
+	for i, v := range values {
+		column[i] = sequence(v)
+	}
+	in.set(name, column)
+	return in
+}
+
+// IsRagged reports whether the named column holds sequences.
+func (in *Input) IsRagged(name string) bool {
+	for _, v := range in.columns[name] {
+		if _, ok := v.([]any); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Flatten replaces the ragged column name by length flat columns name_0 to
+// name_<length-1>, truncating longer sequences and padding shorter ones with
+// pad, plus a name_length column holding the original length of each
+// sequence. This is the encoding expected by models with fixed size inputs.
+func (in *Input) Flatten(name string, length int, pad any) error {
+	values, ok := in.columns[name]
+	if !ok {
+		return fmt.Errorf("input has no column %q", name)
+	}
+	if length <= 0 {
+		return fmt.Errorf("length must be greater than zero, got %d", length)
+	}
+	pad, err := normalizeScalar(pad)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, length+1)
+	for j := 0; j < length; j++ {
+		names[j] = fmt.Sprintf("%s_%d", name, j)
+	}
+	names[length] = name + "_length"
+	for _, n := range names {
+		if _, ok := in.columns[n]; ok {
+			return fmt.Errorf("input already has a column %q", n)
+		}
+	}
+
+	columns := make([][]any, len(names))
+	for j := range columns {
+		columns[j] = make([]any, len(values))
+	}
+	for i, v := range values {
+		s, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("column %q holds %T rather than a sequence for record %d", name, v, i)
+		}
+		for j := 0; j < length; j++ {
+			if j < len(s) {
+				columns[j][i] = s[j]
+			} else {
+				columns[j][i] = pad
+			}
+		}
+		columns[length][i] = int64(len(s))
+	}
+
+	// the flat columns take the place of the ragged one.
+	order := make([]string, 0, len(in.order)+length)
+	for _, n := range in.order {
+		if n == name {
+			order = append(order, names...)
+		} else {
+			order = append(order, n)
+		}
+	}
+	in.order = order
+	for j, n := range names {
+		in.columns[n] = columns[j]
+	}
+	delete(in.columns, name)
+	return nil
+}
+
+// sequence converts a Go slice to a sequence value.
+func sequence[T int | int64 | float64 | string](values []T) []any {
+	s := make([]any, len(values))
+	for i, v := range values {
+		switch v := any(v).(type) {
+		case int:
+			s[i] = int64(v)
+		default:
+			s[i] = v
+		}
+	}
+	return s
+}
+
+// normalizeSequence normalises the elements of a decoded JSON array, which
+// must be present single values.
+func normalizeSequence(values []any) ([]any, error) {
+	s := make([]any, len(values))
+	for i, v := range values {
+		n, err := normalizeScalar(v)
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			return nil, fmt.Errorf("sequence has a missing value at position %d", i)
+		}
+		s[i] = n
+	}
+	return s, nil
+}
+
+// validateSequences checks that a column holds either no sequences or only
+// sequences, whose elements all have the same type.
+func validateSequences(values []any) error {
+	var elementType string
+	sequences := 0
+	for i, v := range values {
+		s, ok := v.([]any)
+		if !ok {
+			continue
+		}
+		sequences++
+		for _, e := range s {
+			t := fmt.Sprintf("%T", e)
+			if elementType == "" {
+				elementType = t
+			}
+			if t != elementType {
+				return fmt.Errorf("record %d has a sequence of %s, expected %s", i, t, elementType)
+			}
+		}
+	}
+	if sequences > 0 && sequences < len(values) {
+		return errors.New("mixes sequences and single values")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"math"
+	"sort"
+)
+
+// SummaryQuantiles are the quantiles computed by Summary.
+var SummaryQuantiles = []float64{0.01, 0.05, 0.25, 0.5, 0.75, 0.95, 0.99}
+
+// ColumnSummary describes the distribution of one column of an output, e.g.
+// the probability of a class over all records.
+type ColumnSummary struct {
+	Output string `json:"output"`
+	Column int    `json:"column"`
+	// Count is the number of values, not counting the NaN ones in Missing.
+	Count     int        `json:"count"`
+	Missing   int        `json:"missing"`
+	Mean      float64    `json:"mean"`
+	Std       float64    `json:"std"`
+	Min       float64    `json:"min"`
+	Max       float64    `json:"max"`
+	Quantiles []Quantile `json:"quantiles"`
+}
+
+// Quantile is the value below which a fraction P of the values fall.
+type Quantile struct {
+	P     float64 `json:"p"`
+	Value float64 `json:"value"`
+}
+
+// Summary returns the distribution of every column of every output, sorted by
+// output name then column. Std is the sample standard deviation.
+func (p *Prediction) Summary() []ColumnSummary {
+	var summaries []ColumnSummary
+	for _, name := range p.Names() {
+		rows := p.Outputs[name]
+		width := 0
+		for _, row := range rows {
+			width = max(width, len(row))
+		}
+		for column := 0; column < width; column++ {
+			values := make([]float64, 0, len(rows))
+			summary := ColumnSummary{Output: name, Column: column}
+			for _, row := range rows {
+				if column >= len(row) {
+					continue
+				}
+				if math.IsNaN(row[column]) {
+					summary.Missing++
+					continue
+				}
+				values = append(values, row[column])
+			}
+			summarise(&summary, values)
+			summaries = append(summaries, summary)
+		}
+	}
+	return summaries
+}
+
+func summarise(s *ColumnSummary, values []float64) {
+	s.Count = len(values)
+	if s.Count == 0 {
+		return
+	}
+	sort.Float64s(values)
+	s.Min, s.Max = values[0], values[len(values)-1]
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	s.Mean = sum / float64(len(values))
+	if len(values) > 1 {
+		var squares float64
+		for _, v := range values {
+			squares += (v - s.Mean) * (v - s.Mean)
+		}
+		s.Std = math.Sqrt(squares / float64(len(values)-1))
+	}
+
+	s.Quantiles = make([]Quantile, len(SummaryQuantiles))
+	for i, q := range SummaryQuantiles {
+		s.Quantiles[i] = Quantile{P: q, Value: quantile(values, q)}
+	}
+}
+
+// quantile returns quantile q of sorted values, interpolating linearly between
+// the closest ranks.
+func quantile(sorted []float64, q float64) float64 {
+	rank := math.Max(0, math.Min(1, q)) * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Template holds the static feature columns of a model, such as
+// configuration constants, so that call sites only build the features which
+// change between calls. Apply merges them into an input.
+//
+//	template, err := types.NewTemplate(types.NewInput().
+//		AddStrings("region", "eu-west").
+//		AddInts("model_config", 3))
+//
+//	input, err := template.Apply(types.NewInput().AddFloats("age", 22.0, 23.8))
+type Template struct {
+	static *Input
+}
+
+// NewTemplate returns a template of the columns of static, which must each
+// hold a single value, repeated in every record by Apply.
+func NewTemplate(static *Input) (*Template, error) {
+	if static == nil || len(static.columns) == 0 {
+		return nil, errors.New("template has no columns")
+	}
+	for _, name := range static.order {
+		values := static.columns[name]
+		if len(values) != 1 {
+			return nil, fmt.Errorf("template column %q has %d values, expected 1", name, len(values))
+		}
+		if values[0] == nil {
+			return nil, fmt.Errorf("template column %q has a missing value", name)
+		}
+	}
+	return &Template{static: static.Clone()}, nil
+}
+
+// Columns returns the names of the static columns, in the order they were
+// added.
+func (t *Template) Columns() []string {
+	return t.static.Order()
+}
+
+// Apply returns a new input holding the columns of in followed by the static
+// columns, repeated in each of its records. Columns of in take precedence over
+// static columns of the same name. The values of in are shared, not copied.
+// An empty input gets a single record of static columns.
+//
+// Template implements jams.Preprocessor.
+func (t *Template) Apply(in *Input) (*Input, error) {
+	records := 1
+	merged := &Input{columns: make(map[string][]any, len(t.static.columns))}
+	if in != nil && len(in.columns) > 0 {
+		records = in.Len()
+		merged.order = make([]string, 0, len(in.order)+len(t.static.order))
+		for _, name := range in.order {
+			merged.set(name, in.columns[name])
+		}
+	}
+	for _, name := range t.static.order {
+		if _, ok := merged.columns[name]; ok {
+			continue
+		}
+		value := t.static.columns[name][0]
+		column := make([]any, records)
+		for i := range column {
+			column[i] = value
+		}
+		merged.set(name, column)
+	}
+	return merged, nil
+}
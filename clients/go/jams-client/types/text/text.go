@@ -0,0 +1,262 @@
+// Package text turns raw strings into the integer token columns expected by
+// NLP models served by J.A.M.S.
+//
+//	tokenizer, err := text.LoadTokenizer("tokenizer.json")
+//	input, err := tokenizer.Input("great movie", "not for me")
+//	prediction, err := client.Predict(ctx, "tensorflow-sentiment", input)
+//
+// J.A.M.S inputs are columnar, so every token position becomes one feature
+// column holding one token id per text. Column names are zero padded, e.g.
+// "token_007", so that their sorted order matches the sequence order.
+package text
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Default spec values.
+const (
+	DefaultPadToken     = "[PAD]"
+	DefaultUnknownToken = "[UNK]"
+	DefaultPrefix       = "token"
+	DefaultMaskPrefix   = "mask"
+)
+
+// Spec describes how texts are tokenized.
+type Spec struct {
+	// Vocabulary is the path of the vocabulary file, one token per line with
+	// the line number as token id. Relative paths are resolved against the
+	// directory of the spec file by LoadTokenizer.
+	Vocabulary string `json:"vocabulary" yaml:"vocabulary"`
+	// Lowercase lowercases texts before tokenizing.
+	Lowercase bool `json:"lowercase,omitempty" yaml:"lowercase,omitempty"`
+	// Length is the sequence length. Shorter sequences are padded and longer
+	// ones truncated.
+	Length int `json:"length" yaml:"length"`
+	// TruncateLeft drops tokens from the start instead of the end of long
+	// sequences.
+	TruncateLeft bool `json:"truncate_left,omitempty" yaml:"truncate_left,omitempty"`
+	// PadToken and UnknownToken must be in the vocabulary. They default to
+	// DefaultPadToken and DefaultUnknownToken.
+	PadToken     string `json:"pad_token,omitempty" yaml:"pad_token,omitempty"`
+	UnknownToken string `json:"unknown_token,omitempty" yaml:"unknown_token,omitempty"`
+	// Prefix is the token column name prefix, DefaultPrefix when empty.
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	// AttentionMask also emits one mask column per position, set to 1 for
+	// tokens and 0 for padding.
+	AttentionMask bool `json:"attention_mask,omitempty" yaml:"attention_mask,omitempty"`
+	// MaskPrefix is the mask column name prefix, DefaultMaskPrefix when
+	// empty.
+	MaskPrefix string `json:"mask_prefix,omitempty" yaml:"mask_prefix,omitempty"`
+}
+
+// Tokenizer applies a Spec to raw strings. It is safe for concurrent use.
+type Tokenizer struct {
+	spec    Spec
+	vocab   map[string]int
+	pad     int
+	unknown int
+}
+
+// LoadTokenizer reads a JSON encoded Spec from a file and loads its
+// vocabulary.
+func LoadTokenizer(path string) (*Tokenizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenizer spec: %w", err)
+	}
+	if spec.Vocabulary != "" && !filepath.IsAbs(spec.Vocabulary) {
+		spec.Vocabulary = filepath.Join(filepath.Dir(path), spec.Vocabulary)
+	}
+	return NewTokenizer(spec)
+}
+
+// NewTokenizer loads the vocabulary file named by the spec.
+func NewTokenizer(spec Spec) (*Tokenizer, error) {
+	if spec.Vocabulary == "" {
+		return nil, errors.New("a vocabulary file is required")
+	}
+	f, err := os.Open(spec.Vocabulary)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	vocab, err := ReadVocabulary(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewTokenizerWithVocabulary(spec, vocab)
+}
+
+// NewTokenizerWithVocabulary returns a Tokenizer using the given vocabulary,
+// where the index of each token is its id. Spec.Vocabulary is ignored.
+func NewTokenizerWithVocabulary(spec Spec, vocab []string) (*Tokenizer, error) {
+	if spec.Length <= 0 {
+		return nil, errors.New("length must be greater than zero")
+	}
+	if spec.PadToken == "" {
+		spec.PadToken = DefaultPadToken
+	}
+	if spec.UnknownToken == "" {
+		spec.UnknownToken = DefaultUnknownToken
+	}
+	if spec.Prefix == "" {
+		spec.Prefix = DefaultPrefix
+	}
+	if spec.MaskPrefix == "" {
+		spec.MaskPrefix = DefaultMaskPrefix
+	}
+
+	t := &Tokenizer{spec: spec, vocab: make(map[string]int, len(vocab))}
+	for id, token := range vocab {
+		if _, ok := t.vocab[token]; !ok {
+			t.vocab[token] = id
+		}
+	}
+	var ok bool
+	if t.pad, ok = t.vocab[spec.PadToken]; !ok {
+		return nil, fmt.Errorf("pad token %q is not in the vocabulary", spec.PadToken)
+	}
+	if t.unknown, ok = t.vocab[spec.UnknownToken]; !ok {
+		return nil, fmt.Errorf("unknown token %q is not in the vocabulary", spec.UnknownToken)
+	}
+	return t, nil
+}
+
+// ReadVocabulary reads one token per line. Trailing whitespace is ignored.
+func ReadVocabulary(r io.Reader) ([]string, error) {
+	var vocab []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		vocab = append(vocab, strings.TrimRightFunc(scanner.Text(), unicode.IsSpace))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+	return vocab, nil
+}
+
+// Tokenize splits text into tokens on whitespace, keeping each punctuation
+// character as a token of its own.
+func (t *Tokenizer) Tokenize(text string) []string {
+	if t.spec.Lowercase {
+		text = strings.ToLower(text)
+	}
+	var (
+		tokens []string
+		word   strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Encode tokenizes text and returns Spec.Length token ids, truncated or
+// padded as needed, along with the number of real tokens.
+func (t *Tokenizer) Encode(text string) ([]int, int) {
+	tokens := t.Tokenize(text)
+	if len(tokens) > t.spec.Length {
+		if t.spec.TruncateLeft {
+			tokens = tokens[len(tokens)-t.spec.Length:]
+		} else {
+			tokens = tokens[:t.spec.Length]
+		}
+	}
+	ids := make([]int, t.spec.Length)
+	for i := range ids {
+		if i >= len(tokens) {
+			ids[i] = t.pad
+			continue
+		}
+		id, ok := t.vocab[tokens[i]]
+		if !ok {
+			id = t.unknown
+		}
+		ids[i] = id
+	}
+	return ids, len(tokens)
+}
+
+// Columns returns the token column names in sequence order, followed by the
+// mask column names when Spec.AttentionMask is set.
+func (t *Tokenizer) Columns() []string {
+	columns := positions(t.spec.Prefix, t.spec.Length)
+	if t.spec.AttentionMask {
+		columns = append(columns, positions(t.spec.MaskPrefix, t.spec.Length)...)
+	}
+	return columns
+}
+
+// Input tokenizes the texts and returns a model input with one record per
+// text.
+func (t *Tokenizer) Input(texts ...string) (*types.Input, error) {
+	if len(texts) == 0 {
+		return nil, errors.New("at least one text is required")
+	}
+	tokens := make([][]int, t.spec.Length)
+	masks := make([][]int, t.spec.Length)
+	for i := range tokens {
+		tokens[i] = make([]int, len(texts))
+		masks[i] = make([]int, len(texts))
+	}
+	for n, text := range texts {
+		ids, count := t.Encode(text)
+		for i, id := range ids {
+			tokens[i][n] = id
+			if i < count {
+				masks[i][n] = 1
+			}
+		}
+	}
+
+	input := types.NewInput()
+	for i, name := range positions(t.spec.Prefix, t.spec.Length) {
+		input.AddInts(name, tokens[i]...)
+	}
+	if t.spec.AttentionMask {
+		for i, name := range positions(t.spec.MaskPrefix, t.spec.Length) {
+			input.AddInts(name, masks[i]...)
+		}
+	}
+	return input, nil
+}
+
+// positions returns zero padded column names for n sequence positions.
+func positions(prefix string, n int) []string {
+	digits := len(fmt.Sprint(n - 1))
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s_%0*d", prefix, digits, i)
+	}
+	return names
+}
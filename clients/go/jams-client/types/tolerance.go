@@ -0,0 +1,81 @@
+package types
+
+import "math"
+
+// Tolerance is how far predicted values may be from the expected ones, e.g.
+// to compare the predictions of servers on different architectures, x86 and
+// ARM, whose floating point results differ in their last bits. A value
+// matches when it is within Abs plus Rel times the magnitude of the expected
+// value, or within ULPs units in the last place of it. The zero Tolerance
+// only matches equal values.
+type Tolerance struct {
+	Abs float64 `json:"abs,omitempty" yaml:"abs,omitempty"`
+	Rel float64 `json:"rel,omitempty" yaml:"rel,omitempty"`
+	// ULPs is the number of representable values allowed between the
+	// expected and actual values, which scales with their magnitude.
+	ULPs uint64 `json:"ulps,omitempty" yaml:"ulps,omitempty"`
+	// Float32 counts ULPs in single precision, for models computing in
+	// float32, e.g. TensorFlow and PyTorch models, whose outputs are widened
+	// to float64 by the server.
+	Float32 bool `json:"float32,omitempty" yaml:"float32,omitempty"`
+}
+
+// Equal reports whether actual matches expected. NaN only matches NaN and
+// infinities only match themselves.
+func (t Tolerance) Equal(expected, actual float64) bool {
+	if math.IsNaN(expected) || math.IsNaN(actual) {
+		return math.IsNaN(expected) && math.IsNaN(actual)
+	}
+	if expected == actual {
+		return true
+	}
+	if math.IsInf(expected, 0) || math.IsInf(actual, 0) {
+		return false
+	}
+	if math.Abs(expected-actual) <= t.Abs+t.Rel*math.Abs(expected) {
+		return true
+	}
+	if t.ULPs == 0 {
+		return false
+	}
+	if t.Float32 {
+		return ULPDistance32(float32(expected), float32(actual)) <= t.ULPs
+	}
+	return ULPDistance(expected, actual) <= t.ULPs
+}
+
+// ULPDistance returns the number of float64 values between a and b, 0 for
+// equal values, including 0 and -0, and the largest distance when either is
+// NaN.
+func ULPDistance(a, b float64) uint64 {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.MaxUint64
+	}
+	return distance(ordered(math.Float64bits(a), 64), ordered(math.Float64bits(b), 64))
+}
+
+// ULPDistance32 is ULPDistance for float32 values.
+func ULPDistance32(a, b float32) uint64 {
+	if a != a || b != b {
+		return math.MaxUint64
+	}
+	return distance(ordered(uint64(math.Float32bits(a)), 32), ordered(uint64(math.Float32bits(b)), 32))
+}
+
+// ordered maps the bits of a float of the given size to an integer of the
+// same order as the float, so that adjacent floats map to adjacent integers
+// and 0 and -0 to the same one.
+func ordered(bits uint64, size uint) int64 {
+	sign := uint64(1) << (size - 1)
+	if bits&sign != 0 {
+		return -int64(bits &^ sign)
+	}
+	return int64(bits)
+}
+
+func distance(a, b int64) uint64 {
+	if a > b {
+		return uint64(a) - uint64(b)
+	}
+	return uint64(b) - uint64(a)
+}
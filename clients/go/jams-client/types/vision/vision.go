@@ -0,0 +1,225 @@
+// Package vision turns images into the flattened numeric input columns expected
+// by image models, e.g. TensorFlow or Torch CNNs, served by J.A.M.S.
+//
+//	spec := vision.Spec{Width: 224, Height: 224, Mean: []float64{0.485, 0.456, 0.406}, Std: []float64{0.229, 0.224, 0.225}}
+//	img, err := vision.Load("cat.jpg")
+//	input, err := spec.Input(img)
+//	prediction, err := client.Predict(ctx, "resnet", input)
+//
+// J.A.M.S inputs are columnar, so every pixel channel becomes one feature
+// column holding one value per image. Column names are zero padded, e.g.
+// "pixel_00042", so that their sorted order matches the tensor layout.
+package vision
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register the JPEG decoder
+	_ "image/png"  // register the PNG decoder
+	"io"
+	"os"
+
+	"golang.org/x/image/draw"
+
+	"github.com/gagansingh894/jams-rs/clients/go/jams-client/types"
+)
+
+// Layout is the order in which pixel values are flattened.
+type Layout string
+
+// Supported layouts.
+const (
+	// LayoutHWC stores the channels of each pixel together, as TensorFlow
+	// models usually expect.
+	LayoutHWC Layout = "hwc"
+	// LayoutCHW stores each channel as a separate plane, as Torch models
+	// usually expect.
+	LayoutCHW Layout = "chw"
+)
+
+// DefaultPrefix is the column name prefix used when Spec.Prefix is empty.
+const DefaultPrefix = "pixel"
+
+// Spec describes how images are preprocessed. Pixel values start in the range
+// 0-255, are multiplied by Scale and then normalised per channel with
+// (value - Mean[c]) / Std[c].
+type Spec struct {
+	// Width and Height are the size images are resized to.
+	Width  int `json:"width" yaml:"width"`
+	Height int `json:"height" yaml:"height"`
+	// Grayscale converts images to a single luminance channel instead of
+	// RGB.
+	Grayscale bool `json:"grayscale,omitempty" yaml:"grayscale,omitempty"`
+	// Layout defaults to LayoutHWC.
+	Layout Layout `json:"layout,omitempty" yaml:"layout,omitempty"`
+	// Scale defaults to 1/255, mapping pixel values to 0-1.
+	Scale float64 `json:"scale,omitempty" yaml:"scale,omitempty"`
+	// Mean and Std hold either one value per channel or none to skip
+	// normalisation.
+	Mean []float64 `json:"mean,omitempty" yaml:"mean,omitempty"`
+	Std  []float64 `json:"std,omitempty" yaml:"std,omitempty"`
+	// Prefix is the feature column name prefix, DefaultPrefix when empty.
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+}
+
+// ParseSpec parses a JSON encoded Spec.
+func ParseSpec(data []byte) (Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return Spec{}, fmt.Errorf("failed to parse vision spec: %w", err)
+	}
+	return spec, spec.Validate()
+}
+
+// LoadSpec reads a JSON encoded Spec from a file.
+func LoadSpec(path string) (Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+	return ParseSpec(data)
+}
+
+// Validate checks that the spec is usable.
+func (s Spec) Validate() error {
+	if s.Width <= 0 || s.Height <= 0 {
+		return errors.New("width and height must be greater than zero")
+	}
+	switch s.Layout {
+	case "", LayoutHWC, LayoutCHW:
+	default:
+		return fmt.Errorf("unknown layout %q", s.Layout)
+	}
+	channels := s.Channels()
+	if len(s.Mean) != 0 && len(s.Mean) != channels {
+		return fmt.Errorf("mean has %d values, expected %d", len(s.Mean), channels)
+	}
+	if len(s.Std) != 0 && len(s.Std) != channels {
+		return fmt.Errorf("std has %d values, expected %d", len(s.Std), channels)
+	}
+	for _, std := range s.Std {
+		if std == 0 {
+			return errors.New("std values must not be zero")
+		}
+	}
+	return nil
+}
+
+// Channels returns the number of channels per pixel.
+func (s Spec) Channels() int {
+	if s.Grayscale {
+		return 1
+	}
+	return 3
+}
+
+// Size returns the number of values, and so feature columns, per image.
+func (s Spec) Size() int {
+	return s.Width * s.Height * s.Channels()
+}
+
+// Columns returns the feature column names in tensor order.
+func (s Spec) Columns() []string {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	size := s.Size()
+	digits := len(fmt.Sprint(size - 1))
+	columns := make([]string, size)
+	for i := range columns {
+		columns[i] = fmt.Sprintf("%s_%0*d", prefix, digits, i)
+	}
+	return columns
+}
+
+// Decode decodes a JPEG or PNG image.
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// Load decodes a JPEG or PNG image from a file.
+func Load(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// Tensor resizes and normalises img and returns its flattened values.
+func (s Spec) Tensor(img image.Image) ([]float64, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	resized := image.NewRGBA(image.Rect(0, 0, s.Width, s.Height))
+	draw.BiLinear.Scale(resized, resized.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	scale := s.Scale
+	if scale == 0 {
+		scale = 1.0 / 255
+	}
+	channels := s.Channels()
+	values := make([]float64, s.Size())
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			offset := resized.PixOffset(x, y)
+			r, g, b := float64(resized.Pix[offset]), float64(resized.Pix[offset+1]), float64(resized.Pix[offset+2])
+			pixel := []float64{r, g, b}
+			if s.Grayscale {
+				pixel = []float64{0.299*r + 0.587*g + 0.114*b}
+			}
+			for c, v := range pixel {
+				v *= scale
+				if len(s.Mean) > 0 {
+					v -= s.Mean[c]
+				}
+				if len(s.Std) > 0 {
+					v /= s.Std[c]
+				}
+				var i int
+				if s.Layout == LayoutCHW {
+					i = c*s.Width*s.Height + y*s.Width + x
+				} else {
+					i = (y*s.Width+x)*channels + c
+				}
+				values[i] = v
+			}
+		}
+	}
+	return values, nil
+}
+
+// Input preprocesses the images and returns a model input with one record per
+// image.
+func (s Spec) Input(images ...image.Image) (*types.Input, error) {
+	if len(images) == 0 {
+		return nil, errors.New("at least one image is required")
+	}
+	columns := make([][]float64, s.Size())
+	for i := range columns {
+		columns[i] = make([]float64, len(images))
+	}
+	for n, img := range images {
+		values, err := s.Tensor(img)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			columns[i][n] = v
+		}
+	}
+
+	input := types.NewInput()
+	for i, name := range s.Columns() {
+		input.AddFloats(name, columns[i]...)
+	}
+	return input, nil
+}
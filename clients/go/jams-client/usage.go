@@ -0,0 +1,174 @@
+package jams_client
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Usage describes the payloads of a single call, for chargeback or showback
+// of a shared model server.
+type Usage struct {
+	Method string
+	Model  string
+	// Team is the label set on the call context with WithTeam.
+	Team string
+	// Metadata is the metadata of the call context, see WithCallMetadata.
+	Metadata map[string]string
+	// Rows is the number of records sent, for Predict calls.
+	Rows int
+	// RequestBytes and ResponseBytes are the uncompressed payload sizes.
+	RequestBytes  int64
+	ResponseBytes int64
+	// RequestWireBytes and ResponseWireBytes are the sizes sent over the
+	// network after compression.
+	RequestWireBytes  int64
+	ResponseWireBytes int64
+}
+
+// WithUsageHook calls hook after every call with the sizes of its payloads.
+// Calls retried by WithRetry count the payloads of every attempt. hook is
+// called synchronously and must not block.
+func WithUsageHook(hook func(Usage)) Option {
+	return func(o *options) {
+		o.usageHook = hook
+	}
+}
+
+type teamKey struct{}
+
+// WithTeam returns a context whose calls are attributed to team in Usage and
+// quotas.
+func WithTeam(ctx context.Context, team string) context.Context {
+	return context.WithValue(ctx, teamKey{}, team)
+}
+
+type rowsKey struct{}
+
+// withRows records the number of records sent by the call made with ctx.
+func withRows(ctx context.Context, rows int) context.Context {
+	return context.WithValue(ctx, rowsKey{}, rows)
+}
+
+// usageCollector gathers the payload sizes of a single call. Transports find
+// it in the call context.
+type usageCollector struct {
+	requestBytes      atomic.Int64
+	responseBytes     atomic.Int64
+	requestWireBytes  atomic.Int64
+	responseWireBytes atomic.Int64
+}
+
+type usageCollectorKey struct{}
+
+func usageFrom(ctx context.Context) *usageCollector {
+	collector, _ := ctx.Value(usageCollectorKey{}).(*usageCollector)
+	return collector
+}
+
+// withUsage returns a context collecting the payload sizes of a call, or ctx
+// itself when no usage hook is set.
+func (c *Client) withUsage(ctx context.Context) (context.Context, *usageCollector) {
+	if c.opts.usageHook == nil {
+		return ctx, nil
+	}
+	collector := &usageCollector{}
+	return context.WithValue(ctx, usageCollectorKey{}, collector), collector
+}
+
+func (c *Client) reportUsage(ctx context.Context, method, model string, collector *usageCollector) {
+	if collector == nil {
+		return
+	}
+	team, _ := ctx.Value(teamKey{}).(string)
+	rows, _ := ctx.Value(rowsKey{}).(int)
+	c.opts.usageHook(Usage{
+		Method:            method,
+		Model:             model,
+		Team:              team,
+		Metadata:          CallMetadata(ctx),
+		Rows:              rows,
+		RequestBytes:      collector.requestBytes.Load(),
+		ResponseBytes:     collector.responseBytes.Load(),
+		RequestWireBytes:  collector.requestWireBytes.Load(),
+		ResponseWireBytes: collector.responseWireBytes.Load(),
+	})
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
+
+// UsageTotals aggregates Usage per model and team. Its Record method can be
+// passed to WithUsageHook. It is safe for concurrent use.
+type UsageTotals struct {
+	mu     sync.Mutex
+	totals map[usageKey]*UsageTotal
+}
+
+// UsageTotal is the aggregated usage of a model and team.
+type UsageTotal struct {
+	Model             string `json:"model"`
+	Team              string `json:"team,omitempty"`
+	Calls             int64  `json:"calls"`
+	Rows              int64  `json:"rows"`
+	RequestBytes      int64  `json:"request_bytes"`
+	ResponseBytes     int64  `json:"response_bytes"`
+	RequestWireBytes  int64  `json:"request_wire_bytes"`
+	ResponseWireBytes int64  `json:"response_wire_bytes"`
+}
+
+type usageKey struct {
+	model string
+	team  string
+}
+
+// Record adds u to the totals.
+func (t *UsageTotals) Record(u Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.totals == nil {
+		t.totals = make(map[usageKey]*UsageTotal)
+	}
+	key := usageKey{model: u.Model, team: u.Team}
+	total, ok := t.totals[key]
+	if !ok {
+		total = &UsageTotal{Model: u.Model, Team: u.Team}
+		t.totals[key] = total
+	}
+	total.Calls++
+	total.Rows += int64(u.Rows)
+	total.RequestBytes += u.RequestBytes
+	total.ResponseBytes += u.ResponseBytes
+	total.RequestWireBytes += u.RequestWireBytes
+	total.ResponseWireBytes += u.ResponseWireBytes
+}
+
+// Snapshot returns the totals sorted by model then team.
+func (t *UsageTotals) Snapshot() []UsageTotal {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totals := make([]UsageTotal, 0, len(t.totals))
+	for _, total := range t.totals {
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Model != totals[j].Model {
+			return totals[i].Model < totals[j].Model
+		}
+		return totals[i].Team < totals[j].Team
+	})
+	return totals
+}
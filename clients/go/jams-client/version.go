@@ -0,0 +1,86 @@
+package jams_client
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+)
+
+// Version is the version of this client.
+const Version = "0.1.0"
+
+// clientHeader carries the language and version of the client, e.g.
+// "go/0.1.0", as an HTTP header or gRPC metadata key.
+const clientHeader = "x-jams-client"
+
+// userAgent is the User-Agent sent with every call, e.g.
+// "jams-go-client/0.1.0 (go1.22.4; linux/amd64)".
+var userAgent = fmt.Sprintf("jams-go-client/%s (%s; %s/%s)", Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+// Feature is a feature a client may support.
+type Feature string
+
+// Features.
+const (
+	FeatureRetry          Feature = "retry"
+	FeatureBearerToken    Feature = "bearer_token"
+	FeatureCache          Feature = "cache"
+	FeatureQuota          Feature = "quota"
+	FeaturePreprocessing  Feature = "preprocessing"
+	FeaturePostprocessing Feature = "postprocessing"
+	FeaturePredictionSink Feature = "prediction_sink"
+	FeatureUsage          Feature = "usage"
+	FeatureServerWarnings Feature = "server_warnings"
+	FeatureDebugDump      Feature = "debug_dump"
+	// FeatureGzip is the decoding of gzip compressed responses, supported
+	// by the HTTP transport.
+	FeatureGzip Feature = "gzip"
+	// FeatureCompression is the compression of requests with the codecs of
+	// WithCompression, supported by the HTTP transport.
+	FeatureCompression Feature = "compression"
+)
+
+// Capabilities describes what a client supports.
+type Capabilities struct {
+	Version string
+	// UserAgent is the User-Agent sent with every call.
+	UserAgent string
+	// Transport is the transport of the client, "http" or "grpc".
+	Transport string
+	// Transports are the transports of the build: "http", and "grpc" unless
+	// built with the jams_nogrpc tag or for js/wasm.
+	Transports []string
+	Features   []Feature
+}
+
+// Supports reports whether f is one of the features.
+func (c Capabilities) Supports(f Feature) bool {
+	return slices.Contains(c.Features, f)
+}
+
+// Capabilities reports the version of the client, the transports of the build
+// and the features of the client and its transport.
+func (c *Client) Capabilities() Capabilities {
+	transports := []string{"http"}
+	if grpcBuilt {
+		transports = append(transports, "grpc")
+	}
+	return Capabilities{
+		Version:    Version,
+		UserAgent:  c.opts.appIdentity.userAgent(),
+		Transport:  c.transport.name(),
+		Transports: transports,
+		Features: append([]Feature{
+			FeatureRetry,
+			FeatureBearerToken,
+			FeatureCache,
+			FeatureQuota,
+			FeaturePreprocessing,
+			FeaturePostprocessing,
+			FeaturePredictionSink,
+			FeatureUsage,
+			FeatureServerWarnings,
+			FeatureDebugDump,
+		}, c.transport.features()...),
+	}
+}
@@ -0,0 +1,46 @@
+package jams_client
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	client, err := NewHTTPClient("http://localhost:3000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	capabilities := client.Capabilities()
+	if capabilities.Transport != "http" || !capabilities.Supports(FeatureGzip) || !capabilities.Supports(FeatureCompression) {
+		t.Fatalf("HTTP client has capabilities %+v", capabilities)
+	}
+
+	grpcClient, err := NewGRPCClient("localhost:4000")
+	switch {
+	case !grpcBuilt:
+		if !errors.Is(err, ErrNoGRPC) {
+			t.Fatalf("got error %v without gRPC, want ErrNoGRPC", err)
+		}
+	case err != nil:
+		t.Fatal(err)
+	default:
+		defer grpcClient.Close()
+		if capabilities := grpcClient.Capabilities(); capabilities.Transport != "grpc" || capabilities.Supports(FeatureGzip) {
+			t.Fatalf("gRPC client has capabilities %+v", capabilities)
+		}
+	}
+
+	capabilities = newTestClient(&fakeTransport{}).Capabilities()
+	if capabilities.Transport != "fake" || capabilities.Supports(FeatureGzip) || !capabilities.Supports(FeatureRetry) {
+		t.Fatalf("client of a fake transport has capabilities %+v", capabilities)
+	}
+	want := []string{"http"}
+	if grpcBuilt {
+		want = append(want, "grpc")
+	}
+	if !slices.Equal(capabilities.Transports, want) {
+		t.Fatalf("build has transports %v, want %v", capabilities.Transports, want)
+	}
+}
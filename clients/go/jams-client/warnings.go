@@ -0,0 +1,175 @@
+package jams_client
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Warning is a deprecation notice or warning sent by the server with a
+// response, through the Deprecation, Sunset and Warning HTTP headers or the
+// gRPC metadata keys of the same name.
+type Warning struct {
+	Method string
+	Model  string
+	// Deprecated is set when the server flagged the call as deprecated.
+	Deprecated bool
+	// Sunset is when the model or API is scheduled for removal, if known.
+	Sunset time.Time
+	// Messages are the texts of the Warning headers.
+	Messages []string
+}
+
+// WithWarningHandler calls handler for every call whose response carried a
+// warning. handler is called synchronously and must not block.
+func WithWarningHandler(handler func(Warning)) Option {
+	return func(o *options) {
+		o.warningHandler = handler
+	}
+}
+
+// WithLogger sets the logger the client reports server warnings to. Each
+// distinct warning is logged once per client at warn level, and again only
+// once maxLoggedWarnings other warnings were logged since.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// warningCollector gathers the warnings of a single call across its
+// attempts. Transports find it in the call context.
+type warningCollector struct {
+	mu      sync.Mutex
+	warning Warning
+	found   bool
+}
+
+type warningCollectorKey struct{}
+
+// collectWarnings parses the warning headers returned by get, which returns
+// the values of a header or metadata key, into the collector of ctx.
+func collectWarnings(ctx context.Context, get func(key string) []string) {
+	collector, ok := ctx.Value(warningCollectorKey{}).(*warningCollector)
+	if !ok {
+		return
+	}
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	if values := get("Deprecation"); len(values) > 0 && values[0] != "false" {
+		collector.warning.Deprecated = true
+		collector.found = true
+	}
+	for _, value := range get("Sunset") {
+		if sunset, err := http.ParseTime(value); err == nil {
+			collector.warning.Sunset = sunset
+			collector.found = true
+		}
+	}
+	for _, value := range get("Warning") {
+		message := warningText(value)
+		if message == "" || contains(collector.warning.Messages, message) {
+			continue
+		}
+		collector.warning.Messages = append(collector.warning.Messages, message)
+		collector.found = true
+	}
+}
+
+// warningText returns the quoted text of a RFC 7234 warning value, e.g.
+// `299 - "model scheduled for removal"`, or the value itself.
+func warningText(value string) string {
+	if start := strings.IndexByte(value, '"'); start >= 0 {
+		if end := strings.IndexByte(value[start+1:], '"'); end >= 0 {
+			return value[start+1 : start+1+end]
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// withWarnings returns a context collecting the warnings of a call, or ctx
+// itself when nobody is interested in warnings.
+func (c *Client) withWarnings(ctx context.Context) (context.Context, *warningCollector) {
+	if c.opts.warningHandler == nil && c.opts.logger == nil {
+		return ctx, nil
+	}
+	collector := &warningCollector{}
+	return context.WithValue(ctx, warningCollectorKey{}, collector), collector
+}
+
+// reportWarnings hands the warnings gathered by collector to the handler and
+// logger.
+func (c *Client) reportWarnings(method, model string, collector *warningCollector) {
+	if collector == nil || !collector.found {
+		return
+	}
+	w := collector.warning
+	w.Method, w.Model = method, model
+	if c.opts.warningHandler != nil {
+		c.opts.warningHandler(w)
+	}
+	if c.opts.logger == nil {
+		return
+	}
+	key := strings.Join(append([]string{method, model, w.Sunset.String()}, w.Messages...), "\x00")
+	if !c.loggedWarnings.add(key) {
+		return
+	}
+	attrs := []any{slog.String("method", method), slog.String("model", model), slog.Bool("deprecated", w.Deprecated)}
+	if !w.Sunset.IsZero() {
+		attrs = append(attrs, slog.Time("sunset", w.Sunset))
+	}
+	if len(w.Messages) > 0 {
+		attrs = append(attrs, slog.Any("messages", w.Messages))
+	}
+	c.opts.logger.Warn("jams server warning", attrs...)
+}
+
+// maxLoggedWarnings bounds the warnings remembered as logged, since their
+// texts come from the server.
+const maxLoggedWarnings = 1024
+
+// warningSet is a least recently used set of the warnings already logged. The
+// zero value is an empty set.
+type warningSet struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// add adds key to the set, reporting whether it was missing. The least
+// recently added or seen key is forgotten beyond maxLoggedWarnings keys.
+func (s *warningSet) add(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries == nil {
+		s.entries = make(map[string]*list.Element)
+		s.order = list.New()
+	}
+	if elem, ok := s.entries[key]; ok {
+		s.order.MoveToFront(elem)
+		return false
+	}
+	s.entries[key] = s.order.PushFront(key)
+	if s.order.Len() > maxLoggedWarnings {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(string))
+	}
+	return true
+}
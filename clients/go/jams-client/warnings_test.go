@@ -0,0 +1,64 @@
+package jams_client
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWarningSet(t *testing.T) {
+	var s warningSet
+	for i := 0; i < maxLoggedWarnings; i++ {
+		if !s.add(fmt.Sprint(i)) {
+			t.Fatalf("add(%d) reported a known warning", i)
+		}
+	}
+	// seeing the oldest warning again keeps it over the next oldest.
+	if s.add("0") {
+		t.Fatalf("add(0) reported a new warning")
+	}
+	if !s.add("new") {
+		t.Fatalf("add(new) reported a known warning")
+	}
+	if s.order.Len() != maxLoggedWarnings || len(s.entries) != maxLoggedWarnings {
+		t.Fatalf("set holds %d warnings, want %d", s.order.Len(), maxLoggedWarnings)
+	}
+	if s.add("0") || s.add("new") {
+		t.Fatalf("recent warnings were forgotten")
+	}
+	if !s.add("1") {
+		t.Fatalf("the least recent warning was kept")
+	}
+}
+
+func TestReportWarningsLogsOnce(t *testing.T) {
+	var logs bytes.Buffer
+	c := newTestClient(&fakeTransport{}, WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	report := func(message string) {
+		c.reportWarnings(MethodPredict, "m", &warningCollector{
+			warning: Warning{Messages: []string{message}},
+			found:   true,
+		})
+	}
+
+	report("model scheduled for removal")
+	report("model scheduled for removal")
+	if n := strings.Count(logs.String(), "\n"); n != 1 {
+		t.Fatalf("logged %d lines, want 1:\n%s", n, logs.String())
+	}
+
+	// distinct warnings are remembered up to maxLoggedWarnings.
+	for i := 0; i < 2*maxLoggedWarnings; i++ {
+		report(fmt.Sprint("request ", i, " is deprecated"))
+	}
+	if n := c.loggedWarnings.order.Len(); n != maxLoggedWarnings {
+		t.Fatalf("remembered %d warnings, want %d", n, maxLoggedWarnings)
+	}
+	logs.Reset()
+	report("model scheduled for removal")
+	if n := strings.Count(logs.String(), "\n"); n != 1 {
+		t.Fatalf("logged a forgotten warning %d times, want 1", n)
+	}
+}
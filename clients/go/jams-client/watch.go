@@ -0,0 +1,160 @@
+package jams_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ModelEventType is the kind of change reported by WatchModels.
+type ModelEventType string
+
+// Model event types.
+const (
+	ModelAdded   ModelEventType = "added"
+	ModelUpdated ModelEventType = "updated"
+	ModelRemoved ModelEventType = "removed"
+)
+
+// ModelEvent is a change to the model catalog of the server.
+type ModelEvent struct {
+	Type  ModelEventType `json:"type"`
+	Model ModelMetadata  `json:"model"`
+	// Time is when the change was observed.
+	Time time.Time `json:"time"`
+	// Initial is set for the events reporting the models loaded when the watch
+	// started.
+	Initial bool `json:"initial,omitempty"`
+}
+
+// ModelWatcher reports changes to the model catalog of the server.
+type ModelWatcher struct {
+	// Events receives model events. It is closed when the watch stops.
+	Events <-chan ModelEvent
+	// Errors receives errors from polling the server. Errors are dropped
+	// when nobody is receiving. It is closed when the watch stops.
+	Errors <-chan error
+}
+
+// WatchModels polls the server every interval and reports changes to the model
+// catalog until ctx is done. The models loaded when the watch starts are
+// reported as ModelAdded events. A model is reported as updated when its
+// framework, path or update timestamp changes. After a failed poll the
+// next one waits according to the client's Backoff, but never less than
+// interval.
+func (c *Client) WatchModels(ctx context.Context, interval time.Duration) *ModelWatcher {
+	events := make(chan ModelEvent)
+	errs := make(chan error, 1)
+	go c.watchModels(ctx, interval, events, errs)
+	return &ModelWatcher{Events: events, Errors: errs}
+}
+
+func (c *Client) watchModels(ctx context.Context, interval time.Duration, events chan<- ModelEvent, errs chan<- error) {
+	defer close(events)
+	defer close(errs)
+
+	var (
+		known    map[string]ModelMetadata
+		failures int
+		wait     time.Duration
+	)
+	for {
+		models, err := c.GetModels(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case errs <- err:
+			default:
+			}
+			// back off from a failing server, but never poll faster than asked.
+			failures++
+			wait = c.opts.backoff.Delay(failures, wait)
+			if wait < interval {
+				wait = interval
+			}
+		} else {
+			failures, wait = 0, interval
+			current := make(map[string]ModelMetadata, len(models))
+			for _, m := range models {
+				current[m.Name] = m
+			}
+			for _, event := range diffModels(known, current, time.Now()) {
+				event.Initial = known == nil
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			known = current
+		}
+
+		if !sleep(ctx, wait) {
+			return
+		}
+	}
+}
+
+// WaitForModel polls GetModels until the named model is listed, the server
+// listing loaded models only, or ctx is done, e.g. right after AddModel on a
+// server whose replicas load models independently. Polls wait according to
+// the client's Backoff. It stops at the first error which is not retryable,
+// e.g. a rejected token, and otherwise returns the error of ctx joined with
+// that of the last failed poll, if the last poll failed.
+func (c *Client) WaitForModel(ctx context.Context, modelName string) error {
+	var (
+		delay   time.Duration
+		lastErr error
+	)
+	for attempt := 1; ; attempt++ {
+		models, err := c.GetModels(ctx)
+		switch {
+		case err == nil:
+			for _, m := range models {
+				if m.Name == modelName {
+					return nil
+				}
+			}
+			lastErr = nil
+		case !answered(err):
+			// cut short by ctx, which the last failure explains better
+		default:
+			if retry, _ := c.transport.retryable(err); !retry && !errors.Is(err, ErrAttemptTimeout) {
+				return fmt.Errorf("failed to list the models: %w", err)
+			}
+			lastErr = err
+		}
+		delay = c.opts.backoff.Delay(attempt, delay)
+		if !sleep(ctx, delay) {
+			return errors.Join(ctx.Err(), lastErr)
+		}
+	}
+}
+
+// diffModels returns the events turning previous into current, sorted by model
+// name.
+func diffModels(previous, current map[string]ModelMetadata, now time.Time) []ModelEvent {
+	var events []ModelEvent
+	for name, m := range current {
+		old, ok := previous[name]
+		switch {
+		case !ok:
+			events = append(events, ModelEvent{Type: ModelAdded, Model: m, Time: now})
+		case old != m:
+			events = append(events, ModelEvent{Type: ModelUpdated, Model: m, Time: now})
+		}
+	}
+	for name, m := range previous {
+		if _, ok := current[name]; !ok {
+			events = append(events, ModelEvent{Type: ModelRemoved, Model: m, Time: now})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Model.Name < events[j].Model.Name
+	})
+	return events
+}
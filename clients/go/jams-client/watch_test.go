@@ -0,0 +1,96 @@
+package jams_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForModel(t *testing.T) {
+	tests := []struct {
+		name string
+		// respond answers the nth poll, from 1, with a status or the models
+		respond    func(n int64) (int, []ModelMetadata)
+		wantStatus int
+		wantCtxErr bool
+		wantPolls  int64
+	}{
+		{
+			name: "listed after polls",
+			respond: func(n int64) (int, []ModelMetadata) {
+				if n < 3 {
+					return http.StatusOK, []ModelMetadata{{Name: "other"}}
+				}
+				return http.StatusOK, []ModelMetadata{{Name: "other"}, {Name: "m"}}
+			},
+			wantPolls: 3,
+		},
+		{
+			name: "transient failures",
+			respond: func(n int64) (int, []ModelMetadata) {
+				if n < 3 {
+					return http.StatusServiceUnavailable, nil
+				}
+				return http.StatusOK, []ModelMetadata{{Name: "m"}}
+			},
+			wantPolls: 3,
+		},
+		{
+			name:       "unauthorized",
+			respond:    func(int64) (int, []ModelMetadata) { return http.StatusUnauthorized, nil },
+			wantStatus: http.StatusUnauthorized,
+			wantPolls:  1,
+		},
+		{
+			name:       "persistent failure",
+			respond:    func(int64) (int, []ModelMetadata) { return http.StatusServiceUnavailable, nil },
+			wantStatus: http.StatusServiceUnavailable,
+			wantCtxErr: true,
+		},
+		{
+			name:       "never listed",
+			respond:    func(int64) (int, []ModelMetadata) { return http.StatusOK, nil },
+			wantCtxErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var polls atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status, models := tt.respond(polls.Add(1))
+				w.WriteHeader(status)
+				json.NewEncoder(w).Encode(getModelsResponse{Total: len(models), Models: models})
+			}))
+			defer server.Close()
+			client, err := NewHTTPClient(server.URL, WithBackoff(ConstantBackoff{Interval: 5 * time.Millisecond}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err = client.WaitForModel(ctx, "m")
+
+			var httpErr *HTTPError
+			switch {
+			case tt.wantStatus == 0 && !tt.wantCtxErr:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case tt.wantStatus != 0 && (!errors.As(err, &httpErr) || httpErr.StatusCode != tt.wantStatus):
+				t.Fatalf("got error %v, want status %d", err, tt.wantStatus)
+			case errors.Is(err, context.DeadlineExceeded) != tt.wantCtxErr:
+				t.Fatalf("got error %v, want the context error %v", err, tt.wantCtxErr)
+			}
+			if tt.wantPolls > 0 && polls.Load() != tt.wantPolls {
+				t.Fatalf("polled %d times, want %d", polls.Load(), tt.wantPolls)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+import torch
+import torch.nn as nn
+import torch.nn.functional as F
+import pandas as pd
+import numpy as np
+import random
+import json
+
+
+# seed function for reproducibility
+def random_seed(seed_value):
+    np.random.seed(seed_value)
+    torch.manual_seed(seed_value)
+    random.seed(seed_value)
+    torch.backends.cudnn.deterministic = True
+    torch.backends.cudnn.benchmark = False
+
+
+random_seed(113)
+
+"""
+SECTION 1 : Load and setup data for training
+
+predict both the species and the body mass of a penguin from its bill and flipper measurements
+"""
+
+features = ['bill_length_mm', 'bill_depth_mm', 'flipper_length_mm']
+species = {'Adelie': 0, 'Gentoo': 1, 'Chinstrap': 2}
+
+datatrain = pd.read_csv('datasets/penguins-clean-train.csv')
+datatrain['species'] = datatrain['species'].map(species)
+
+# standardise the body mass so both losses have a similar scale
+mass_mean = datatrain['body_mass_g'].mean()
+mass_std = datatrain['body_mass_g'].std()
+
+X = torch.Tensor(datatrain[features].values).float()
+Y_species = torch.Tensor(datatrain['species'].values).long()
+Y_mass = torch.Tensor(((datatrain['body_mass_g'] - mass_mean) / mass_std).values).float().unsqueeze(1)
+
+"""
+SECTION 2 : Build and Train Model
+
+Multilayer perceptron with a shared hidden layer and two heads:
+species head : 3 neuron, logits of the species
+body mass head : 1 neuron, standardised body mass
+"""
+
+hl = 20
+lr = 0.01
+num_epoch = 200
+
+
+class MultiHeadNet(nn.Module):
+
+    def __init__(self):
+        super(MultiHeadNet, self).__init__()
+        self.fc1 = nn.Linear(3, hl)
+        self.species = nn.Linear(hl, 3)
+        self.body_mass = nn.Linear(hl, 1)
+
+    def forward(self, x):
+        x = F.relu(self.fc1(x))
+        return self.species(x), self.body_mass(x)
+
+
+net = MultiHeadNet()
+optimizer = torch.optim.Adam(net.parameters(), lr=lr)
+
+for epoch in range(num_epoch):
+    optimizer.zero_grad()
+    species_out, mass_out = net(X)
+    loss = F.cross_entropy(species_out, Y_species) + F.mse_loss(mass_out, Y_mass)
+    loss.backward()
+    optimizer.step()
+    if (epoch + 1) % 20 == 0:
+        print('Epoch [%d/%d] Loss: %.4f' % (epoch + 1, num_epoch, loss.item()))
+
+"""
+SECTION 3 : Save the artefacts
+
+J.A.M.S returns a single tensor per model, so the heads are concatenated by a wrapper before
+scripting: columns 0-2 hold the species logits and column 3 the standardised body mass. The Go
+client splits them again with types.Heads, and a postprocessing "linear" step with
+multiplier=mass_std and offset=mass_mean maps the body mass back to grams.
+"""
+
+
+class ConcatHeads(nn.Module):
+
+    def __init__(self, net):
+        super(ConcatHeads, self).__init__()
+        self.net = net
+
+    def forward(self, x):
+        species_out, mass_out = self.net(x)
+        return torch.cat([species_out, mass_out], dim=1)
+
+
+datatest = pd.read_csv('datasets/penguins-clean-test.csv')
+sample_input = datatest[features].head(10).to_dict(orient='list')
+with open("torch_multihead_input.json", "w") as outfile:
+    json.dump(sample_input, outfile)
+with open("torch_multihead_postprocessing.json", "w") as outfile:
+    json.dump({"models": {"torch-penguin_multihead": {"steps": [
+        {"type": "heads", "heads": [{"name": "species", "width": 3}, {"name": "body_mass", "width": 1}]},
+        {"type": "linear", "output": "body_mass", "multiplier": float(mass_std), "offset": float(mass_mean)},
+    ]}}}, outfile, indent=2)
+
+script_module = torch.jit.script(ConcatHeads(net))
+script_module.save("torch_penguin_multihead.pt")